@@ -0,0 +1,80 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// SetAdminToken sets the bearer token required by the admin endpoints.
+// The admin endpoints are disabled while the token is empty.
+func (s *Server) SetAdminToken(token string) {
+	s.adminToken = token
+}
+
+// requireAdmin wraps a handler so that it only runs for requests carrying the
+// admin bearer token.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			writeError(w, http.StatusNotFound, CodeNotFound, "admin endpoints are disabled")
+			return
+		}
+		token := bearerToken(r)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			writeError(w, http.StatusUnauthorized, CodeUnauthorized, "admin token required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// adminGameHandler returns the full, unredacted game state, including every
+// player's hand. It is the only endpoint that serializes a game.Game.
+func (s *Server) adminGameHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+
+	body, err := g.ToJSON()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "could not encode game state")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, body)
+}
+
+// DebugRequest is the body of a request to switch a game's debug logging.
+type DebugRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// DebugResponse says whether debug logging is on for a game.
+type DebugResponse struct {
+	GameID  string `json:"gameID"`
+	Enabled bool   `json:"enabled"`
+}
+
+// adminDebugHandler switches debug logging on or off for one game. Its debug
+// records, such as every event, are then logged whatever the server's level,
+// so a game can be looked into without a restart.
+func (s *Server) adminDebugHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+	var req DebugRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	g.SetDebug(req.Enabled)
+	slog.Info("game debug logging switched", "game_id", g.ID, "enabled", req.Enabled)
+	writeJSON(w, http.StatusOK, DebugResponse{GameID: g.ID, Enabled: g.Debug()})
+}
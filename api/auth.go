@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"nuclear-war-game-server/game"
+)
+
+// bearerToken returns the token from the request's Authorization header, or ""
+// if there is none.
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+}
+
+// authenticatePlayer works out which player is making the request from their
+// bearer token. It returns "" and true for a spectator token, and false when
+// no token was sent or the token does not belong to this game.
+// Every authenticated request counts as a sign that the player is still
+// connected, and keeps a spectator token from expiring.
+func authenticatePlayer(r *http.Request, g *game.Game) (string, bool) {
+	return authenticateToken(r, g, bearerToken(r))
+}
+
+// authenticateToken is authenticatePlayer for a token that did not come from
+// the Authorization header.
+func authenticateToken(r *http.Request, g *game.Game, token string) (string, bool) {
+	if g.IsSpectatorToken(token) {
+		g.MarkSpectatorSeen(token, time.Now())
+		return "", true
+	}
+	playerID, ok := g.PlayerForToken(token)
+	if ok {
+		g.MarkSeen(playerID, time.Now())
+		notePlayer(r, playerID)
+	}
+	return playerID, ok
+}
+
+// requirePlayer returns the ID of the player making the request, writing a 401
+// response and returning false if the request does not carry a valid player token.
+func requirePlayer(w http.ResponseWriter, r *http.Request, g *game.Game) (string, bool) {
+	playerID, ok := authenticatePlayer(r, g)
+	if !ok || playerID == "" {
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, "a valid player token is required (Authorization: Bearer <token>)")
+		return "", false
+	}
+	return playerID, true
+}
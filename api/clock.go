@@ -0,0 +1,39 @@
+package api
+
+import (
+	"time"
+
+	"nuclear-war-game-server/game"
+)
+
+// turnClockInterval is how often the scheduler checks the games' turn clocks.
+const turnClockInterval = time.Second
+
+// runTurnClock acts on expired turn clocks and lets bots move until the
+// server begins to shut down. Games must not move on after they are saved.
+func (s *Server) runTurnClock(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopping:
+			return
+		case now := <-ticker.C:
+			s.tickGames(now)
+		}
+	}
+}
+
+// tickGames runs the scheduler once over every game.
+func (s *Server) tickGames(now time.Time) {
+	s.mu.Lock()
+	games := make([]*game.Game, 0, len(s.games))
+	for _, g := range s.games {
+		games = append(games, g)
+	}
+	s.mu.Unlock()
+
+	for _, g := range games {
+		g.Tick(now)
+	}
+}
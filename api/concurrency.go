@@ -0,0 +1,236 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"nuclear-war-game-server/game"
+)
+
+const (
+	// idempotencyTTL is how long the result of a keyed request is kept for replay.
+	idempotencyTTL = 24 * time.Hour
+	// maxIdempotencyKeys bounds the number of results kept across all games.
+	maxIdempotencyKeys = 10000
+	// maxIdempotencyKeyLength is the longest Idempotency-Key accepted.
+	maxIdempotencyKeyLength = 255
+)
+
+// errInvalidIfMatch is returned by applyAction when the If-Match header does
+// not name a game version.
+var errInvalidIfMatch = errors.New(`If-Match must be a game version or an ETag from GET /games/{gameID}`)
+
+// applyAction applies a to the game, honouring the request's If-Match header.
+// If-Match carries the version of the view the player acted on, either bare
+// or as the ETag the view was served with; the action is rejected with
+// game.ErrVersionMismatch if the game has changed since then.
+func applyAction(r *http.Request, g *game.Game, a game.Action) error {
+	noteAction(r, string(a.Type()))
+	version, ok, err := ifMatchVersion(r.Header.Get("If-Match"))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return g.Apply(a)
+	}
+	return g.ApplyAtVersion(a, version)
+}
+
+// ifMatchVersion parses an If-Match header. ok is false if the header is
+// missing or "*", so any version will do.
+func ifMatchVersion(header string) (version uint64, ok bool, err error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	// viewETag renders as "<version>-<digest>"; only the version matters here.
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		tag = tag[:i]
+	}
+	version, err = strconv.ParseUint(tag, 10, 64)
+	if err != nil {
+		return 0, false, errInvalidIfMatch
+	}
+	return version, true, nil
+}
+
+// recordedResponse is the result of a request made with an Idempotency-Key.
+type recordedResponse struct {
+	key         string        // Idempotency-Key, scoped to the game and player
+	fingerprint string        // Method, path and body digest of the original request
+	expires     time.Time     // When the result may be forgotten
+	done        chan struct{} // Closed once the result below is filled in
+	status      int
+	header      http.Header
+	body        []byte
+	errorCode   string // Error code of the response, for the request log
+	discarded   bool   // The original failed on the server's side and may be retried
+}
+
+// idempotencyCache holds the results of keyed requests, oldest first. Every
+// entry lives for the same time, so the oldest is always the first to expire.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	entries map[string]*recordedResponse
+	order   []*recordedResponse // May still hold results that were discarded
+}
+
+func newIdempotencyCache() *idempotencyCache {
+	return &idempotencyCache{entries: make(map[string]*recordedResponse)}
+}
+
+// claim returns the result recorded under key, or records a new, unfinished
+// one and returns it with owner set, in which case the caller must finish it.
+func (c *idempotencyCache) claim(key, fingerprint string, now time.Time) (entry *recordedResponse, owner bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.order) > 0 {
+		oldest := c.order[0]
+		live := c.entries[oldest.key] == oldest
+		if live && now.Before(oldest.expires) && len(c.entries) < maxIdempotencyKeys {
+			break
+		}
+		if live {
+			delete(c.entries, oldest.key)
+		}
+		c.order = c.order[1:]
+	}
+
+	if entry, ok := c.entries[key]; ok {
+		return entry, false
+	}
+	entry = &recordedResponse{key: key, fingerprint: fingerprint, expires: now.Add(idempotencyTTL), done: make(chan struct{})}
+	c.entries[key] = entry
+	c.order = append(c.order, entry)
+	return entry, true
+}
+
+// finish records the response to a claimed key and wakes any duplicate
+// requests waiting for it. Server errors are not kept, so the client can retry.
+func (c *idempotencyCache) finish(entry *recordedResponse, rec *responseRecorder) {
+	c.mu.Lock()
+	entry.status, entry.header, entry.body = rec.status, rec.header, rec.body.Bytes()
+	entry.errorCode = rec.errorCode
+	if entry.status >= http.StatusInternalServerError {
+		entry.discarded = true
+		if c.entries[entry.key] == entry {
+			delete(c.entries, entry.key)
+		}
+	}
+	c.mu.Unlock()
+	close(entry.done)
+}
+
+// responseRecorder buffers a handler's response so it can be both sent and
+// kept. It is not wrapped around the real writer, so it holds on to the
+// response's error code for writeRecorded to pass on to the request log.
+type responseRecorder struct {
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	errorCode string
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// writeRecorded sends a recorded response.
+func writeRecorded(w http.ResponseWriter, status int, header http.Header, body []byte, errorCode string) {
+	if errorCode != "" {
+		noteErrorCode(w, errorCode)
+	}
+	for name, values := range header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// idempotent lets clients safely retry an action by sending an
+// Idempotency-Key header. The first request with a key is handled as usual
+// and its response kept; later requests from the same player with the same
+// key get that response back, marked with Idempotent-Replayed, without the
+// action being applied again. A duplicate that arrives while the first is
+// still being handled waits for it. Reusing a key for a different request is
+// rejected with 422.
+func (s *Server) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		token := bearerToken(r)
+		if key == "" || token == "" {
+			// Without a token the request is rejected anyway, and keys are
+			// only ever scoped to a player.
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		fingerprint := fmt.Sprintf("%s %s %x", r.Method, r.URL.Path, sum)
+		scoped := mux.Vars(r)["gameID"] + "\x00" + token + "\x00" + key
+
+		for {
+			entry, owner := s.idempotency.claim(scoped, fingerprint, time.Now())
+			if owner {
+				rec := &responseRecorder{header: make(http.Header)}
+				next(rec, r)
+				if rec.status == 0 {
+					rec.status = http.StatusOK
+				}
+				s.idempotency.finish(entry, rec)
+				writeRecorded(w, rec.status, rec.header, rec.body.Bytes(), rec.errorCode)
+				return
+			}
+			if entry.fingerprint != fingerprint {
+				writeError(w, http.StatusUnprocessableEntity, CodeIdempotencyKeyReused, "Idempotency-Key was already used for a different request")
+				return
+			}
+
+			select {
+			case <-entry.done:
+			case <-r.Context().Done():
+				return
+			}
+			if entry.discarded {
+				continue
+			}
+			header := entry.header.Clone()
+			header.Set("Idempotent-Replayed", "true")
+			writeRecorded(w, entry.status, header, entry.body, entry.errorCode)
+			return
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nuclear-war-game-server/game"
+)
+
+// startedGame seats two players in a started game on s and returns it with
+// their tokens.
+func startedGame(t *testing.T, s *Server) (g *game.Game, p1, p2 *game.Player, token1, token2 string) {
+	t.Helper()
+	g = game.NewGame()
+	p1, _ = g.AddPlayer("Player 1")
+	p2, _ = g.AddPlayer("Player 2")
+	s.games[g.ID] = g
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("failed to start game: %v", err)
+	}
+	playOpeningSecrets(t, g)
+	return g, p1, p2, issueToken(t, g, p1.ID), issueToken(t, g, p2.ID)
+}
+
+// keyedAction sends an action with an Idempotency-Key.
+func keyedAction(s *Server, g *game.Game, action, token, key, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/%s", g.ID, action), strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, authorize(req, token))
+	return rr
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	t.Run("duplicates replay the recorded result", func(t *testing.T) {
+		s := NewServer()
+		g, _, _, token1, _ := startedGame(t, s)
+		before := g.CurrentVersion()
+
+		first := keyedAction(s, g, "pass", token1, "pass-1", "")
+		if first.Code != http.StatusOK {
+			t.Fatalf("expected status %d, but got %d: %s", http.StatusOK, first.Code, first.Body.String())
+		}
+		retry := keyedAction(s, g, "pass", token1, "pass-1", "")
+		if retry.Code != http.StatusOK {
+			t.Fatalf("expected the retry to get status %d, but got %d: %s", http.StatusOK, retry.Code, retry.Body.String())
+		}
+		if retry.Body.String() != first.Body.String() {
+			t.Errorf("expected the retry to get the original response back")
+		}
+		if retry.Header().Get("Idempotent-Replayed") != "true" {
+			t.Errorf("expected the retry to be marked as replayed")
+		}
+		if got := g.CurrentVersion(); got != before+1 {
+			t.Errorf("expected the pass to be applied once (version %d), but the game is at version %d", before+1, got)
+		}
+	})
+
+	t.Run("rejections are replayed too", func(t *testing.T) {
+		s := NewServer()
+		g, _, _, _, token2 := startedGame(t, s)
+
+		// It is Player 1's turn, so Player 2's pass is rejected both times,
+		// even though it would be legal by the time of the retry.
+		if rr := keyedAction(s, g, "pass", token2, "early", ""); rr.Code != http.StatusConflict {
+			t.Fatalf("expected status %d, but got %d", http.StatusConflict, rr.Code)
+		}
+		if err := g.PassTurn(g.PlayerOrder[0]); err != nil {
+			t.Fatalf("failed to pass: %v", err)
+		}
+		if rr := keyedAction(s, g, "pass", token2, "early", ""); rr.Code != http.StatusConflict {
+			t.Errorf("expected the rejection to be replayed with status %d, but got %d", http.StatusConflict, rr.Code)
+		}
+	})
+
+	t.Run("keys are scoped to the player", func(t *testing.T) {
+		s := NewServer()
+		g, _, _, token1, token2 := startedGame(t, s)
+
+		if rr := keyedAction(s, g, "pass", token1, "same", ""); rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+		}
+		rr := keyedAction(s, g, "pass", token2, "same", "")
+		if rr.Code != http.StatusOK || rr.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("expected Player 2's pass to be applied, but got status %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("reusing a key for a different request is rejected", func(t *testing.T) {
+		s := NewServer()
+		g, _, p2, token1, _ := startedGame(t, s)
+
+		if rr := keyedAction(s, g, "pass", token1, "k", ""); rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+		}
+		rr := keyedAction(s, g, "attack", token1, "k", fmt.Sprintf(`{"targetID": %q}`, p2.ID))
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d, but got %d", http.StatusUnprocessableEntity, rr.Code)
+		}
+	})
+
+	t.Run("concurrent duplicates apply once", func(t *testing.T) {
+		s := NewServer()
+		g, _, _, token1, _ := startedGame(t, s)
+		before := g.CurrentVersion()
+
+		var wg sync.WaitGroup
+		codes := make([]int, 8)
+		for i := range codes {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				codes[i] = keyedAction(s, g, "pass", token1, "racy", "").Code
+			}(i)
+		}
+		wg.Wait()
+
+		for i, code := range codes {
+			if code != http.StatusOK {
+				t.Errorf("request %d: expected status %d, but got %d", i, http.StatusOK, code)
+			}
+		}
+		if got := g.CurrentVersion(); got != before+1 {
+			t.Errorf("expected the pass to be applied once (version %d), but the game is at version %d", before+1, got)
+		}
+	})
+}
+
+func TestIdempotencyCache(t *testing.T) {
+	c := newIdempotencyCache()
+	now := time.Now()
+
+	entry, owner := c.claim("k", "fp", now)
+	if !owner {
+		t.Fatal("expected the first claim to own the key")
+	}
+	c.finish(entry, &responseRecorder{header: make(http.Header), status: http.StatusInternalServerError})
+	if _, owner := c.claim("k", "fp", now); !owner {
+		t.Error("expected a server error not to be kept")
+	}
+
+	if _, owner := c.claim("k", "fp", now.Add(time.Minute)); owner {
+		t.Error("expected a claimed key to be kept")
+	}
+	if _, owner := c.claim("k", "fp", now.Add(idempotencyTTL)); !owner {
+		t.Error("expected the key to be forgotten once it expires")
+	}
+	if len(c.order) != 1 {
+		t.Errorf("expected expired keys to be pruned, but %d are kept", len(c.order))
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	s := NewServer()
+	g, _, _, token1, token2 := startedGame(t, s)
+
+	// Player 2 fetches their view, then Player 1 passes.
+	req, _ := http.NewRequest("GET", "/games/"+g.ID, nil)
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, authorize(req, token2))
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected the view to carry an ETag")
+	}
+	stale := g.CurrentVersion()
+	if err := g.PassTurn(g.PlayerOrder[0]); err != nil {
+		t.Fatalf("failed to pass: %v", err)
+	}
+
+	pass := func(token, ifMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/pass", g.ID), nil)
+		req.Header.Set("If-Match", ifMatch)
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, authorize(req, token))
+		return rr
+	}
+
+	if rr := pass(token2, etag); rr.Code != http.StatusConflict {
+		t.Errorf("expected a stale ETag to get status %d, but got %d: %s", http.StatusConflict, rr.Code, rr.Body.String())
+	}
+	if rr := pass(token2, fmt.Sprint(stale)); rr.Code != http.StatusConflict {
+		t.Errorf("expected a stale version to get status %d, but got %d", http.StatusConflict, rr.Code)
+	}
+	if rr := pass(token2, "not-a-version"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected a malformed If-Match to get status %d, but got %d", http.StatusBadRequest, rr.Code)
+	}
+	if rr := pass(token2, fmt.Sprintf(`"%d"`, g.CurrentVersion())); rr.Code != http.StatusOK {
+		t.Errorf("expected the current version to be accepted, but got status %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := pass(token1, "*"); rr.Code != http.StatusOK {
+		t.Errorf("expected If-Match: * to be accepted, but got status %d: %s", rr.Code, rr.Body.String())
+	}
+}
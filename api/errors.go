@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"nuclear-war-game-server/game"
+)
+
+// ErrorResponse is the body of every error response. Code is stable and
+// meant for programs; Message is meant for people and may change.
+type ErrorResponse struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Error codes raised by the API layer itself.
+const (
+	CodeGameNotFound         = "game_not_found"
+	CodeNotFound             = "not_found"
+	CodeUnauthorized         = "unauthorized"
+	CodeWrongPassword        = "wrong_password"
+	CodeInvalidBody          = "invalid_body"
+	CodeInvalidParameter     = "invalid_parameter"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeInternal             = "internal_error"
+	CodeShuttingDown         = "shutting_down"
+	CodeStoreUnavailable     = "store_unavailable"
+	CodeRateLimited          = "rate_limited"
+	CodeBodyTooLarge         = "body_too_large"
+)
+
+// gameErrors maps the engine's sentinel errors to their code and status.
+var gameErrors = []struct {
+	err    error
+	code   string
+	status int
+}{
+	{game.ErrUnknownAction, "unknown_action", http.StatusBadRequest},
+	{game.ErrInvalidPhase, "invalid_phase", http.StatusConflict},
+	{game.ErrPlayerNotFound, "player_not_found", http.StatusBadRequest},
+	{game.ErrNotHost, "not_host", http.StatusForbidden},
+	{game.ErrNotYourTurn, "not_your_turn", http.StatusConflict},
+	{game.ErrPlayerEliminated, "player_eliminated", http.StatusBadRequest},
+	{game.ErrInvalidTarget, "invalid_target", http.StatusBadRequest},
+	{game.ErrNotReady, "not_ready", http.StatusBadRequest},
+	{game.ErrNotEnoughPlayers, "not_enough_players", http.StatusBadRequest},
+	{game.ErrGameFull, "game_full", http.StatusConflict},
+	{game.ErrInvalidName, "invalid_name", http.StatusBadRequest},
+	{game.ErrInvalidOptions, "invalid_options", http.StatusBadRequest},
+	{game.ErrCardNotInHand, "card_not_in_hand", http.StatusBadRequest},
+	{game.ErrCardNotPlayable, "card_not_playable", http.StatusBadRequest},
+	{game.ErrInvalidLocation, "invalid_location", http.StatusBadRequest},
+	{game.ErrSlotOccupied, "slot_occupied", http.StatusBadRequest},
+	{game.ErrNotArmed, "not_armed", http.StatusBadRequest},
+	{game.ErrVersionMismatch, "version_mismatch", http.StatusConflict},
+	{game.ErrInvalidState, "invalid_state", http.StatusConflict},
+	{errInvalidIfMatch, CodeInvalidParameter, http.StatusBadRequest},
+}
+
+// writeError writes an error raised by the API layer.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, ErrorResponse{Code: code, Message: message})
+}
+
+// writeGameError writes an error returned by the engine, with the code and
+// status of its sentinel error. Anything else is an internal error.
+func writeGameError(w http.ResponseWriter, err error) {
+	status, body := gameErrorResponse(err)
+	writeJSON(w, status, body)
+}
+
+// gameErrorResponse returns the status and body for an error returned by the
+// engine.
+func gameErrorResponse(err error) (int, ErrorResponse) {
+	body := ErrorResponse{Code: CodeInternal, Message: err.Error()}
+	var details *game.Error
+	if errors.As(err, &details) {
+		body.Details = details.Details
+	}
+	for _, e := range gameErrors {
+		if errors.Is(err, e.err) {
+			body.Code = e.code
+			return e.status, body
+		}
+	}
+	return http.StatusInternalServerError, body
+}
+
+// writeGameNotFound writes the response for a game ID or join code that does
+// not name a game.
+func writeGameNotFound(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusNotFound, CodeGameNotFound, err.Error())
+}
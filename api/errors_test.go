@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nuclear-war-game-server/game"
+)
+
+// decodeError parses an error response, failing the test if the status is
+// not want or the body is not an ErrorResponse.
+func decodeError(t *testing.T, rr *httptest.ResponseRecorder, want int) ErrorResponse {
+	t.Helper()
+	if rr.Code != want {
+		t.Fatalf("expected status %d, but got %d: %s", want, rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected a JSON error, but got Content-Type '%s'", ct)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("could not parse error body: %v", err)
+	}
+	if body.Message == "" {
+		t.Error("expected the error to carry a message")
+	}
+	return body
+}
+
+func TestErrorResponses(t *testing.T) {
+	s := NewServer()
+	g, _, _, token1, token2 := startedGame(t, s)
+
+	send := func(method, url, token, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, strings.NewReader(body))
+		if token != "" {
+			authorize(req, token)
+		}
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("engine errors carry their code and details", func(t *testing.T) {
+		rr := send("POST", fmt.Sprintf("/games/%s/play", g.ID), token1, `{"cardID": "no-such-card", "location": "face_up"}`)
+		body := decodeError(t, rr, http.StatusBadRequest)
+		if body.Code != "card_not_in_hand" || body.Details["cardID"] != "no-such-card" {
+			t.Errorf("expected card_not_in_hand for no-such-card, but got %+v", body)
+		}
+	})
+
+	t.Run("acting out of turn", func(t *testing.T) {
+		body := decodeError(t, send("POST", fmt.Sprintf("/games/%s/pass", g.ID), token2, ""), http.StatusConflict)
+		if body.Code != "not_your_turn" || body.Details["currentPlayerID"] != g.PlayerOrder[0] {
+			t.Errorf("expected not_your_turn naming player 1, but got %+v", body)
+		}
+	})
+
+	t.Run("unknown games", func(t *testing.T) {
+		body := decodeError(t, send("GET", "/games/no-such-game", "", ""), http.StatusNotFound)
+		if body.Code != CodeGameNotFound {
+			t.Errorf("expected code %s, but got %s", CodeGameNotFound, body.Code)
+		}
+	})
+
+	t.Run("missing tokens", func(t *testing.T) {
+		body := decodeError(t, send("POST", fmt.Sprintf("/games/%s/pass", g.ID), "", ""), http.StatusUnauthorized)
+		if body.Code != CodeUnauthorized {
+			t.Errorf("expected code %s, but got %s", CodeUnauthorized, body.Code)
+		}
+	})
+
+	t.Run("malformed bodies", func(t *testing.T) {
+		body := decodeError(t, send("POST", fmt.Sprintf("/games/%s/attack", g.ID), token1, "{"), http.StatusBadRequest)
+		if body.Code != CodeInvalidBody {
+			t.Errorf("expected code %s, but got %s", CodeInvalidBody, body.Code)
+		}
+	})
+
+	t.Run("a game that cannot move on", func(t *testing.T) {
+		status, body := gameErrorResponse(&game.Error{Err: game.ErrInvalidState, Message: "illegal transition"})
+		if status != http.StatusConflict || body.Code != "invalid_state" {
+			t.Errorf("expected 409 invalid_state, but got %d %+v", status, body)
+		}
+	})
+
+	t.Run("joining a full game", func(t *testing.T) {
+		full := game.NewGame()
+		for i := 0; i < game.MaxPlayers; i++ {
+			full.AddPlayer(fmt.Sprintf("Player %d", i+1))
+		}
+		s.games[full.ID] = full
+		body := decodeError(t, send("POST", fmt.Sprintf("/games/%s/join", full.ID), "", `{"playerName": "Late"}`), http.StatusConflict)
+		if body.Code != "game_full" {
+			t.Errorf("expected code game_full, but got %s", body.Code)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"nuclear-war-game-server/game"
+)
+
+// resyncMessage explains a resync notice.
+const resyncMessage = "events were missed and are no longer kept; take the view that follows as the whole game"
+
+// ResyncNotice tells a client that the events after the last one it saw are
+// no longer kept, so some were lost. The view that follows is complete, and
+// the events resume at FirstEventID.
+type ResyncNotice struct {
+	Message      string `json:"message"`
+	LastEventID  uint64 `json:"lastEventId"`  // The last event the client saw
+	FirstEventID uint64 `json:"firstEventId"` // The oldest event still kept
+}
+
+// eventsSince returns the events after seq that are still kept and, if some
+// before them are not, the notice telling the client so.
+func eventsSince(g *game.Game, seq uint64) ([]game.Event, *ResyncNotice) {
+	events := g.EventsSince(seq)
+	next := g.FirstSeq()
+	if len(events) > 0 {
+		next = events[0].Seq
+	}
+	if seq+1 >= next {
+		return events, nil
+	}
+	return events, &ResyncNotice{Message: resyncMessage, LastEventID: seq, FirstEventID: next}
+}
+
+// feed keeps track of what one client following a game has been sent. The
+// event stream and the WebSocket share it, and only frame its messages
+// differently.
+type feed struct {
+	game     *game.Game
+	playerID string // "" for spectators
+	lastSeq  uint64 // Sequence number of the last event sent
+}
+
+// feedWriter frames a feed's messages for one transport.
+type feedWriter interface {
+	sendResync(notice *ResyncNotice) error
+	sendEvent(ev game.Event) error
+	sendView(view interface{}) error
+	// sendUnseated tells a player they are no longer seated in the game.
+	sendUnseated() error
+}
+
+// delayed reports whether the client is a spectator of a delayed game.
+func (f *feed) delayed() bool {
+	return f.playerID == "" && f.game.Summary().Options.SpectatorDelaySeconds > 0
+}
+
+// refresher returns a channel that ticks every second for spectators of a
+// delayed game, who see the game move on without any new changes, and nil for
+// everyone else. stop releases the ticker.
+func (f *feed) refresher() (refresh <-chan time.Time, stop func()) {
+	if !f.delayed() {
+		return nil, func() {}
+	}
+	t := time.NewTicker(time.Second)
+	return t.C, t.Stop
+}
+
+// push sends the events the client has not seen yet, followed by its fresh
+// view. Spectators of a delayed game only get the delayed view, since live
+// events would give the delay away.
+func (f *feed) push(w feedWriter) error {
+	if !f.delayed() {
+		events, resync := eventsSince(f.game, f.lastSeq)
+		if resync != nil {
+			if err := w.sendResync(resync); err != nil {
+				return err
+			}
+		}
+		for _, ev := range events {
+			if err := w.sendEvent(ev); err != nil {
+				return err
+			}
+			f.lastSeq = ev.Seq
+		}
+	}
+	if f.playerID != "" && !f.game.HasPlayer(f.playerID) {
+		w.sendUnseated()
+		return fmt.Errorf("player %s left the game", f.playerID)
+	}
+	return f.refresh(w)
+}
+
+// refresh sends the client its fresh view.
+func (f *feed) refresh(w feedWriter) error {
+	return w.sendView(viewFor(f.game, f.playerID))
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"nuclear-war-game-server/game"
+)
+
+// HealthResponse is the body of a successful /healthz or /readyz response.
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// VersionResponse describes the running build.
+type VersionResponse struct {
+	Module       string `json:"module"`
+	Version      string `json:"version"`                // Module version; "(devel)" for a local build
+	Revision     string `json:"revision,omitempty"`     // VCS revision the binary was built from
+	RevisionTime string `json:"revisionTime,omitempty"` // Commit time of Revision
+	Modified     bool   `json:"modified,omitempty"`     // Whether the working tree had local changes
+	GoVersion    string `json:"goVersion"`
+	CatalogHash  string `json:"catalogHash"` // Digest of the card catalog new games are dealt from
+}
+
+// buildInfo reads the binary's build information once.
+var buildInfo = sync.OnceValue(func() VersionResponse {
+	var v VersionResponse
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v
+	}
+	v.Module, v.Version, v.GoVersion = info.Main.Path, info.Main.Version, info.GoVersion
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			v.Revision = setting.Value
+		case "vcs.time":
+			v.RevisionTime = setting.Value
+		case "vcs.modified":
+			v.Modified = setting.Value == "true"
+		}
+	}
+	return v
+})
+
+// healthzHandler answers as long as the process is serving requests.
+func (s *Server) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// readyzHandler answers whether the server should be sent traffic: it is not
+// shutting down and its store can save games.
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		writeError(w, http.StatusServiceUnavailable, CodeShuttingDown, "the server is shutting down")
+		return
+	}
+	if err := s.store.Ping(); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{
+			Code:    CodeStoreUnavailable,
+			Message: "the store cannot save games",
+			Details: map[string]string{"store": err.Error()},
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ready"})
+}
+
+// versionHandler describes the running build and its card catalog.
+func (s *Server) versionHandler(w http.ResponseWriter, r *http.Request) {
+	v := buildInfo()
+	v.CatalogHash = game.CurrentCatalog().Hash()
+	writeJSON(w, http.StatusOK, v)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nuclear-war-game-server/game"
+	"nuclear-war-game-server/store"
+)
+
+// brokenStore is a store that cannot be reached.
+type brokenStore struct {
+	*store.Memory
+}
+
+func (*brokenStore) Ping() error { return errors.New("disk full") }
+
+func get(s *Server, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestHealthHandlers(t *testing.T) {
+	t.Run("healthz", func(t *testing.T) {
+		if rr := get(NewServer(), "/healthz"); rr.Code != http.StatusOK {
+			t.Errorf("expected status %d, but got %d", http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("readyz with a working store", func(t *testing.T) {
+		if rr := get(NewServer(), "/readyz"); rr.Code != http.StatusOK {
+			t.Errorf("expected status %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("readyz with a broken store", func(t *testing.T) {
+		s := NewServer()
+		s.SetStore(&brokenStore{store.NewMemory()})
+		body := decodeError(t, get(s, "/readyz"), http.StatusServiceUnavailable)
+		if body.Code != CodeStoreUnavailable || body.Details["store"] != "disk full" {
+			t.Errorf("expected the store's error, but got %+v", body)
+		}
+	})
+
+	t.Run("readyz while shutting down", func(t *testing.T) {
+		s := NewServer()
+		s.beginShutdown()
+		if body := decodeError(t, get(s, "/readyz"), http.StatusServiceUnavailable); body.Code != CodeShuttingDown {
+			t.Errorf("expected code %s, but got %s", CodeShuttingDown, body.Code)
+		}
+		if rr := get(s, "/healthz"); rr.Code != http.StatusOK {
+			t.Errorf("expected the process to stay healthy while shutting down, but got %d", rr.Code)
+		}
+	})
+}
+
+func TestVersionHandler(t *testing.T) {
+	rr := get(NewServer(), "/version")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	var v VersionResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &v); err != nil {
+		t.Fatalf("could not parse response: %v", err)
+	}
+	if v.GoVersion == "" {
+		t.Error("expected the Go version to be reported")
+	}
+	if v.CatalogHash != game.CurrentCatalog().Hash() {
+		t.Errorf("expected the catalog hash %s, but got %s", game.CurrentCatalog().Hash(), v.CatalogHash)
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"nuclear-war-game-server/game"
+)
+
+// ReadyRequest is the optional body for a ready request. Without a body the
+// player is marked ready.
+type ReadyRequest struct {
+	Ready *bool `json:"ready,omitempty"`
+}
+
+// PlayerTargetRequest names the player a host action is aimed at.
+type PlayerTargetRequest struct {
+	PlayerID string `json:"playerID"`
+}
+
+// UpdateOptionsRequest is the expected body for an options request.
+type UpdateOptionsRequest struct {
+	Options game.GameOptions `json:"options"`
+}
+
+func (s *Server) readyHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+
+	playerID, ok := requirePlayer(w, r, g)
+	if !ok {
+		return
+	}
+
+	var req ReadyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeBodyError(w, err)
+		return
+	}
+	ready := req.Ready == nil || *req.Ready
+
+	if err := applyAction(r, g, game.ReadyAction{PlayerID: playerID, Ready: ready}); err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	writeView(w, http.StatusOK, g, playerID)
+}
+
+func (s *Server) kickHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+
+	hostID, ok := requirePlayer(w, r, g)
+	if !ok {
+		return
+	}
+
+	var req PlayerTargetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	if err := applyAction(r, g, game.KickPlayerAction{PlayerID: hostID, TargetID: req.PlayerID}); err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	writeView(w, http.StatusOK, g, hostID)
+}
+
+func (s *Server) transferHostHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+
+	hostID, ok := requirePlayer(w, r, g)
+	if !ok {
+		return
+	}
+
+	var req PlayerTargetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	if err := applyAction(r, g, game.TransferHostAction{PlayerID: hostID, TargetID: req.PlayerID}); err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	writeView(w, http.StatusOK, g, hostID)
+}
+
+func (s *Server) updateOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+
+	hostID, ok := requirePlayer(w, r, g)
+	if !ok {
+		return
+	}
+
+	var req UpdateOptionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	if err := applyAction(r, g, game.UpdateOptionsAction{PlayerID: hostID, Options: req.Options}); err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	writeView(w, http.StatusOK, g, hostID)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"nuclear-war-game-server/game"
+
+	"github.com/gorilla/mux"
+)
+
+// maxJoinCodeAttempts bounds the retries when a new join code collides with
+// one that is still in use.
+const maxJoinCodeAttempts = 10
+
+// registerGame gives g a join code that no other open game is using and adds
+// it to the server's games, in one step, so a game is never listed without
+// its code. Codes of games that have since started are free to be handed out
+// again.
+func (s *Server) registerGame(g *game.Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < maxJoinCodeAttempts; i++ {
+		code, err := game.NewJoinCode()
+		if err != nil {
+			return err
+		}
+		if other, ok := s.joinCodes[code]; ok {
+			if og, ok := s.games[other]; ok && og.HasJoinCode(code) {
+				continue
+			}
+		}
+		s.joinCodes[code] = g.ID
+		g.SetJoinCode(code)
+		s.games[g.ID] = g
+		return nil
+	}
+	return fmt.Errorf("could not find a free join code")
+}
+
+// getGameFromJoinCode resolves the join code in the request URL to its game.
+// A code stops resolving once its game has started.
+func (s *Server) getGameFromJoinCode(r *http.Request) (*game.Game, error) {
+	code := game.NormalizeJoinCode(mux.Vars(r)["code"])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gameID, ok := s.joinCodes[code]
+	if !ok {
+		return nil, fmt.Errorf("join code not found")
+	}
+	g, ok := s.games[gameID]
+	if !ok || !g.HasJoinCode(code) {
+		delete(s.joinCodes, code)
+		return nil, fmt.Errorf("join code has expired")
+	}
+	return g, nil
+}
+
+// joinCodeHandler describes the game a join code belongs to, so a client can
+// show it before the player joins.
+func (s *Server) joinCodeHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromJoinCode(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g.Summary())
+}
+
+// joinByCodeHandler joins the game a join code belongs to.
+func (s *Server) joinByCodeHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromJoinCode(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+	s.joinGame(w, r, g)
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+
+	"nuclear-war-game-server/game"
+)
+
+func (s *Server) leaveHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+
+	playerID, ok := requirePlayer(w, r, g)
+	if !ok {
+		return
+	}
+
+	if err := applyAction(r, g, game.LeaveAction{PlayerID: playerID}); err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	// The player is no longer seated, so they get the spectator view.
+	writeView(w, http.StatusOK, g, "")
+}
+
+func (s *Server) forfeitHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+
+	playerID, ok := requirePlayer(w, r, g)
+	if !ok {
+		return
+	}
+
+	if err := applyAction(r, g, game.ForfeitAction{PlayerID: playerID}); err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	writeView(w, http.StatusOK, g, playerID)
+}
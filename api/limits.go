@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultMaxBodyBytes caps request bodies until SetLimits says otherwise.
+const defaultMaxBodyBytes = 1 << 20
+
+// limiterSweepInterval is how often idle buckets are dropped, so that clients
+// that have gone away do not hold memory.
+const limiterSweepInterval = time.Minute
+
+// RateLimit is a token bucket: Burst requests may be made at once, and the
+// bucket refills at Rate requests per second. A zero Rate means no limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Limits bounds what a single client may ask of the server.
+type Limits struct {
+	PerIP        RateLimit // Every request, by client IP address
+	PerToken     RateLimit // Every request carrying a player or spectator token, by token
+	Creates      RateLimit // Game creations, by client IP address, on top of PerIP
+	MaxBodyBytes int64     // Largest request body read; 0 means no limit
+}
+
+// SetLimits sets the rate and body limits. It must be called before the
+// server starts serving.
+func (s *Server) SetLimits(l Limits) {
+	s.limits = l
+	s.ipLimiter = newRateLimiter(l.PerIP)
+	s.tokenLimiter = newRateLimiter(l.PerToken)
+	s.createLimiter = newRateLimiter(l.Creates)
+}
+
+// rateLimiter keeps a token bucket per key.
+type rateLimiter struct {
+	limit RateLimit
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time // When tokens was last brought up to date
+}
+
+// newRateLimiter returns a limiter for l, or nil if l has no rate.
+func newRateLimiter(l RateLimit) *rateLimiter {
+	if l.Rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{limit: l, buckets: make(map[string]*bucket)}
+}
+
+// allow takes a token from key's bucket. If the bucket is empty it returns
+// false and how long until a token is back. A nil limiter allows everything.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	burst := float64(l.limit.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.limit.Rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets that have filled up again, which are no different
+// from new ones.
+// This is an internal function and assumes a lock is already held.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < limiterSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	full := time.Duration(float64(l.limit.Burst) / l.limit.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientIP returns the address a request came from, without its port.
+// Requests over a Unix socket all share the socket's address.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// limit rejects requests over the rate limits with 429 and caps the size of
+// request bodies. Probes and scrapers are never limited, so a busy client
+// cannot take the server out of its load balancer.
+func (s *Server) limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.limits.MaxBodyBytes > 0 && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, s.limits.MaxBodyBytes)
+		}
+		route := unversioned(routeTemplate(r))
+		if quietRoutes[route] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		ip := clientIP(r)
+		if ok, wait := s.ipLimiter.allow(ip, now); !ok {
+			writeRateLimited(w, "ip", wait)
+			return
+		}
+		token := bearerToken(r)
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		if token != "" {
+			if ok, wait := s.tokenLimiter.allow(token, now); !ok {
+				writeRateLimited(w, "token", wait)
+				return
+			}
+		}
+		if r.Method == http.MethodPost && route == "/games" {
+			if ok, wait := s.createLimiter.allow(ip, now); !ok {
+				writeRateLimited(w, "create", wait)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeRateLimited answers a request over a rate limit, telling the client
+// which limit it hit and when to try again.
+func writeRateLimited(w http.ResponseWriter, limit string, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeJSON(w, http.StatusTooManyRequests, ErrorResponse{
+		Code:    CodeRateLimited,
+		Message: "too many requests; slow down",
+		Details: map[string]string{"limit": limit, "retryAfter": strconv.Itoa(seconds)},
+	})
+}
+
+// writeBodyError answers a request whose body could not be read or decoded:
+// 413 if it was larger than the limit, 400 otherwise.
+func writeBodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSON(w, http.StatusRequestEntityTooLarge, ErrorResponse{
+			Code:    CodeBodyTooLarge,
+			Message: fmt.Sprintf("the request body is larger than %d bytes", tooLarge.Limit),
+			Details: map[string]string{"limit": strconv.FormatInt(tooLarge.Limit, 10)},
+		})
+		return
+	}
+	writeError(w, http.StatusBadRequest, CodeInvalidBody, "Invalid request body")
+}
+
+// holdOpen lifts the server's read timeout for a request that stays open
+// long after its body is read, such as a stream or a long poll. Otherwise the
+// connection would be deemed dead, and the request cancelled, once it ran out.
+func holdOpen(w http.ResponseWriter) *http.ResponseController {
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	return rc
+}
+
+// extendWrite gives a request held open d more time, plus the usual write
+// timeout, to write its response.
+func (s *Server) extendWrite(rc *http.ResponseController, d time.Duration) {
+	if s.writeTimeout > 0 {
+		rc.SetWriteDeadline(time.Now().Add(d + s.writeTimeout))
+	}
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nuclear-war-game-server/game"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(RateLimit{Rate: 2, Burst: 2})
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("expected request %d of the burst to be allowed", i+1)
+		}
+	}
+	ok, wait := l.allow("a", now)
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("expected the third request to wait 500ms, but got ok=%v wait=%v", ok, wait)
+	}
+	if ok, _ := l.allow("b", now); !ok {
+		t.Error("expected another key to have its own bucket")
+	}
+	if ok, _ := l.allow("a", now.Add(500*time.Millisecond)); !ok {
+		t.Error("expected the bucket to refill over time")
+	}
+
+	l.allow("a", now.Add(2*limiterSweepInterval))
+	if len(l.buckets) != 1 {
+		t.Errorf("expected the full bucket to be swept, but %d are left", len(l.buckets))
+	}
+
+	var unlimited *rateLimiter
+	if ok, _ := unlimited.allow("a", now); !ok {
+		t.Error("expected a nil limiter to allow everything")
+	}
+}
+
+func TestLimit(t *testing.T) {
+	send := func(s *Server, method, path, addr, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = addr
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("per IP", func(t *testing.T) {
+		s := NewServer()
+		s.SetLimits(Limits{PerIP: RateLimit{Rate: 0.01, Burst: 2}})
+		for i := 0; i < 2; i++ {
+			if rr := send(s, "GET", "/games", "192.0.2.1:1000", ""); rr.Code != http.StatusOK {
+				t.Fatalf("expected request %d to be served, but got %d", i+1, rr.Code)
+			}
+		}
+		rr := send(s, "GET", "/v1/games", "192.0.2.1:2000", "")
+		body := decodeError(t, rr, http.StatusTooManyRequests)
+		if body.Code != CodeRateLimited || body.Details["limit"] != "ip" {
+			t.Errorf("expected the IP limit to be named, but got %+v", body)
+		}
+		if got := rr.Header().Get("Retry-After"); got != "100" {
+			t.Errorf("expected Retry-After: 100, but got %q", got)
+		}
+		if rr := send(s, "GET", "/games", "192.0.2.2:1000", ""); rr.Code != http.StatusOK {
+			t.Errorf("expected another client to be served, but got %d", rr.Code)
+		}
+		if rr := send(s, "GET", "/healthz", "192.0.2.1:1000", ""); rr.Code != http.StatusOK {
+			t.Errorf("expected probes never to be limited, but got %d", rr.Code)
+		}
+	})
+
+	t.Run("per token", func(t *testing.T) {
+		s := NewServer()
+		g, _, _, token1, token2 := startedGame(t, s)
+		s.SetLimits(Limits{PerToken: RateLimit{Rate: 0.01, Burst: 1}})
+		path := fmt.Sprintf("/games/%s", g.ID)
+
+		send(s, "GET", path, "192.0.2.1:1000", token1)
+		body := decodeError(t, send(s, "GET", path, "192.0.2.2:1000", token1), http.StatusTooManyRequests)
+		if body.Details["limit"] != "token" {
+			t.Errorf("expected the token limit to be named, but got %+v", body)
+		}
+		if rr := send(s, "GET", path, "192.0.2.1:1000", token2); rr.Code != http.StatusOK {
+			t.Errorf("expected another player to be served, but got %d", rr.Code)
+		}
+	})
+
+	t.Run("creates", func(t *testing.T) {
+		s := NewServer()
+		s.SetLimits(Limits{Creates: RateLimit{Rate: 0.01, Burst: 1}})
+		if rr := send(s, "POST", "/games", "192.0.2.1:1000", ""); rr.Code != http.StatusCreated {
+			t.Fatalf("expected the first game to be created, but got %d", rr.Code)
+		}
+		body := decodeError(t, send(s, "POST", "/v2/games", "192.0.2.1:1000", ""), http.StatusTooManyRequests)
+		if body.Details["limit"] != "create" {
+			t.Errorf("expected the create limit to be named, but got %+v", body)
+		}
+		if rr := send(s, "GET", "/games", "192.0.2.1:1000", ""); rr.Code != http.StatusOK {
+			t.Errorf("expected other requests to be served, but got %d", rr.Code)
+		}
+	})
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	s := NewServer()
+	g, _, _, token1, _ := startedGame(t, s)
+	s.SetLimits(Limits{MaxBodyBytes: 64})
+
+	post := func(path, token, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if strings.HasSuffix(path, "/play") {
+			req.Header.Set("Idempotency-Key", "big")
+		}
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+		return rr
+	}
+	padding := strings.Repeat(" ", 100)
+
+	for _, tc := range []struct{ path, token string }{
+		{"/games", ""},
+		{fmt.Sprintf("/games/%s/join", g.ID), ""},
+		{fmt.Sprintf("/games/%s/play", g.ID), token1}, // Read whole for the Idempotency-Key
+	} {
+		body := decodeError(t, post(tc.path, tc.token, `{"name": "x"`+padding+`}`), http.StatusRequestEntityTooLarge)
+		if body.Code != CodeBodyTooLarge || body.Details["limit"] != "64" {
+			t.Errorf("%s: expected body_too_large with the limit, but got %+v", tc.path, body)
+		}
+	}
+
+	if rr := post("/games", "", `{"name": "small"}`); rr.Code != http.StatusCreated {
+		t.Errorf("expected a body under the limit to be read, but got %d: %s", rr.Code, rr.Body.String())
+	}
+	decodeError(t, post("/games", "", `{"name": `), http.StatusBadRequest)
+}
+
+func TestTimeouts_LongRequestsOutlastThem(t *testing.T) {
+	s := NewServer()
+	g, p1, p2, _, token2 := startedGame(t, s)
+	ts := httptest.NewUnstartedServer(s.router)
+	ts.Config.ReadTimeout = 200 * time.Millisecond
+	ts.Config.WriteTimeout = 200 * time.Millisecond
+	s.writeTimeout = ts.Config.WriteTimeout
+	ts.Start()
+	defer ts.Close()
+
+	t.Run("event stream", func(t *testing.T) {
+		next := openStream(t, ts, g, "", watcherToken(t, g), "")
+		if ev := next(); ev.event != "view" {
+			t.Fatalf("expected the view first, but got %q", ev.event)
+		}
+		time.Sleep(500 * time.Millisecond)
+		if err := g.Apply(game.PassTurnAction{PlayerID: p1.ID}); err != nil {
+			t.Fatalf("pass failed unexpectedly: %v", err)
+		}
+		if ev := next(); ev.event != string(game.EventTurnPassed) {
+			t.Errorf("expected the pass past the timeouts, but got %q", ev.event)
+		}
+	})
+
+	t.Run("socket", func(t *testing.T) {
+		conn := dialGame(t, ts, g, token2)
+		readUntil(t, conn, g, p2.ID, "view")
+		time.Sleep(500 * time.Millisecond)
+		if err := conn.WriteJSON(SocketCommand{Action: game.ActionPass, RequestID: "late"}); err != nil {
+			t.Fatalf("could not send the command: %v", err)
+		}
+		if reply := readUntil(t, conn, g, p2.ID, "ack"); reply.RequestID != "late" {
+			t.Errorf("expected the reply past the timeouts, but got %+v", reply)
+		}
+	})
+
+	t.Run("long poll", func(t *testing.T) {
+		version := viewVersion(viewFor(g, ""))
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/games/%s?waitForVersion=%d&timeout=1", ts.URL, g.ID, version), nil)
+		res, err := http.DefaultClient.Do(authorize(req, watcherToken(t, g)))
+		if err != nil {
+			t.Fatalf("long poll failed: %v", err)
+		}
+		defer res.Body.Close()
+		var view game.SpectatorView
+		if err := json.NewDecoder(res.Body).Decode(&view); err != nil || res.StatusCode != http.StatusOK {
+			t.Errorf("expected the view after the poll's own timeout, but got %d: %v", res.StatusCode, err)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"nuclear-war-game-server/store"
+)
+
+// ListenConfig says where and how Serve listens.
+type ListenConfig struct {
+	Addr    string // TCP address, such as ":8080"
+	Socket  string // Unix socket path; used instead of Addr when set
+	TLSCert string // Certificate file; HTTPS is served when set
+	TLSKey  string // Private key file for TLSCert
+
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout and WriteTimeout bound reading a request and writing its
+	// response. Streams and long polls lift the first and extend the second.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ShutdownTimeout bounds how long a shutdown waits for requests and
+	// sockets to finish before the games are saved regardless.
+	ShutdownTimeout time.Duration
+}
+
+// SetStore sets where the server keeps games between restarts.
+func (s *Server) SetStore(st store.Store) {
+	s.store = st
+}
+
+// listen opens the listener cfg asks for. A socket file left behind by an
+// earlier run is removed first.
+func (cfg ListenConfig) listen() (net.Listener, error) {
+	if cfg.Socket == "" {
+		return net.Listen("tcp", cfg.Addr)
+	}
+	if info, err := os.Lstat(cfg.Socket); err == nil && info.Mode()&fs.ModeSocket != 0 {
+		os.Remove(cfg.Socket)
+	}
+	return net.Listen("unix", cfg.Socket)
+}
+
+// Serve listens as cfg says and serves the API, running the turn clocks
+// alongside, until ctx is done or the listener fails. It then shuts down
+// gracefully: no new games are taken, streams and sockets are told, requests
+// in flight are given up to cfg.ShutdownTimeout to finish, and every game is
+// saved to the store.
+func (s *Server) Serve(ctx context.Context, cfg ListenConfig) error {
+	ln, err := cfg.listen()
+	if err != nil {
+		return fmt.Errorf("could not listen: %w", err)
+	}
+	srv := &http.Server{
+		Handler:           s.router,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	s.writeTimeout = cfg.WriteTimeout
+	slog.Info("Nuclear War server listening", "addr", ln.Addr().String())
+	go s.runTurnClock(turnClockInterval)
+
+	served := make(chan error, 1)
+	go func() {
+		if cfg.TLSCert != "" {
+			served <- srv.ServeTLS(ln, cfg.TLSCert, cfg.TLSKey)
+		} else {
+			served <- srv.Serve(ln)
+		}
+	}()
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down: draining requests", "timeout", cfg.ShutdownTimeout)
+	case serveErr = <-served:
+		slog.Error("shutting down: the listener failed", "err", serveErr)
+	}
+	s.beginShutdown()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	drainErr := srv.Shutdown(drainCtx)
+	if drainErr == nil {
+		drainErr = s.waitForSockets(drainCtx)
+	}
+	if drainErr != nil {
+		// Whatever is still running is cut off, so the games can be saved.
+		srv.Close()
+		drainErr = fmt.Errorf("draining: %w", drainErr)
+	}
+	saveErr := s.SaveGames()
+	if errors.Is(serveErr, http.ErrServerClosed) {
+		serveErr = nil
+	}
+	return errors.Join(serveErr, drainErr, saveErr)
+}
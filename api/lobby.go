@@ -0,0 +1,176 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"nuclear-war-game-server/game"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// GameList is a page of lobby listings.
+type GameList struct {
+	Games    []game.GameSummary `json:"games"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"pageSize"`
+	Total    int                `json:"total"`
+}
+
+// lobbyFilter holds the filters a lobby listing request can apply.
+type lobbyFilter struct {
+	states       map[game.GameState]bool // nil means any state
+	minSeatsFree int
+	hasPassword  *bool
+	delayed      *bool
+	name         string
+}
+
+// stateFilters maps the lobby's state filter values to game states.
+// "in_progress" covers every state of a game that is being played.
+var stateFilters = map[string][]game.GameState{
+	"waiting_for_players": {game.StateWaitingForPlayers},
+	"waiting":             {game.StateWaitingForPlayers},
+	"in_progress":         {game.StateOpeningRound, game.StateInProgress, game.StateFinalStrike},
+	"finished":            {game.StateGameOver},
+	"game_over":           {game.StateGameOver},
+}
+
+// parseLobbyFilter reads the filters from the request's query string.
+func parseLobbyFilter(r *http.Request) (lobbyFilter, string) {
+	q := r.URL.Query()
+	var f lobbyFilter
+
+	if v := q.Get("state"); v != "" {
+		f.states = map[game.GameState]bool{}
+		for _, name := range strings.Split(v, ",") {
+			states, ok := stateFilters[name]
+			if !ok {
+				return f, "invalid state filter: " + name
+			}
+			for _, state := range states {
+				f.states[state] = true
+			}
+		}
+	}
+	if v := q.Get("seatsFree"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return f, "seatsFree must be a non-negative number"
+		}
+		f.minSeatsFree = n
+	}
+	for param, dst := range map[string]**bool{"hasPassword": &f.hasPassword, "spectatorDelay": &f.delayed} {
+		if v := q.Get(param); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return f, param + " must be true or false"
+			}
+			*dst = &b
+		}
+	}
+	f.name = strings.ToLower(q.Get("name"))
+	return f, ""
+}
+
+// matches checks if a listing passes the filter.
+func (f lobbyFilter) matches(summary game.GameSummary) bool {
+	if f.states != nil && !f.states[summary.State] {
+		return false
+	}
+	if summary.SeatsFree < f.minSeatsFree {
+		return false
+	}
+	if f.hasPassword != nil && summary.HasPassword != *f.hasPassword {
+		return false
+	}
+	if f.delayed != nil && (summary.Options.SpectatorDelaySeconds > 0) != *f.delayed {
+		return false
+	}
+	if f.name != "" && !strings.Contains(strings.ToLower(summary.Name), f.name) {
+		return false
+	}
+	return true
+}
+
+// parsePage reads the 1-based page number and page size from the query string.
+func parsePage(r *http.Request) (page, pageSize int, errMsg string) {
+	q := r.URL.Query()
+	page, pageSize = 1, defaultPageSize
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, 0, "page must be a positive number"
+		}
+		page = n
+	}
+	if v := q.Get("pageSize"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			return 0, 0, "pageSize must be between 1 and " + strconv.Itoa(maxPageSize)
+		}
+		pageSize = n
+	}
+	return page, pageSize, ""
+}
+
+// listGamesHandler lists the public games, newest first.
+func (s *Server) listGamesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, errMsg := parseLobbyFilter(r)
+	if errMsg != "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, errMsg)
+		return
+	}
+	page, pageSize, errMsg := parsePage(r)
+	if errMsg != "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, errMsg)
+		return
+	}
+
+	s.mu.Lock()
+	games := make([]*game.Game, 0, len(s.games))
+	for _, g := range s.games {
+		games = append(games, g)
+	}
+	s.mu.Unlock()
+
+	matching := []game.GameSummary{}
+	for _, g := range games {
+		summary := g.Summary()
+		if summary.Visibility == game.VisibilityPrivate || !filter.matches(summary) {
+			continue
+		}
+		matching = append(matching, summary)
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		if !matching[i].CreatedAt.Equal(matching[j].CreatedAt) {
+			return matching[i].CreatedAt.After(matching[j].CreatedAt)
+		}
+		return matching[i].ID < matching[j].ID
+	})
+
+	list := GameList{Games: []game.GameSummary{}, Page: page, PageSize: pageSize, Total: len(matching)}
+	if start := (page - 1) * pageSize; start < len(matching) {
+		end := start + pageSize
+		if end > len(matching) {
+			end = len(matching)
+		}
+		list.Games = matching[start:end]
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// gameSummaryHandler describes a single game, including private ones.
+func (s *Server) gameSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g.Summary())
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nuclear-war-game-server/game"
+)
+
+// createGameWith creates a game through the API with the given request body.
+func createGameWith(t *testing.T, s *Server, req CreateGameRequest) *game.Game {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	httpReq, _ := http.NewRequest("POST", "/games", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, httpReq)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create returned wrong status code: got %v want %v: %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	var view game.SpectatorView
+	json.Unmarshal(rr.Body.Bytes(), &view)
+	return s.games[view.GameID]
+}
+
+// listGames fetches a lobby listing with the given query string.
+func listGames(t *testing.T, s *Server, query string) GameList {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "/games?"+query, nil)
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var list GameList
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("could not parse response JSON: %v", err)
+	}
+	return list
+}
+
+// joinAs joins g through the API and returns the join response.
+func joinAs(t *testing.T, s *Server, g *game.Game, name string) JoinGameResponse {
+	t.Helper()
+	body, _ := json.Marshal(JoinGameRequest{PlayerName: name})
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/join", g.ID), bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("join returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp JoinGameResponse
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestListGamesHandler(t *testing.T) {
+	s, _ := setupTestServer()
+
+	open := createGameWith(t, s, CreateGameRequest{Name: "Open Table"})
+	createGameWith(t, s, CreateGameRequest{Name: "Locked", Password: "hunter2"})
+	createGameWith(t, s, CreateGameRequest{Name: "Hidden", Visibility: game.VisibilityPrivate})
+	started := createGameWith(t, s, CreateGameRequest{Name: "Underway"})
+	started.AddPlayer("Player 1")
+	started.AddPlayer("Player 2")
+	if err := started.StartGame(); err != nil {
+		t.Fatalf("failed to start game: %v", err)
+	}
+
+	t.Run("private games are not listed", func(t *testing.T) {
+		list := listGames(t, s, "")
+		if list.Total != 3 {
+			t.Errorf("expected 3 public games, but got %d", list.Total)
+		}
+		for _, summary := range list.Games {
+			if summary.Name == "Hidden" {
+				t.Error("expected the private game not to be listed")
+			}
+		}
+	})
+
+	t.Run("filters by state", func(t *testing.T) {
+		list := listGames(t, s, "state=in_progress")
+		if list.Total != 1 || list.Games[0].ID != started.ID {
+			t.Errorf("expected only the started game, but got %+v", list.Games)
+		}
+		if list := listGames(t, s, "state=waiting_for_players"); list.Total != 2 {
+			t.Errorf("expected 2 waiting games, but got %d", list.Total)
+		}
+	})
+
+	t.Run("filters by password and seats", func(t *testing.T) {
+		list := listGames(t, s, "hasPassword=false&seatsFree=1")
+		if list.Total != 1 || list.Games[0].ID != open.ID {
+			t.Errorf("expected only the open game, but got %+v", list.Games)
+		}
+	})
+
+	t.Run("paginates", func(t *testing.T) {
+		list := listGames(t, s, "pageSize=2&page=2")
+		if list.Total != 3 || len(list.Games) != 1 {
+			t.Errorf("expected 1 game on page 2 of 3 games, but got %d of %d", len(list.Games), list.Total)
+		}
+	})
+
+	t.Run("rejects invalid filters", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/games?state=paused", nil)
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
+
+func TestJoinGameHandler_Password(t *testing.T) {
+	s, _ := setupTestServer()
+	g := createGameWith(t, s, CreateGameRequest{Name: "Locked", Password: "hunter2"})
+
+	join := func(password string) int {
+		body, _ := json.Marshal(JoinGameRequest{PlayerName: "Player 1", Password: password})
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/join", g.ID), bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := join("wrong"); code != http.StatusForbidden {
+		t.Errorf("expected status %d for the wrong password, got %d", http.StatusForbidden, code)
+	}
+	if code := join("hunter2"); code != http.StatusOK {
+		t.Errorf("expected status %d for the right password, got %d", http.StatusOK, code)
+	}
+}
+
+func TestJoinByCodeHandler(t *testing.T) {
+	s, _ := setupTestServer()
+	g := createGameWith(t, s, CreateGameRequest{Name: "Friday Night"})
+	other := createGameWith(t, s, CreateGameRequest{})
+
+	code := g.Summary().JoinCode
+	if len(code) != game.JoinCodeLength {
+		t.Fatalf("expected the create response to carry a join code, but got '%s'", code)
+	}
+	if code == other.Summary().JoinCode {
+		t.Fatal("expected two open games to have different join codes")
+	}
+
+	t.Run("spectators do not see the code", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/games/"+g.ID, nil)
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, authorize(req, watcherToken(t, g)))
+		if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), code) {
+			t.Errorf("expected the spectator view without the join code, but got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("resolves the code to the game", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/join/"+strings.ToLower(code), nil)
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var summary game.GameSummary
+		json.Unmarshal(rr.Body.Bytes(), &summary)
+		if summary.ID != g.ID {
+			t.Errorf("expected the code to resolve to game %s, but got %s", g.ID, summary.ID)
+		}
+	})
+
+	t.Run("joins by code", func(t *testing.T) {
+		for _, name := range []string{"Player 1", "Player 2"} {
+			body, _ := json.Marshal(JoinGameRequest{PlayerName: name})
+			req, _ := http.NewRequest("POST", "/join/"+code, bytes.NewBuffer(body))
+			rr := httptest.NewRecorder()
+			s.router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+			}
+		}
+		if g.PlayerCount() != 2 {
+			t.Errorf("expected 2 players, but got %d", g.PlayerCount())
+		}
+	})
+
+	t.Run("the code expires once the game starts", func(t *testing.T) {
+		if err := g.StartGame(); err != nil {
+			t.Fatalf("failed to start game: %v", err)
+		}
+		req, _ := http.NewRequest("GET", "/join/"+code, nil)
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d for an expired code, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestHostHandlers(t *testing.T) {
+	s, _ := setupTestServer()
+
+	// Creating a game with a player name seats the creator as host.
+	body, _ := json.Marshal(CreateGameRequest{PlayerName: "Host"})
+	req, _ := http.NewRequest("POST", "/games", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create returned wrong status code: got %v want %v: %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	var host JoinGameResponse
+	json.Unmarshal(rr.Body.Bytes(), &host)
+	if host.PlayerToken == "" || host.HostID != host.PlayerID {
+		t.Fatalf("expected the creator to be seated as host, but got %+v", host)
+	}
+	g := s.games[host.GameID]
+
+	guest := joinAs(t, s, g, "Guest")
+
+	post := func(path, token string, body interface{}) int {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/%s", g.ID, path), bytes.NewBuffer(data))
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, authorize(req, token))
+		return rr.Code
+	}
+
+	if code := post("kick", guest.PlayerToken, PlayerTargetRequest{PlayerID: host.PlayerID}); code != http.StatusForbidden {
+		t.Errorf("expected status %d when a guest kicks, got %d", http.StatusForbidden, code)
+	}
+	if code := post("options", host.PlayerToken, UpdateOptionsRequest{Options: game.GameOptions{SpectatorDelaySeconds: 30}}); code != http.StatusOK {
+		t.Errorf("expected status %d when the host updates options, got %d", http.StatusOK, code)
+	}
+	if code := post("ready", guest.PlayerToken, nil); code != http.StatusOK {
+		t.Errorf("expected status %d when readying up, got %d", http.StatusOK, code)
+	}
+	if code := post("transfer_host", host.PlayerToken, PlayerTargetRequest{PlayerID: guest.PlayerID}); code != http.StatusOK {
+		t.Errorf("expected status %d when transferring host, got %d", http.StatusOK, code)
+	}
+	if code := post("kick", guest.PlayerToken, PlayerTargetRequest{PlayerID: host.PlayerID}); code != http.StatusOK {
+		t.Errorf("expected status %d when the new host kicks, got %d", http.StatusOK, code)
+	}
+	if code := post("ready", host.PlayerToken, nil); code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for a kicked player's token, got %d", http.StatusUnauthorized, code)
+	}
+}
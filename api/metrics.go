@@ -0,0 +1,197 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nuclear-war-game-server/game"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency
+// histogram buckets.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// gameStates lists every state, so that the games gauge reports states that
+// no game is in as well.
+var gameStates = []game.GameState{
+	game.StateWaitingForPlayers,
+	game.StateOpeningRound,
+	game.StateInProgress,
+	game.StateFinalStrike,
+	game.StateGameOver,
+}
+
+// metrics counts what the server and its games do, for the /metrics endpoint.
+// It observes every game the server holds.
+type metrics struct {
+	mu            sync.Mutex
+	actions       map[actionKey]uint64
+	attacks       uint64
+	interceptions uint64
+	eliminations  uint64
+	requests      map[requestKey]*histogram
+}
+
+type actionKey struct{ action, result string }
+
+type requestKey struct{ method, route, code string }
+
+// histogram counts observations into latencyBuckets.
+type histogram struct {
+	buckets []uint64 // Observations per bucket, not cumulative
+	count   uint64
+	sum     float64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		actions:  make(map[actionKey]uint64),
+		requests: make(map[requestKey]*histogram),
+	}
+}
+
+// ActionTaken implements game.Observer. The result is "ok", or the error code
+// the API answers the rejection with.
+func (m *metrics) ActionTaken(gameID string, action game.ActionType, err error) {
+	result := "ok"
+	if err != nil {
+		_, body := gameErrorResponse(err)
+		result = body.Code
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.actions[actionKey{string(action), result}]++
+}
+
+// EventCommitted implements game.Observer.
+func (m *metrics) EventCommitted(gameID string, ev game.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch ev.Type {
+	case game.EventAttack:
+		m.attacks++
+	case game.EventIntercepted:
+		m.interceptions++
+	case game.EventPlayerEliminated:
+		m.eliminations++
+	}
+}
+
+// observeRequest records how long a request to a route took.
+func (m *metrics) observeRequest(method, route string, status int, took time.Duration) {
+	key := requestKey{method, route, strconv.Itoa(status)}
+	seconds := took.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.requests[key]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(latencyBuckets)+1)}
+		m.requests[key] = h
+	}
+	i := sort.SearchFloat64s(latencyBuckets, seconds)
+	h.buckets[i]++
+	h.count++
+	h.sum += seconds
+}
+
+// metricsHandler serves the server's metrics in the Prometheus text format.
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.writeMetrics(w, time.Now())
+}
+
+// writeMetrics writes every metric to w. The gauges are read from the games
+// as they are now; the counters are kept as things happen.
+func (s *Server) writeMetrics(w io.Writer, now time.Time) {
+	s.mu.Lock()
+	games := make([]*game.Game, 0, len(s.games))
+	for _, g := range s.games {
+		games = append(games, g)
+	}
+	s.mu.Unlock()
+
+	byState := make(map[game.GameState]int, len(gameStates))
+	connected := 0
+	for _, g := range games {
+		byState[g.Summary().State]++
+		connected += g.ConnectedPlayers(now)
+	}
+
+	header(w, "nuclear_war_games", "gauge", "Games held by the server, by state.")
+	for _, state := range gameStates {
+		fmt.Fprintf(w, "nuclear_war_games{state=%s} %d\n", labelValue(string(state)), byState[state])
+	}
+	header(w, "nuclear_war_players_connected", "gauge", "Seated players who have made a request recently.")
+	fmt.Fprintf(w, "nuclear_war_players_connected %d\n", connected)
+
+	m := s.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	header(w, "nuclear_war_actions_total", "counter", "Actions games were asked to apply, by type and by result: ok or the error code.")
+	actions := make([]actionKey, 0, len(m.actions))
+	for k := range m.actions {
+		actions = append(actions, k)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].action != actions[j].action {
+			return actions[i].action < actions[j].action
+		}
+		return actions[i].result < actions[j].result
+	})
+	for _, k := range actions {
+		fmt.Fprintf(w, "nuclear_war_actions_total{action=%s,result=%s} %d\n", labelValue(k.action), labelValue(k.result), m.actions[k])
+	}
+
+	header(w, "nuclear_war_attacks_total", "counter", "Attacks launched.")
+	fmt.Fprintf(w, "nuclear_war_attacks_total %d\n", m.attacks)
+	header(w, "nuclear_war_interceptions_total", "counter", "Attacks stopped by an anti-missile.")
+	fmt.Fprintf(w, "nuclear_war_interceptions_total %d\n", m.interceptions)
+	header(w, "nuclear_war_eliminations_total", "counter", "Players eliminated.")
+	fmt.Fprintf(w, "nuclear_war_eliminations_total %d\n", m.eliminations)
+
+	header(w, "nuclear_war_http_request_duration_seconds", "histogram", "Time taken to serve requests, by method, route and status code.")
+	requests := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		requests = append(requests, k)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+	for _, k := range requests {
+		h := m.requests[k]
+		labels := fmt.Sprintf("method=%s,route=%s,code=%s", labelValue(k.method), labelValue(k.route), labelValue(k.code))
+		var cumulative uint64
+		for i, bound := range latencyBuckets {
+			cumulative += h.buckets[i]
+			fmt.Fprintf(w, "nuclear_war_http_request_duration_seconds_bucket{%s,le=\"%g\"} %d\n", labels, bound, cumulative)
+		}
+		fmt.Fprintf(w, "nuclear_war_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(w, "nuclear_war_http_request_duration_seconds_sum{%s} %g\n", labels, h.sum)
+		fmt.Fprintf(w, "nuclear_war_http_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+}
+
+// header writes the HELP and TYPE lines of a metric.
+func header(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// labelValue quotes a label value as the text format asks.
+func labelValue(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
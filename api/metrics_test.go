@@ -0,0 +1,76 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nuclear-war-game-server/game"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	s := NewServer()
+	g, p1, p2, token1, token2 := startedGame(t, s)
+	g.SetObserver(s.metrics)
+	p1.Placemat.ActiveCards = append(p1.Placemat.ActiveCards,
+		&game.Card{ID: "card-b52", Name: "B-52 Bomber", Type: game.TypeDeliverySystem},
+		&game.Card{ID: "card-10mt", Name: "10 Megaton Warhead", Type: game.TypeWarhead, WarheadSize: 10})
+	p2.Population = 5000000
+
+	attack := func(prefix, token string) {
+		body, _ := json.Marshal(AttackRequest{TargetID: p2.ID})
+		req, _ := http.NewRequest("POST", prefix+fmt.Sprintf("/games/%s/attack", g.ID), bytes.NewBuffer(body))
+		s.router.ServeHTTP(httptest.NewRecorder(), authorize(req, token))
+	}
+	attack("/v1", token1)
+	attack("", token2)
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d", http.StatusOK, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("expected the Prometheus text format, but got Content-Type %q", ct)
+	}
+	body := rr.Body.String()
+
+	for _, want := range []string{
+		`nuclear_war_games{state="waiting_for_players"} 0`,
+		`nuclear_war_players_connected 2`,
+		`nuclear_war_actions_total{action="attack",result="ok"} 1`,
+		`nuclear_war_attacks_total 1`,
+		`nuclear_war_interceptions_total 0`,
+		`nuclear_war_eliminations_total 1`,
+		`nuclear_war_http_request_duration_seconds_count{method="POST",route="/v1/games/{gameID}/attack",code="200"} 1`,
+		`nuclear_war_http_request_duration_seconds_bucket{method="POST",route="/v1/games/{gameID}/attack",code="200",le="+Inf"} 1`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("expected the metrics to include %q, but got:\n%s", want, body)
+		}
+	}
+	if !strings.Contains(body, `nuclear_war_actions_total{action="attack",result="`) || strings.Count(body, `action="attack"`) != 2 {
+		t.Errorf("expected the rejected attack to be counted by its error code, but got:\n%s", body)
+	}
+
+	// Every sample line is a name, optional labels and a number.
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		if strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# TYPE ") {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) != 2 || !strings.HasPrefix(fields[0], "nuclear_war_") {
+			t.Errorf("malformed sample line %q", line)
+		}
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	if got := labelValue("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("unexpected escaping: %s", got)
+	}
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openAPIDocument is an OpenAPI 3.0 description of the API. It is generated
+// from the route table and the Go types of the request and response bodies,
+// so it always matches what the handlers do.
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Servers    []openAPIServer                         `json:"servers"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]*openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIOperation struct {
+	Summary     string                      `json:"summary"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+// openAPISchema is the subset of the OpenAPI schema object the generator uses.
+type openAPISchema struct {
+	Ref      string                    `json:"$ref,omitempty"`
+	Type     string                    `json:"type,omitempty"`
+	Format   string                    `json:"format,omitempty"`
+	Nullable bool                      `json:"nullable,omitempty"`
+	AllOf    []*openAPISchema          `json:"allOf,omitempty"`
+	OneOf    []*openAPISchema          `json:"oneOf,omitempty"`
+	Items    *openAPISchema            `json:"items,omitempty"`
+	Required []string                  `json:"required,omitempty"`
+	Props    map[string]*openAPISchema `json:"properties,omitempty"`
+	// Additional is false for structs, whose fields are all listed, or the
+	// schema of the values of a map.
+	Additional interface{} `json:"additionalProperties,omitempty"`
+}
+
+// pathParam matches a path parameter in a route, such as {gameID}.
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// v2Description tells v2 clients which keys are renamed. The keys of data
+// maps are not, so error details keep the engine's spellings, whether that
+// is cardID or retryAfter.
+const v2Description = "Field names are camelCase, with initialisms written as words: gameId, playerId, cardId. " +
+	"The keys of maps that hold data are not field names and are spelled as in /v1: " +
+	"error details (such as cardID, currentPlayerID, limit and retryAfter), " +
+	"the args of a legal move, which match the command parameter names (such as cardID and targetID), " +
+	"and players, keyed by player ID."
+
+// newOpenAPIDocument describes routes as served under /v1, or /v2 if v2 is set.
+func newOpenAPIDocument(routes []route, v2 bool) openAPIDocument {
+	version, name := "1", func(n string) string { return n }
+	if v2 {
+		version, name = "2", v2Name
+	}
+	b := &schemaBuilder{name: name, schemas: map[string]*openAPISchema{}}
+	doc := openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:   "Nuclear War Game Server",
+			Version: version,
+		},
+		Servers: []openAPIServer{{URL: "/v" + version}},
+		Paths:   map[string]map[string]*openAPIOperation{},
+		Components: openAPIComponents{
+			Schemas: b.schemas,
+			SecuritySchemes: map[string]*openAPISecurityScheme{
+				"player":    {Type: "http", Scheme: "bearer", Description: "The playerToken returned by a join"},
+				"spectator": {Type: "http", Scheme: "bearer", Description: "The spectatorToken returned by a spectate request"},
+				"admin":     {Type: "http", Scheme: "bearer", Description: "The server's admin token"},
+			},
+		},
+	}
+	if v2 {
+		doc.Info.Description = v2Description
+	} else {
+		doc.Info.Description = "Every /v1 path is also served without the prefix."
+	}
+	errorBody := b.schema(reflect.TypeOf(ErrorResponse{}))
+
+	for _, rt := range routes {
+		op := &openAPIOperation{Summary: rt.summary, Responses: map[string]*openAPIResponse{}}
+		for _, m := range pathParam.FindAllStringSubmatch(rt.path, -1) {
+			op.Parameters = append(op.Parameters, openAPIParameter{Name: m[1], In: "path", Required: true, Schema: &openAPISchema{Type: "string"}})
+		}
+		for _, q := range rt.query {
+			op.Parameters = append(op.Parameters, openAPIParameter{Name: q, In: "query", Schema: &openAPISchema{Type: "string"}})
+		}
+		if rt.request != nil {
+			op.RequestBody = &openAPIRequestBody{Content: map[string]openAPIMediaType{
+				"application/json": {Schema: b.schema(reflect.TypeOf(rt.request))},
+			}}
+		}
+		success := &openAPIResponse{Description: http.StatusText(rt.status)}
+		switch len(rt.responses) {
+		case 0:
+		case 1:
+			success.Content = jsonContent(b.schema(reflect.TypeOf(rt.responses[0])))
+		default:
+			oneOf := &openAPISchema{}
+			for _, r := range rt.responses {
+				oneOf.OneOf = append(oneOf.OneOf, b.schema(reflect.TypeOf(r)))
+			}
+			success.Content = jsonContent(oneOf)
+		}
+		op.Responses[strconv.Itoa(rt.status)] = success
+		op.Responses["default"] = &openAPIResponse{Description: "An error", Content: jsonContent(errorBody)}
+		switch rt.auth {
+		case authViewer:
+			op.Security = []map[string][]string{{"player": {}}, {"spectator": {}}}
+		case authPlayer:
+			op.Security = []map[string][]string{{"player": {}}}
+		case authAdmin:
+			op.Security = []map[string][]string{{"admin": {}}}
+		}
+
+		if doc.Paths[rt.path] == nil {
+			doc.Paths[rt.path] = map[string]*openAPIOperation{}
+		}
+		doc.Paths[rt.path][strings.ToLower(rt.method)] = op
+	}
+	return doc
+}
+
+func jsonContent(schema *openAPISchema) map[string]openAPIMediaType {
+	return map[string]openAPIMediaType{"application/json": {Schema: schema}}
+}
+
+// schemaBuilder derives schemas from Go types the way encoding/json encodes
+// them. Named structs become components referenced by name.
+type schemaBuilder struct {
+	name    func(string) string // Spells a JSON field name for the API version
+	schemas map[string]*openAPISchema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema of values of type t.
+func (b *schemaBuilder) schema(t reflect.Type) *openAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := b.schemas[t.Name()]; !ok {
+			obj := &openAPISchema{Type: "object", Props: map[string]*openAPISchema{}, Additional: false}
+			b.schemas[t.Name()] = obj
+			b.fields(t, obj)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + t.Name()}
+	case t.Kind() == reflect.Struct:
+		obj := &openAPISchema{Type: "object", Props: map[string]*openAPISchema{}, Additional: false}
+		b.fields(t, obj)
+		return obj
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &openAPISchema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", Additional: b.schema(t.Elem())}
+	}
+	// An interface{} may hold anything.
+	return &openAPISchema{}
+}
+
+// fields adds the JSON fields of struct type t to obj. Fields of embedded
+// structs are promoted, as encoding/json does.
+func (b *schemaBuilder) fields(t reflect.Type, obj *openAPISchema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			b.fields(ft, obj)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		name = b.name(name)
+
+		schema := b.schema(f.Type)
+		omitEmpty := strings.Contains(opts, "omitempty")
+		// Nil pointers, slices and maps are encoded as null unless omitted.
+		switch f.Type.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			if !omitEmpty {
+				schema = nullable(schema)
+			}
+		}
+		obj.Props[name] = schema
+		if !omitEmpty {
+			obj.Required = append(obj.Required, name)
+		}
+	}
+}
+
+// nullable returns a schema that also allows null.
+func nullable(s *openAPISchema) *openAPISchema {
+	if s.Ref != "" {
+		// Siblings of a $ref are ignored, so the reference is wrapped.
+		return &openAPISchema{AllOf: []*openAPISchema{s}, Nullable: true}
+	}
+	s.Nullable = true
+	return s
+}
+
+// openAPIHandler serves the OpenAPI document of the API version requested.
+func (s *Server) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	// The document is already spelled for its version, so it skips the v2
+	// rewrite by going straight to the underlying writer.
+	if vw, ok := w.(*v2Writer); ok {
+		w = vw.ResponseWriter
+	}
+	writeJSON(w, http.StatusOK, newOpenAPIDocument(s.apiRoutes(), isV2(r)))
+}
@@ -0,0 +1,412 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"nuclear-war-game-server/game"
+)
+
+// validate checks a decoded JSON value against a schema of doc, returning a
+// description of the first mismatch found.
+func validate(doc openAPIDocument, schema *openAPISchema, v interface{}, at string) error {
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		target, ok := doc.Components.Schemas[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown schema %s", at, schema.Ref)
+		}
+		return validate(doc, target, v, at)
+	}
+	if v == nil {
+		if schema.Nullable || (schema.Type == "" && len(schema.AllOf) == 0 && len(schema.OneOf) == 0) {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not allowed", at)
+	}
+	for _, s := range schema.AllOf {
+		if err := validate(doc, s, v, at); err != nil {
+			return err
+		}
+	}
+	if len(schema.OneOf) > 0 {
+		matched := 0
+		for _, s := range schema.OneOf {
+			if validate(doc, s, v, at) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: matches %d of the oneOf schemas, not exactly one", at, matched)
+		}
+	}
+
+	switch schema.Type {
+	case "":
+		return nil
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%s: expected a string, but got %v", at, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean, but got %v", at, v)
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if _, err := strconv.ParseInt(string(n), 10, 64); !ok || err != nil {
+			if _, err := strconv.ParseUint(string(n), 10, 64); !ok || err != nil {
+				return fmt.Errorf("%s: expected an integer, but got %v", at, v)
+			}
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			return fmt.Errorf("%s: expected a number, but got %v", at, v)
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an array, but got %v", at, v)
+		}
+		for i, item := range items {
+			if err := validate(doc, schema.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object, but got %v", at, v)
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing required field %q", at, name)
+			}
+		}
+		for name, value := range obj {
+			prop, ok := schema.Props[name]
+			if !ok {
+				// A decoded document holds additionalProperties as either
+				// false or a plain object.
+				additional, isSchema := schema.Additional.(map[string]interface{})
+				if !isSchema {
+					return fmt.Errorf("%s: undocumented field %q", at, name)
+				}
+				data, _ := json.Marshal(additional)
+				prop = &openAPISchema{}
+				json.Unmarshal(data, prop)
+			}
+			if err := validate(doc, prop, value, at+"."+name); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%s: unknown schema type %q", at, schema.Type)
+	}
+	return nil
+}
+
+// conformanceClient drives a server through one API version, checking every
+// response against that version's OpenAPI document.
+type conformanceClient struct {
+	t      *testing.T
+	s      *Server
+	prefix string
+	doc    openAPIDocument
+}
+
+// field spells a v1 field name for the client's API version.
+func (c *conformanceClient) field(name string) string {
+	if c.prefix == "/v2" {
+		return v2Name(name)
+	}
+	return name
+}
+
+// do sends a request and checks the response against the operation the
+// document gives for its route. It returns the decoded body.
+func (c *conformanceClient) do(method, path, token string, body interface{}) (int, map[string]interface{}) {
+	c.t.Helper()
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req, _ := http.NewRequest(method, c.prefix+path, bytes.NewReader(data))
+	if token != "" {
+		authorize(req, token)
+	}
+	rr := httptest.NewRecorder()
+	c.s.router.ServeHTTP(rr, req)
+
+	var match mux.RouteMatch
+	if !c.s.router.Match(req, &match) || match.Route == nil {
+		c.t.Fatalf("%s %s: no route matched", method, path)
+	}
+	template, _ := match.Route.GetPathTemplate()
+	op := c.doc.Paths[strings.TrimPrefix(template, c.prefix)][strings.ToLower(method)]
+	if op == nil {
+		c.t.Fatalf("%s %s: route %s is not documented", method, path, template)
+	}
+	response := op.Responses[strconv.Itoa(rr.Code)]
+	if response == nil {
+		response = op.Responses["default"]
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		c.t.Fatalf("%s %s: expected a JSON response, but got Content-Type '%s'", method, path, ct)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(rr.Body.Bytes()))
+	dec.UseNumber()
+	var decoded interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		c.t.Fatalf("%s %s: could not parse response: %v", method, path, err)
+	}
+	if err := validate(c.doc, response.Content["application/json"].Schema, decoded, "body"); err != nil {
+		c.t.Errorf("%s %s: status %d response does not conform: %v\n%s", method, path, rr.Code, err, rr.Body.String())
+	}
+	obj, _ := decoded.(map[string]interface{})
+	return rr.Code, obj
+}
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	s := NewServer()
+	documented := map[string]bool{}
+	for _, prefix := range []string{"", "/v1", "/v2"} {
+		for path, ops := range newOpenAPIDocument(s.apiRoutes(), prefix == "/v2").Paths {
+			for method := range ops {
+				documented[strings.ToUpper(method)+" "+prefix+path] = true
+			}
+		}
+	}
+
+	served := map[string]bool{}
+	s.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Subrouter prefixes have no methods of their own.
+			return nil
+		}
+		for _, method := range methods {
+			served[method+" "+template] = true
+		}
+		return nil
+	})
+
+	var missing, extra []string
+	for k := range served {
+		if !documented[k] {
+			missing = append(missing, k)
+		}
+	}
+	for k := range documented {
+		if !served[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	if len(missing) > 0 {
+		t.Errorf("routes missing from the document: %v", missing)
+	}
+	if len(extra) > 0 {
+		t.Errorf("documented routes that are not served: %v", extra)
+	}
+}
+
+func TestOpenAPI_HandlersConform(t *testing.T) {
+	for _, prefix := range []string{"/v1", "/v2"} {
+		t.Run(prefix, func(t *testing.T) {
+			s := NewServer()
+			s.SetAdminToken("admin-secret")
+			c := &conformanceClient{t: t, s: s, prefix: prefix}
+
+			// The served document, itself checked against the generated one,
+			// is what the handlers are checked against. Both go through JSON
+			// so that they read the same.
+			data, _ := json.Marshal(newOpenAPIDocument(s.apiRoutes(), prefix == "/v2"))
+			json.Unmarshal(data, &c.doc)
+			_, raw := c.do("GET", "/openapi.json", "", nil)
+			data, _ = json.Marshal(raw)
+			c.doc = openAPIDocument{}
+			if err := json.Unmarshal(data, &c.doc); err != nil {
+				t.Fatalf("could not parse the OpenAPI document: %v", err)
+			}
+			if c.doc.Servers[0].URL != prefix {
+				t.Fatalf("expected the document to describe %s, but it describes %s", prefix, c.doc.Servers[0].URL)
+			}
+
+			c.do("GET", "/rules/transitions", "", nil)
+			c.do("GET", "/healthz", "", nil)
+			c.do("GET", "/readyz", "", nil)
+			c.do("GET", "/version", "", nil)
+			status, host := c.do("POST", "/games", "", CreateGameRequest{Name: "Conformance", PlayerName: "Host"})
+			if status != http.StatusCreated {
+				t.Fatalf("expected status %d creating a game, but got %d", http.StatusCreated, status)
+			}
+			gameID, _ := host[c.field("gameID")].(string)
+			hostToken, _ := host[c.field("playerToken")].(string)
+			if gameID == "" || hostToken == "" {
+				t.Fatalf("expected the creator to be seated, but got %v", host)
+			}
+			games := "/games/" + gameID
+
+			_, guest := c.do("POST", games+"/join", "", JoinGameRequest{PlayerName: "Guest"})
+			guestToken, _ := guest[c.field("playerToken")].(string)
+			guestID, _ := guest[c.field("playerID")].(string)
+			c.do("GET", "/games", "", nil)
+			c.do("GET", games+"/summary", "", nil)
+			c.do("GET", games, "", nil)
+			c.do("POST", games+"/spectate", "", SpectateRequest{Name: "Watcher"})
+			c.do("POST", games+"/options", hostToken, UpdateOptionsRequest{Options: game.GameOptions{}})
+			c.do("POST", games+"/ready", hostToken, nil)
+			c.do("POST", games+"/ready", guestToken, nil)
+			if status, _ := c.do("POST", games+"/start", hostToken, nil); status != http.StatusOK {
+				t.Fatalf("expected status %d starting the game, but got %d", http.StatusOK, status)
+			}
+
+			// Each player's first move is the secret card the opening round asks for.
+			for _, token := range []string{hostToken, guestToken} {
+				_, view := c.do("GET", games, token, nil)
+				commands, _ := view[c.field("availableCommands")].([]interface{})
+				for _, cmd := range commands {
+					cmd := cmd.(map[string]interface{})
+					moves, _ := cmd["moves"].([]interface{})
+					if cmd["name"] != string(game.ActionPlayCard) || len(moves) == 0 {
+						continue
+					}
+					args := moves[0].(map[string]interface{})["args"].(map[string]interface{})
+					c.do("POST", games+"/play", token, PlayCardRequest{
+						CardID:   args["cardID"].(string),
+						Location: args["location"].(string),
+					})
+				}
+			}
+			c.do("POST", games+"/attack", guestToken, AttackRequest{TargetID: guestID})
+			c.do("POST", games+"/pass", hostToken, nil)
+			c.do("POST", games+"/pass", hostToken, nil)
+			c.do("GET", "/admin"+games, "admin-secret", nil)
+			c.do("POST", "/admin"+games+"/debug", "admin-secret", DebugRequest{Enabled: true})
+			c.do("POST", games+"/forfeit", guestToken, nil)
+			c.do("GET", "/games/no-such-game", "", nil)
+		})
+	}
+}
+
+func TestV2_FieldNames(t *testing.T) {
+	s := NewServer()
+	g, _, _, token1, _ := startedGame(t, s)
+
+	for _, path := range []string{"/v2/games/" + g.ID, "/v2/admin/games/" + g.ID} {
+		s.SetAdminToken(token1)
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, authorize(req, token1))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status %d, but got %d: %s", path, http.StatusOK, rr.Code, rr.Body.String())
+		}
+		body := rr.Body.String()
+		for _, v1 := range []string{`"gameID"`, `"player_order"`, `"current_player_index"`, `"playerID"`} {
+			if strings.Contains(body, v1) {
+				t.Errorf("GET %s: expected no v1 field %s, but got %s", path, v1, body)
+			}
+		}
+		if !strings.Contains(body, `"`+g.PlayerOrder[0]+`"`) {
+			t.Errorf("GET %s: expected player IDs to be left alone", path)
+		}
+	}
+
+	// v1, with or without its prefix, keeps the original names.
+	for _, path := range []string{"/games/" + g.ID, "/v1/games/" + g.ID} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, authorize(req, token1))
+		if !strings.Contains(rr.Body.String(), `"gameID"`) {
+			t.Errorf("GET %s: expected the v1 field gameID, but got %s", path, rr.Body.String())
+		}
+	}
+}
+
+func TestV2_DataMapKeys(t *testing.T) {
+	s := NewServer()
+	g, _, _, token1, token2 := startedGame(t, s)
+	send := func(method, path, token string) map[string]interface{} {
+		req, _ := http.NewRequest(method, path, nil)
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, authorize(req, token))
+		var body map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s %s: could not decode the response: %v", method, path, err)
+		}
+		return body
+	}
+
+	body := send("POST", "/v2/games/"+g.ID+"/pass", token2)
+	details, _ := body["details"].(map[string]interface{})
+	if _, ok := details["currentPlayerID"]; !ok {
+		t.Errorf("expected error details to keep their keys, but got %v", body)
+	}
+
+	view := send("GET", "/v2/games/"+g.ID, token1)
+	commands, _ := view["availableCommands"].([]interface{})
+	found := false
+	for _, cmd := range commands {
+		cmd := cmd.(map[string]interface{})
+		if cmd["name"] != string(game.ActionPlayCard) {
+			continue
+		}
+		found = true
+		params := cmd["params"].([]interface{})
+		if name := params[0].(map[string]interface{})["name"]; name != "cardID" {
+			t.Errorf("expected the parameter name cardID, but got %v", name)
+		}
+		args := cmd["moves"].([]interface{})[0].(map[string]interface{})["args"].(map[string]interface{})
+		if _, ok := args["cardID"]; !ok {
+			t.Errorf("expected move args to keep their keys, but got %v", args)
+		}
+	}
+	if !found {
+		t.Fatalf("expected a play command, but got %v", commands)
+	}
+
+	if doc := newOpenAPIDocument(s.apiRoutes(), true); !strings.Contains(doc.Info.Description, "error details") {
+		t.Errorf("expected the v2 description to say which keys keep their spelling, but got %q", doc.Info.Description)
+	}
+
+	s.SetAdminToken("admin-secret")
+	players, _ := send("GET", "/v2/admin/games/"+g.ID, "admin-secret")["players"].(map[string]interface{})
+	if _, ok := players[g.PlayerOrder[0]]; !ok {
+		t.Errorf("expected players to stay keyed by ID, but got %v", players)
+	}
+}
+
+func TestV2Name(t *testing.T) {
+	tests := map[string]string{
+		"player_order":         "playerOrder",
+		"current_player_index": "currentPlayerIndex",
+		"gameID":               "gameId",
+		"playerID":             "playerId",
+		"currentTurnPlayerId":  "currentTurnPlayerId",
+		"IDs":                  "IDs",
+		"turnLog":              "turnLog",
+		"8b0c6a4e-1f2d":        "8b0c6a4e-1f2d",
+		"$ref":                 "$ref",
+	}
+	for in, want := range tests {
+		if got := v2Name(in); got != want {
+			t.Errorf("v2Name(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
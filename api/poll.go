@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"nuclear-war-game-server/game"
+)
+
+const (
+	// defaultLongPoll is how long a waitForVersion request waits by default.
+	defaultLongPoll = 30 * time.Second
+	// maxLongPoll is the longest a waitForVersion request may ask to wait.
+	maxLongPoll = 60 * time.Second
+)
+
+// viewVersion returns the game version a view was taken at.
+func viewVersion(view interface{}) uint64 {
+	switch v := view.(type) {
+	case *game.PlayerView:
+		return v.Version
+	case *game.SpectatorView:
+		return v.Version
+	}
+	return 0
+}
+
+// viewETag returns the entity tag of a rendered view. It leads with the game
+// version, and adds a digest of the body because parts of a view, such as
+// who is connected and the spectator delay, change with time alone.
+func viewETag(version uint64, body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf(`"%d-%x"`, version, sum[:8])
+}
+
+// etagMatches reports whether an If-None-Match header matches etag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLongPoll reads the waitForVersion and timeout query parameters. ok is
+// false if the request does not ask to wait.
+func parseLongPoll(r *http.Request) (version uint64, timeout time.Duration, ok bool, err error) {
+	q := r.URL.Query()
+	v := q.Get("waitForVersion")
+	if v == "" {
+		return 0, 0, false, nil
+	}
+	if version, err = strconv.ParseUint(v, 10, 64); err != nil {
+		return 0, 0, false, fmt.Errorf("invalid waitForVersion")
+	}
+	timeout = defaultLongPoll
+	if t := q.Get("timeout"); t != "" {
+		seconds, err := strconv.Atoi(t)
+		if err != nil || seconds < 0 {
+			return 0, 0, false, fmt.Errorf("invalid timeout")
+		}
+		timeout = time.Duration(seconds) * time.Second
+		if timeout > maxLongPoll {
+			timeout = maxLongPoll
+		}
+	}
+	return version, timeout, true, nil
+}
+
+// waitForView blocks until the caller's view of the game has moved past
+// version, the timeout passes or the client goes away, and returns the view.
+func waitForView(ctx context.Context, g *game.Game, playerID string, version uint64, timeout time.Duration) interface{} {
+	// Subscribe before the first look, so no change can slip in between.
+	changes, unsubscribe := g.Subscribe()
+	defer unsubscribe()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	// Delayed spectator views move on without any new change to the game.
+	recheck := time.NewTicker(time.Second)
+	defer recheck.Stop()
+
+	for {
+		view := viewFor(g, playerID)
+		if viewVersion(view) > version {
+			return view
+		}
+		select {
+		case <-changes:
+		case <-recheck.C:
+		case <-deadline.C:
+			return view
+		case <-ctx.Done():
+			return view
+		}
+	}
+}
+
+// writeCachedView writes a view with its ETag, or a bare 304 if the client
+// already has it.
+func writeCachedView(w http.ResponseWriter, r *http.Request, view interface{}) {
+	body, err := json.Marshal(view)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	etag := viewETag(viewVersion(view), body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(body, '\n'))
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nuclear-war-game-server/game"
+)
+
+func TestGetGameHandler_ETag(t *testing.T) {
+	s, _ := setupTestServer()
+	g := createGame(t, s)
+	p1, _ := g.AddPlayer("Player 1")
+	g.AddPlayer("Player 2")
+	token := issueToken(t, g, p1.ID)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/games/%s", g.ID), nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, authorize(req, token))
+		return rr
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected a 200 with an ETag, but got %d and %q", first.Code, etag)
+	}
+
+	if rr := get(etag); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("expected an empty %d for a matching ETag, got %d", http.StatusNotModified, rr.Code)
+	}
+
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("failed to start game: %v", err)
+	}
+	rr := get(etag)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d after the game changed, got %d", http.StatusOK, rr.Code)
+	}
+	if rr.Header().Get("ETag") == etag {
+		t.Error("expected a new ETag after the game changed")
+	}
+	var view game.PlayerView
+	json.Unmarshal(rr.Body.Bytes(), &view)
+	if view.Version != g.Version {
+		t.Errorf("expected the view to carry version %d, but got %d", g.Version, view.Version)
+	}
+}
+
+func TestGetGameHandler_LongPoll(t *testing.T) {
+	s, _ := setupTestServer()
+	g := createGame(t, s)
+	g.AddPlayer("Player 1")
+	g.AddPlayer("Player 2")
+	version := g.Version
+	watcher := watcherToken(t, g)
+
+	poll := func(query string) (game.SpectatorView, time.Duration) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/games/%s?%s", g.ID, query), nil)
+		rr := httptest.NewRecorder()
+		start := time.Now()
+		s.router.ServeHTTP(rr, authorize(req, watcher))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var view game.SpectatorView
+		json.Unmarshal(rr.Body.Bytes(), &view)
+		return view, time.Since(start)
+	}
+
+	t.Run("returns at once if the state has moved on", func(t *testing.T) {
+		view, took := poll(fmt.Sprintf("waitForVersion=%d", version-1))
+		if view.Version != version || took > time.Second {
+			t.Errorf("expected version %d at once, but got %d after %s", version, view.Version, took)
+		}
+	})
+
+	t.Run("wakes up when the state changes", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			g.StartGame()
+		}()
+		view, took := poll(fmt.Sprintf("waitForVersion=%d&timeout=5", version))
+		if view.Version <= version || took > 4*time.Second {
+			t.Errorf("expected a newer version than %d before the timeout, but got %d after %s", version, view.Version, took)
+		}
+	})
+
+	t.Run("times out with the current state", func(t *testing.T) {
+		current := g.CurrentVersion()
+		view, took := poll(fmt.Sprintf("waitForVersion=%d&timeout=1", current))
+		if view.Version != current || took < time.Second {
+			t.Errorf("expected version %d after the timeout, but got %d after %s", current, view.Version, took)
+		}
+	})
+}
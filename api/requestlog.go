@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// requestIDHeader carries a request's ID. A client may send its own, to tie
+// the server's log to its own; otherwise the server makes one up. Either way
+// it is sent back on the response.
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID matches the request IDs accepted from clients.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// quietRoutes are logged at debug level when they succeed, since probes and
+// scrapers call them all the time.
+var quietRoutes = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// routeTemplate returns the template of the route r matched, such as
+// "/v1/games/{gameID}", or its path if it matched none.
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// unversioned strips the API version prefix from a route.
+func unversioned(route string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(route, "/v1"), "/v2")
+}
+
+// quietRoute reports whether route is one that probes and scrapers call.
+func quietRoute(route string) bool {
+	return quietRoutes[unversioned(route)]
+}
+
+// requestLog collects what the handlers learn about a request, for the line
+// logged when it ends.
+type requestLog struct {
+	id       string
+	gameID   string
+	playerID string
+	action   string
+}
+
+type requestLogKey struct{}
+
+// requestLogFor returns the log of r, or nil if r is not being logged.
+func requestLogFor(r *http.Request) *requestLog {
+	l, _ := r.Context().Value(requestLogKey{}).(*requestLog)
+	return l
+}
+
+// noteGame records the game a request is about, for requests that do not
+// name it in their path.
+func noteGame(r *http.Request, gameID string) {
+	if l := requestLogFor(r); l != nil {
+		l.gameID = gameID
+	}
+}
+
+// notePlayer records the player who made a request.
+func notePlayer(r *http.Request, playerID string) {
+	if l := requestLogFor(r); l != nil && playerID != "" {
+		l.playerID = playerID
+	}
+}
+
+// noteAction records what a request does to its game.
+func noteAction(r *http.Request, action string) {
+	if l := requestLogFor(r); l != nil {
+		l.action = action
+	}
+}
+
+// newRequestID returns a random request ID.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// instrument logs every request to a route of the router and records its
+// latency in the metrics. Streams and WebSockets are logged and timed when
+// they close.
+func (s *Server) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		route := routeTemplate(r)
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		l := &requestLog{id: id, gameID: mux.Vars(r)["gameID"]}
+		r = r.WithContext(context.WithValue(r.Context(), requestLogKey{}, l))
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		took := time.Since(start)
+
+		s.metrics.observeRequest(r.Method, route, sw.status, took)
+		s.logRequest(r.Context(), r.Method, route, l, sw, took)
+	})
+}
+
+// logRequest writes the log line for a finished request.
+func (s *Server) logRequest(ctx context.Context, method, route string, l *requestLog, sw *statusWriter, took time.Duration) {
+	level := slog.LevelInfo
+	switch {
+	case sw.status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case sw.status < http.StatusBadRequest && quietRoute(route):
+		level = slog.LevelDebug
+	}
+	outcome := sw.errorCode
+	if outcome == "" {
+		outcome = "ok"
+	}
+
+	attrs := []slog.Attr{
+		slog.String("request_id", l.id),
+		slog.String("method", method),
+		slog.String("route", route),
+	}
+	if l.gameID != "" {
+		attrs = append(attrs, slog.String("game_id", l.gameID))
+		s.mu.Lock()
+		g := s.games[l.gameID]
+		s.mu.Unlock()
+		if g != nil {
+			ctx = g.LogContext(ctx)
+		}
+	}
+	if l.playerID != "" {
+		attrs = append(attrs, slog.String("player_id", l.playerID))
+	}
+	if l.action != "" {
+		attrs = append(attrs, slog.String("action", l.action))
+	}
+	attrs = append(attrs,
+		slog.Int("status", sw.status),
+		slog.String("outcome", outcome),
+		slog.Duration("latency", took))
+	slog.LogAttrs(ctx, level, "request", attrs...)
+}
+
+// logCommand writes the log line for a command received over a WebSocket.
+func (s *socketSession) logCommand(cmd SocketCommand, reply SocketMessage, took time.Duration) {
+	level, outcome := slog.LevelInfo, "ok"
+	if reply.Type == "error" {
+		outcome = reply.Code
+		if reply.Code == CodeInternal {
+			level = slog.LevelError
+		}
+	}
+	slog.LogAttrs(s.game.LogContext(context.Background()), level, "socket command",
+		slog.String("request_id", s.requestID),
+		slog.String("command_id", cmd.RequestID),
+		slog.String("game_id", s.game.ID),
+		slog.String("player_id", s.playerID),
+		slog.String("action", string(cmd.Action)),
+		slog.String("outcome", outcome),
+		slog.Duration("latency", took))
+}
+
+// noteErrorCode records the error code of a response for the request log.
+func noteErrorCode(w http.ResponseWriter, code string) {
+	for {
+		switch rw := w.(type) {
+		case *statusWriter:
+			rw.errorCode = code
+			return
+		case *responseRecorder:
+			rw.errorCode = code
+			return
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
+
+// statusWriter remembers the status and error code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	errorCode   string
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher for streams.
+func (w *statusWriter) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker for WebSocket upgrades, which are recorded
+// as 101 Switching Protocols.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.status, w.wroteHeader = http.StatusSwitchingProtocols, true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
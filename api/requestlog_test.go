@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nuclear-war-game-server/game"
+)
+
+// captureLog sends the default logger's JSON records to the returned buffer
+// until the test ends, letting debugged games' records through as main does.
+func captureLog(t *testing.T, level slog.Level) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(game.DebugHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// logRecords parses the JSON records in buf with the given message.
+func logRecords(t *testing.T, buf *bytes.Buffer, msg string) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("could not parse log line %q: %v", line, err)
+		}
+		if rec["msg"] == msg {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+func TestRequestLogging(t *testing.T) {
+	buf := captureLog(t, slog.LevelInfo)
+	s := NewServer()
+	g, p1, _, token1, token2 := startedGame(t, s)
+
+	send := func(token, requestID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/v1/games/%s/pass", g.ID), nil)
+		if requestID != "" {
+			req.Header.Set(requestIDHeader, requestID)
+		}
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, authorize(req, token))
+		return rr
+	}
+	ok := send(token1, "client-id-1")
+	rejected := send(token1, "bad id with spaces")
+	send(token2, "")
+
+	if got := ok.Header().Get(requestIDHeader); got != "client-id-1" {
+		t.Errorf("expected the client's request ID back, but got %q", got)
+	}
+	if got := rejected.Header().Get(requestIDHeader); got == "" || strings.Contains(got, " ") {
+		t.Errorf("expected an invalid request ID to be replaced, but got %q", got)
+	}
+
+	records := logRecords(t, buf, "request")
+	if len(records) != 3 {
+		t.Fatalf("expected 3 request records, but got %d:\n%s", len(records), buf.String())
+	}
+	first := records[0]
+	for key, want := range map[string]interface{}{
+		"request_id": "client-id-1",
+		"method":     "POST",
+		"route":      "/v1/games/{gameID}/pass",
+		"game_id":    g.ID,
+		"player_id":  p1.ID,
+		"action":     "pass",
+		"status":     float64(http.StatusOK),
+		"outcome":    "ok",
+	} {
+		if first[key] != want {
+			t.Errorf("expected %s to be %v, but got %v", key, want, first[key])
+		}
+	}
+	if _, ok := first["latency"]; !ok {
+		t.Error("expected the latency to be logged")
+	}
+	if records[1]["outcome"] != "not_your_turn" {
+		t.Errorf("expected the rejection's error code as the outcome, but got %v", records[1]["outcome"])
+	}
+}
+
+func TestRequestLogging_IdempotencyKey(t *testing.T) {
+	buf := captureLog(t, slog.LevelInfo)
+	s := NewServer()
+	g, _, _, _, token2 := startedGame(t, s)
+
+	// Player 2 passes out of turn, then retries with the same key.
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/v1/games/%s/pass", g.ID), http.NoBody)
+		req.Header.Set("Idempotency-Key", "retry-me")
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, authorize(req, token2))
+		if rr.Code != http.StatusConflict {
+			t.Fatalf("expected status %d, but got %d", http.StatusConflict, rr.Code)
+		}
+	}
+
+	records := logRecords(t, buf, "request")
+	if len(records) != 2 {
+		t.Fatalf("expected 2 request records, but got %d:\n%s", len(records), buf.String())
+	}
+	for i, rec := range records {
+		if rec["outcome"] != "not_your_turn" {
+			t.Errorf("expected request %d to be logged as not_your_turn, but got %v", i+1, rec["outcome"])
+		}
+	}
+}
+
+func TestRequestLogging_QuietRoutes(t *testing.T) {
+	buf := captureLog(t, slog.LevelInfo)
+	s := NewServer()
+	for _, path := range []string{"/healthz", "/v1/readyz", "/metrics"} {
+		get(s, path)
+	}
+	if records := logRecords(t, buf, "request"); len(records) != 0 {
+		t.Errorf("expected probes to be logged at debug level only, but got %v", records)
+	}
+}
+
+func TestAdminDebugHandler(t *testing.T) {
+	buf := captureLog(t, slog.LevelInfo)
+	s := NewServer()
+	s.SetAdminToken("admin-secret")
+	g, p1, _, _, _ := startedGame(t, s)
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/games/%s/debug", g.ID), strings.NewReader(`{"enabled": true}`))
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, authorize(req, "admin-secret"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if !g.Debug() {
+		t.Fatal("expected debug logging to be on for the game")
+	}
+
+	if records := logRecords(t, buf, "game debug logging switched"); len(records) != 1 || records[0]["game_id"] != g.ID {
+		t.Errorf("expected the switch to be logged, but got %v", records)
+	}
+
+	// With debug on, the game's events are logged although the level is info.
+	if err := g.Apply(game.ForfeitAction{PlayerID: p1.ID}); err != nil {
+		t.Fatalf("forfeit failed unexpectedly: %v", err)
+	}
+	debugged := false
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, `"level":"DEBUG"`) && strings.Contains(line, g.ID) {
+			debugged = true
+		}
+	}
+	if !debugged {
+		t.Errorf("expected the game's debug records to be logged, but got:\n%s", buf.String())
+	}
+}
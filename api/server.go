@@ -1,30 +1,54 @@
 package api
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"nuclear-war-game-server/game"
+	"nuclear-war-game-server/store"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 // Server holds the state of the API server, including all active games.
 type Server struct {
-	games  map[string]*game.Game
-	mu     sync.Mutex
-	router *mux.Router
+	games      map[string]*game.Game
+	joinCodes  map[string]string // Join code to game ID
+	mu         sync.Mutex
+	router     *mux.Router
+	adminToken string      // Bearer token for the admin endpoints; empty disables them
+	store      store.Store // Where games are kept between restarts
+
+	idempotency *idempotencyCache // Results of actions sent with an Idempotency-Key
+	metrics     *metrics          // Observes every game; served at /metrics
+
+	limits        Limits
+	ipLimiter     *rateLimiter  // nil when requests per IP are not limited
+	tokenLimiter  *rateLimiter  // nil when requests per token are not limited
+	createLimiter *rateLimiter  // nil when game creations are not limited
+	writeTimeout  time.Duration // The HTTP server's, which long requests extend
+
+	draining atomic.Bool    // Set once shutdown begins; no new games are taken
+	stopping chan struct{}  // Closed once shutdown begins
+	stopOnce sync.Once      // Guards closing stopping
+	sockets  sync.WaitGroup // Open WebSockets, which outlive http.Server.Shutdown
 }
 
 // NewServer creates a new API server instance.
 func NewServer() *Server {
 	s := &Server{
-		games:  make(map[string]*game.Game),
-		router: mux.NewRouter(),
+		games:       make(map[string]*game.Game),
+		joinCodes:   make(map[string]string),
+		router:      mux.NewRouter(),
+		idempotency: newIdempotencyCache(),
+		metrics:     newMetrics(),
+		store:       store.NewMemory(),
+		stopping:    make(chan struct{}),
+		limits:      Limits{MaxBodyBytes: defaultMaxBodyBytes},
 	}
 	s.routes()
 	return s
@@ -46,42 +70,149 @@ func (s *Server) getGameFromRequest(r *http.Request) (*game.Game, error) {
 	return g, nil
 }
 
+// CreateGameRequest is the optional body for a create game request.
+type CreateGameRequest struct {
+	Name       string           `json:"name"`
+	Visibility game.Visibility  `json:"visibility"` // "public" (default) or "private"
+	Password   string           `json:"password"`   // Optional join password
+	Options    game.GameOptions `json:"options"`
+	// PlayerName, if set, seats the creator as the game's host. Otherwise the
+	// first player to join becomes the host.
+	PlayerName string `json:"playerName,omitempty"`
+}
+
 func (s *Server) createGameHandler(w http.ResponseWriter, r *http.Request) {
+	if s.rejectWhileDraining(w) {
+		return
+	}
+	var req CreateGameRequest
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeBodyError(w, err)
+			return
+		}
+	}
 
-	newGame := game.NewGame()
+	newGame, err := game.NewGameWithOptions(req.Options)
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+	newGame.SetObserver(s.metrics)
+	noteGame(r, newGame.ID)
+	noteAction(r, "create")
+	settings := game.LobbySettings{Name: req.Name, Visibility: req.Visibility, Password: req.Password}
+	if err := newGame.SetLobbySettings(settings); err != nil {
+		writeGameError(w, err)
+		return
+	}
 
-	s.mu.Lock()
-	s.games[newGame.ID] = newGame
-	s.mu.Unlock()
+	// The game is only registered once the creator has their seat or token,
+	// so a failed create leaves nothing behind in the lobby or the store.
+	if req.PlayerName != "" {
+		join := game.NewJoinAction(req.PlayerName)
+		if err := newGame.Apply(join); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		token, err := newGame.IssuePlayerToken(join.PlayerID)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		if err := s.registerGame(newGame); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		notePlayer(r, join.PlayerID)
+		writeJSON(w, http.StatusCreated, JoinGameResponse{PlayerView: newGame.NewPlayerView(join.PlayerID), PlayerToken: token})
+		return
+	}
+
+	// Without a seat the creator watches the game, and hands out its join
+	// code, which spectators do not see.
+	token, err := newGame.IssueSpectatorToken("")
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+	if err := s.registerGame(newGame); err != nil {
+		writeGameError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, CreateGameResponse{SpectatorView: newGame.DelayedSpectatorView(time.Now()),
+		JoinCode: newGame.Summary().JoinCode, SpectatorToken: token})
+}
 
+// writeJSON writes v as the JSON response body with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	if e, ok := v.(ErrorResponse); ok {
+		noteErrorCode(w, e.Code)
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(newGame)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeView writes the game as seen by playerID, or the public spectator view
+// if playerID is empty. The full game state is never written from here, so
+// no response can leak another player's hand. Spectators see the game with
+// the delay the game was created with.
+func writeView(w http.ResponseWriter, status int, g *game.Game, playerID string) {
+	writeJSON(w, status, viewFor(g, playerID))
+}
+
+// viewFor returns the game as seen by playerID, or the public spectator view
+// if playerID is empty or no longer seated. Every transport builds its views
+// here, so they all redact the same way.
+func viewFor(g *game.Game, playerID string) interface{} {
+	if playerID != "" {
+		if view := g.NewPlayerView(playerID); view != nil {
+			return view
+		}
+	}
+	return g.DelayedSpectatorView(time.Now())
 }
 
 // JoinGameRequest is the expected body for a join game request.
 type JoinGameRequest struct {
 	PlayerName string `json:"playerName"`
+	Password   string `json:"password,omitempty"` // Required if the game has a join password
+}
+
+// CreateGameResponse is the body of a successful create that did not seat the
+// creator: the spectator view, the join code to hand out, and a spectator
+// token to follow the game with.
+type CreateGameResponse struct {
+	*game.SpectatorView
+	JoinCode       string `json:"joinCode,omitempty"`
+	SpectatorToken string `json:"spectatorToken"`
+}
+
+// JoinGameResponse is the body of a successful join: the new player's view of
+// the game plus the secret token they must send with every later request.
+type JoinGameResponse struct {
+	*game.PlayerView
+	PlayerToken string `json:"playerToken"`
 }
 
 // PlayCardRequest is the expected body for a play card request.
+// The acting player is identified by their token, not by the body.
 type PlayCardRequest struct {
-	PlayerID string `json:"playerID"`
 	CardID   string `json:"cardID"`
-	Location string `json:"location"` // e.g., "face_up", "face_down_1", "deterrent_1"
+	Location string `json:"location"`           // e.g., "face_up", "face_down_1", "deterrent_1"
+	TargetID string `json:"targetID,omitempty"` // The player a face-up Propaganda card steals from
 }
 
-// GameViewForPlayer is a custom view of the game state for a specific player,
-// including their hand.
-type GameViewForPlayer struct {
-	*game.Game
-	PlayerHand []*game.Card `json:"player_hand,omitempty"`
+// action returns the play as taken by playerID.
+func (req PlayCardRequest) action(playerID string) game.PlayCardAction {
+	return game.PlayCardAction{PlayerID: playerID, CardID: req.CardID, Location: req.Location, TargetID: req.TargetID}
 }
 
 func (s *Server) gameHandler(w http.ResponseWriter, r *http.Request) {
 	g, err := s.getGameFromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeGameNotFound(w, err)
 		return
 	}
 
@@ -89,221 +220,388 @@ func (s *Server) gameHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) getGameStateHandler(w http.ResponseWriter, r *http.Request, g *game.Game) {
-	playerID := r.URL.Query().Get("playerID")
-
-	w.Header().Set("Content-Type", "application/json")
+	playerID, ok := authenticatePlayer(r, g)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, "a valid player or spectator token is required (Authorization: Bearer <token>)")
+		return
+	}
 
-	// If a playerID is provided, return a view specific to that player
-	if playerID != "" {
-		if _, ok := g.Players[playerID]; !ok {
-			http.Error(w, "Player not found in this game", http.StatusNotFound)
-			return
-		}
-		playerView := g.NewPlayerView(playerID)
+	// A playerID alone no longer identifies the caller; it must match the token.
+	if requested := r.URL.Query().Get("playerID"); requested != "" && requested != playerID {
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, "a valid player token is required to view a player's hand")
+		return
+	}
 
-		json.NewEncoder(w).Encode(playerView)
+	version, timeout, wait, err := parseLongPoll(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
 		return
 	}
 
-	// Otherwise, return the general game state
-	json.NewEncoder(w).Encode(g)
+	// Return the caller's view, or the public spectator view for spectators
+	view := viewFor(g, playerID)
+	if wait {
+		// A shutdown answers long polls at once, so they do not hold it up.
+		ctx, cancel := s.untilShutdown(r.Context())
+		defer cancel()
+		s.extendWrite(holdOpen(w), timeout)
+		view = waitForView(ctx, g, playerID, version, timeout)
+	}
+	writeCachedView(w, r, view)
+}
+
+// SpectateRequest is the optional body for a spectate request.
+type SpectateRequest struct {
+	Name string `json:"name"`
 }
 
-func (s *Server) joinGameHandler(w http.ResponseWriter, r *http.Request) {
-	// Log the request body for debugging
-	bodyBytes, err := io.ReadAll(r.Body)
+// SpectateResponse is the body of a successful spectate request: the public
+// view of the game plus a watcher token to follow it with.
+type SpectateResponse struct {
+	*game.SpectatorView
+	SpectatorToken string `json:"spectatorToken"`
+}
+
+func (s *Server) spectateHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
 	if err != nil {
-		log.Printf("Error reading body: %v", err)
-		http.Error(w, "can't read body", http.StatusBadRequest)
+		writeGameNotFound(w, err)
 		return
 	}
-	r.Body.Close() //  must close
-	// And now set a new body, which will simulate the same data we read:
-	r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 
-	var joinReq JoinGameRequest
-	if err := json.Unmarshal(bodyBytes, &joinReq); err == nil {
-		log.Printf("Received join request from player '%s'", joinReq.PlayerName)
+	var req SpectateRequest
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeBodyError(w, err)
+			return
+		}
+	}
+
+	token, err := g.IssueSpectatorToken(req.Name)
+	if err != nil {
+		writeGameError(w, err)
+		return
 	}
 
+	noteAction(r, "spectate")
+	writeJSON(w, http.StatusOK, SpectateResponse{SpectatorView: g.DelayedSpectatorView(time.Now()), SpectatorToken: token})
+}
+
+func (s *Server) joinGameHandler(w http.ResponseWriter, r *http.Request) {
 	g, err := s.getGameFromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeGameNotFound(w, err)
 		return
 	}
+	s.joinGame(w, r, g)
+}
 
+// joinGame seats the player named in the request body in g.
+func (s *Server) joinGame(w http.ResponseWriter, r *http.Request, g *game.Game) {
+	noteGame(r, g.ID)
+	noteAction(r, "join")
 	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
 		return
 	}
 
 	var req JoinGameRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeBodyError(w, err)
 		return
 	}
 
-	if req.PlayerName == "" {
-		http.Error(w, "Player name must be provided", http.StatusBadRequest)
+	if !g.CheckPassword(req.Password) {
+		writeError(w, http.StatusForbidden, CodeWrongPassword, "Incorrect game password")
 		return
 	}
 
-	if g.IsFull() {
-		http.Error(w, "Game is full", http.StatusConflict)
+	// Joining is rejected for empty names, full games and games that have
+	// started.
+	join := game.NewJoinAction(req.PlayerName)
+	if err := g.Apply(join); err != nil {
+		writeGameError(w, err)
 		return
 	}
 
-	if g.HasStarted() {
-		http.Error(w, "Game has already started", http.StatusConflict)
-		return
-	}
+	notePlayer(r, join.PlayerID)
 
-	player, err := g.AddPlayer(req.PlayerName)
+	token, err := g.IssuePlayerToken(join.PlayerID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeGameError(w, err)
 		return
 	}
 
-	log.Printf("Player '%s' successfully joined game %s", req.PlayerName, g.ID)
-
-	log.Printf("Game %s: Player '%s' joined (total players: %d)", g.ID, req.PlayerName, len(g.Players))
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(player)
+	writeJSON(w, http.StatusOK, JoinGameResponse{PlayerView: g.NewPlayerView(join.PlayerID), PlayerToken: token})
 }
 
 func (s *Server) startGameHandler(w http.ResponseWriter, r *http.Request) {
-	log.Printf("START GAME: Handler called for URL: %s", r.URL.Path)
-	
 	g, err := s.getGameFromRequest(r)
 	if err != nil {
-		log.Printf("START GAME ERROR: Failed to get game: %v", err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeGameNotFound(w, err)
 		return
 	}
 
-	log.Printf("START GAME: Found game %s with %d players in state: %s", g.ID, len(g.Players), g.State)
-	
 	if r.Method != http.MethodPost {
-		log.Printf("START GAME ERROR: Invalid method: %s", r.Method)
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
 		return
 	}
 
-	log.Printf("START GAME: Calling g.StartGame() for game %s", g.ID)
-	if err := g.StartGame(); err != nil {
-		log.Printf("START GAME ERROR: Failed to start game: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	playerID, ok := requirePlayer(w, r, g)
+	if !ok {
 		return
 	}
 
-	log.Printf("START GAME: Game %s successfully started! New state: %s", g.ID, g.State)
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(g)
+	if err := applyAction(r, g, game.StartGameAction{PlayerID: playerID}); err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	writeView(w, http.StatusOK, g, playerID)
 }
 
 // AttackRequest defines the expected body for an attack request.
+// The attacker is identified by their token, not by the body.
 type AttackRequest struct {
-	AttackerID string `json:"attackerID"`
-	TargetID   string `json:"targetID"`
+	TargetID string `json:"targetID"`
 }
 
 func (s *Server) attackHandler(w http.ResponseWriter, r *http.Request) {
 	g, err := s.getGameFromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeGameNotFound(w, err)
 		return
 	}
 
 	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	attackerID, ok := requirePlayer(w, r, g)
+	if !ok {
 		return
 	}
 
 	var req AttackRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeBodyError(w, err)
 		return
 	}
 
-	if err := g.Attack(req.AttackerID, req.TargetID); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := applyAction(r, g, game.AttackAction{AttackerID: attackerID, TargetID: req.TargetID}); err != nil {
+		writeGameError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(g)
+	writeView(w, http.StatusOK, g, attackerID)
 }
 
 func (s *Server) passHandler(w http.ResponseWriter, r *http.Request) {
-	game, err := s.getGameFromRequest(r)
+	g, err := s.getGameFromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeGameNotFound(w, err)
 		return
 	}
 
-	var reqBody struct {
-		PlayerID string `json:"playerID"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	playerID, ok := requirePlayer(w, r, g)
+	if !ok {
 		return
 	}
 
-	if err := game.PassTurn(reqBody.PlayerID); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := applyAction(r, g, game.PassTurnAction{PlayerID: playerID}); err != nil {
+		writeGameError(w, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(game)
+	writeView(w, http.StatusOK, g, playerID)
 }
 
 func (s *Server) playCardHandler(w http.ResponseWriter, r *http.Request) {
 	g, err := s.getGameFromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeGameNotFound(w, err)
 		return
 	}
 
 	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	playerID, ok := requirePlayer(w, r, g)
+	if !ok {
 		return
 	}
 
 	var req PlayCardRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeBodyError(w, err)
+		return
+	}
+
+	if err := applyAction(r, g, req.action(playerID)); err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	writeView(w, http.StatusOK, g, playerID)
+}
+
+// TurnRequest is the expected body for a turn request: the cards to play, in
+// order, and the player to attack afterwards.
+type TurnRequest struct {
+	Plays []PlayCardRequest `json:"plays"`
+	// TargetID is the player to attack once the cards are played. The turn
+	// ends with a pass if it is empty.
+	TargetID string `json:"targetID,omitempty"`
+}
+
+// turnAction returns a whole turn as taken by playerID.
+func turnAction(playerID string, plays []PlayCardRequest, targetID string) game.TurnAction {
+	a := game.TurnAction{PlayerID: playerID, AttackTargetID: targetID}
+	for _, play := range plays {
+		a.Plays = append(a.Plays, play.action(playerID))
+	}
+	return a
+}
+
+// turnHandler takes a player's whole turn in one request. Either every play
+// and the closing attack or pass succeed, or nothing changes.
+func (s *Server) turnHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+
+	playerID, ok := requirePlayer(w, r, g)
+	if !ok {
+		return
+	}
+
+	var req TurnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
 		return
 	}
 
-	if err := g.PlayCard(req.PlayerID, req.CardID, req.Location); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := applyAction(r, g, turnAction(playerID, req.Plays, req.TargetID)); err != nil {
+		writeGameError(w, err)
 		return
 	}
 
+	writeView(w, http.StatusOK, g, playerID)
+}
+
+// transitionsHandler serves the game's state transition table.
+func (s *Server) transitionsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(g)
+	json.NewEncoder(w).Encode(game.Transitions())
+}
+
+// authKind is the credential an endpoint takes.
+type authKind int
+
+const (
+	authNone   authKind = iota
+	authViewer          // A player token shows the caller's own view, a spectator token the public one
+	authPlayer          // A player token is required
+	authAdmin           // The admin token is required
+)
+
+// route is one endpoint of the API. The same table registers the handlers
+// and describes them in the OpenAPI document, so the two cannot drift apart.
+type route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+	summary string
+	auth    authKind
+	query   []string    // Query parameters the endpoint reads
+	request interface{} // The JSON request body, if it takes one
+	status  int         // Status of a successful response
+	// responses are the bodies a successful response may carry. There are
+	// none for responses that are not JSON, such as streams.
+	responses []interface{}
+}
+
+// apiRoutes lists every endpoint of the API, without the version prefix.
+func (s *Server) apiRoutes() []route {
+	views := []interface{}{game.PlayerView{}, game.SpectatorView{}}
+	playerView := []interface{}{game.PlayerView{}}
+	action := func(path string, handler http.HandlerFunc, summary string, request interface{}) route {
+		return route{method: "POST", path: path, handler: s.idempotent(handler), summary: summary, auth: authPlayer,
+			request: request, status: http.StatusOK, responses: playerView}
+	}
+	return []route{
+		{method: "GET", path: "/openapi.json", handler: s.openAPIHandler, summary: "This API's OpenAPI document",
+			status: http.StatusOK, responses: []interface{}{openAPIDocument{}}},
+		{method: "GET", path: "/healthz", handler: s.healthzHandler, summary: "Whether the process is alive",
+			status: http.StatusOK, responses: []interface{}{HealthResponse{}}},
+		{method: "GET", path: "/readyz", handler: s.readyzHandler, summary: "Whether the server can take traffic: not shutting down, with a working store",
+			status: http.StatusOK, responses: []interface{}{HealthResponse{}}},
+		{method: "GET", path: "/version", handler: s.versionHandler, summary: "The running build and its card catalog",
+			status: http.StatusOK, responses: []interface{}{VersionResponse{}}},
+		{method: "GET", path: "/metrics", handler: s.metricsHandler, summary: "Server metrics in the Prometheus text format",
+			status: http.StatusOK},
+		{method: "GET", path: "/rules/transitions", handler: s.transitionsHandler, summary: "The game's state transition table",
+			status: http.StatusOK, responses: []interface{}{[]game.Transition{}}},
+		{method: "POST", path: "/games", handler: s.createGameHandler, summary: "Create a game, optionally seating the creator as host",
+			request: CreateGameRequest{}, status: http.StatusCreated, responses: []interface{}{JoinGameResponse{}, CreateGameResponse{}}},
+		{method: "GET", path: "/games", handler: s.listGamesHandler, summary: "List public games, newest first",
+			query:  []string{"state", "seatsFree", "hasPassword", "spectatorDelay", "name", "page", "pageSize"},
+			status: http.StatusOK, responses: []interface{}{GameList{}}},
+		{method: "GET", path: "/games/{gameID}", handler: s.gameHandler, summary: "The caller's view of a game",
+			auth: authViewer, query: []string{"waitForVersion", "timeout"}, status: http.StatusOK, responses: views},
+		{method: "GET", path: "/games/{gameID}/summary", handler: s.gameSummaryHandler, summary: "The lobby listing of a game",
+			status: http.StatusOK, responses: []interface{}{game.GameSummary{}}},
+		{method: "POST", path: "/games/{gameID}/join", handler: s.joinGameHandler, summary: "Take a seat in a game",
+			request: JoinGameRequest{}, status: http.StatusOK, responses: []interface{}{JoinGameResponse{}}},
+		{method: "GET", path: "/join/{code}", handler: s.joinCodeHandler, summary: "The lobby listing of the game a join code belongs to",
+			status: http.StatusOK, responses: []interface{}{game.GameSummary{}}},
+		{method: "POST", path: "/join/{code}", handler: s.joinByCodeHandler, summary: "Take a seat in a game by its join code",
+			request: JoinGameRequest{}, status: http.StatusOK, responses: []interface{}{JoinGameResponse{}}},
+		{method: "POST", path: "/games/{gameID}/spectate", handler: s.spectateHandler, summary: "Follow a game without joining it",
+			request: SpectateRequest{}, status: http.StatusOK, responses: []interface{}{SpectateResponse{}}},
+		action("/games/{gameID}/ready", s.readyHandler, "Mark yourself ready to start, or not ready", ReadyRequest{}),
+		action("/games/{gameID}/kick", s.kickHandler, "Remove a player from the game (host only)", PlayerTargetRequest{}),
+		action("/games/{gameID}/transfer_host", s.transferHostHandler, "Make another player the host (host only)", PlayerTargetRequest{}),
+		action("/games/{gameID}/options", s.updateOptionsHandler, "Change the game options (host only)", UpdateOptionsRequest{}),
+		action("/games/{gameID}/start", s.startGameHandler, "Start the game (host only)", nil),
+		action("/games/{gameID}/play", s.playCardHandler, "Play a card from your hand", PlayCardRequest{}),
+		action("/games/{gameID}/attack", s.attackHandler, "Attack a player", AttackRequest{}),
+		action("/games/{gameID}/pass", s.passHandler, "Pass your turn", nil),
+		action("/games/{gameID}/turn", s.turnHandler, "Play cards, then attack or pass, all or nothing", TurnRequest{}),
+		{method: "GET", path: "/games/{gameID}/ws", handler: s.socketHandler, summary: "A WebSocket pushing views and events, and taking commands",
+			auth: authViewer, query: []string{"token", "since"}, status: http.StatusSwitchingProtocols},
+		{method: "GET", path: "/games/{gameID}/events", handler: s.streamHandler, summary: "A Server-Sent Events stream of views and events",
+			auth: authViewer, query: []string{"token", "lastEventId"}, status: http.StatusOK},
+		{method: "POST", path: "/games/{gameID}/leave", handler: s.idempotent(s.leaveHandler), summary: "Give up your seat before the game starts",
+			auth: authPlayer, status: http.StatusOK, responses: []interface{}{game.SpectatorView{}}},
+		action("/games/{gameID}/forfeit", s.forfeitHandler, "Concede the game", nil),
+		{method: "GET", path: "/admin/games/{gameID}", handler: s.requireAdmin(s.adminGameHandler), summary: "The full, unredacted game state",
+			auth: authAdmin, status: http.StatusOK, responses: []interface{}{&game.Game{}}},
+		{method: "POST", path: "/admin/games/{gameID}/debug", handler: s.requireAdmin(s.adminDebugHandler), summary: "Switch debug logging for a game on or off",
+			auth: authAdmin, request: DebugRequest{}, status: http.StatusOK, responses: []interface{}{DebugResponse{}}},
+	}
 }
 
-// Start runs the HTTP server.
+// routes registers the API's HTTP handlers under /v1 and /v2. The unprefixed
+// routes are aliases of /v1, kept for existing clients.
 func (s *Server) routes() {
-	s.router.HandleFunc("/games", s.createGameHandler).Methods("POST")
-	s.router.HandleFunc("/games/{gameID}", s.gameHandler).Methods("GET")
-	s.router.HandleFunc("/games/{gameID}/join", s.joinGameHandler).Methods("POST")
-	s.router.HandleFunc("/games/{gameID}/start", s.startGameHandler).Methods("POST")
-	s.router.HandleFunc("/games/{gameID}/play", s.playCardHandler).Methods("POST")
-	s.router.HandleFunc("/games/{gameID}/attack", s.attackHandler).Methods("POST")
-	s.router.HandleFunc("/games/{gameID}/pass", s.passHandler).Methods("POST")
-}
-
-// Start runs the HTTP server.
-func (s *Server) Start() {
-	fmt.Println("Nuclear War server listening on port 8080...")
-	if err := http.ListenAndServe(":8080", s.router); err != nil {
-		log.Fatalf("could not start server: %v", err)
+	s.router.Use(s.instrument, s.limit)
+	for _, router := range []*mux.Router{s.router.PathPrefix("/v1").Subrouter(), s.router} {
+		s.register(router)
+	}
+	v2 := s.router.PathPrefix("/v2").Subrouter()
+	v2.Use(v2Schema)
+	s.register(v2)
+}
+
+// register adds every route to router.
+func (s *Server) register(router *mux.Router) {
+	for _, rt := range s.apiRoutes() {
+		router.HandleFunc(rt.path, rt.handler).Methods(rt.method)
 	}
 }
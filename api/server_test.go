@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
-
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"nuclear-war-game-server/game"
 )
@@ -32,27 +35,90 @@ func createGame(t *testing.T, s *Server) *game.Game {
 		t.Fatalf("createGame handler returned wrong status code: got %v want %v", status, http.StatusCreated)
 	}
 
-	var g game.Game
-	if err := json.NewDecoder(rr.Body).Decode(&g); err != nil {
+	var view game.SpectatorView
+	if err := json.NewDecoder(rr.Body).Decode(&view); err != nil {
 		t.Fatalf("could not parse response JSON from createGame: %v", err)
 	}
 
-	if g.ID == "" {
+	if view.GameID == "" {
 		t.Fatal("createGame returned a game with an empty ID")
 	}
 
 	// The handler adds the game to s.games. We need to retrieve the pointer to the
 	// actual game instance from the server's map to check its state directly.
 	s.mu.Lock()
-	gameInstance, ok := s.games[g.ID]
+	gameInstance, ok := s.games[view.GameID]
 	s.mu.Unlock()
 	if !ok {
-		t.Fatalf("game %s not found in server map after creation", g.ID)
+		t.Fatalf("game %s not found in server map after creation", view.GameID)
 	}
 
 	return gameInstance
 }
 
+// playOpeningSecrets plays every player's secret card so that the game
+// leaves the opening round and moves into the main phase.
+func playOpeningSecrets(t *testing.T, g *game.Game) {
+	t.Helper()
+	for _, id := range g.PlayerOrder {
+		for _, c := range g.Players[id].Hand {
+			if c.Type == game.TypeSecret {
+				if err := g.PlayCard(id, c.ID, "face_down_1"); err != nil {
+					t.Fatalf("failed to play secret card: %v", err)
+				}
+				break
+			}
+		}
+	}
+	if g.State != game.StateInProgress {
+		t.Fatalf("expected game state to be '%s' after the opening round, but got '%s'", game.StateInProgress, g.State)
+	}
+}
+
+// issueToken issues a player token directly on the game, for tests that seat
+// players without going through the join endpoint.
+func issueToken(t *testing.T, g *game.Game, playerID string) string {
+	t.Helper()
+	token, err := g.IssuePlayerToken(playerID)
+	if err != nil {
+		t.Fatalf("failed to issue player token: %v", err)
+	}
+	return token
+}
+
+// watcherToken issues a spectator token for g.
+func watcherToken(t *testing.T, g *game.Game) string {
+	t.Helper()
+	token, err := g.IssueSpectatorToken("Watcher")
+	if err != nil {
+		t.Fatalf("failed to issue spectator token: %v", err)
+	}
+	return token
+}
+
+// authorize adds a player's bearer token to a request.
+func authorize(req *http.Request, token string) *http.Request {
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// assertNoHandLeaked fails the test if a response body contains the ID of any
+// card in the hand of a player other than viewerID.
+func assertNoHandLeaked(t *testing.T, body []byte, g *game.Game, viewerID string) {
+	t.Helper()
+	for _, id := range g.PlayerOrder {
+		if id == viewerID {
+			continue
+		}
+		snapshot, _ := g.PlayerSnapshot(id)
+		for _, c := range snapshot.Hand {
+			if bytes.Contains(body, []byte(`"`+c.ID+`"`)) {
+				t.Errorf("response leaked card %s from player %s's hand", c.ID, snapshot.Name)
+			}
+		}
+	}
+}
+
 func TestCreateGameHandler(t *testing.T) {
 	s, rr := setupTestServer()
 
@@ -68,13 +134,13 @@ func TestCreateGameHandler(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
 	}
 
-	var resp game.Game
+	var resp game.SpectatorView
 	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("could not parse response JSON: %v", err)
 	}
 
-	if resp.ID == "" {
-		t.Errorf("expected a game_id in response, but it was empty")
+	if resp.GameID == "" {
+		t.Errorf("expected a gameID in response, but it was empty")
 	}
 
 	if len(s.games) != 1 {
@@ -82,6 +148,20 @@ func TestCreateGameHandler(t *testing.T) {
 	}
 }
 
+func TestCreateGameHandler_FailedCreateLeavesNoGame(t *testing.T) {
+	s, rr := setupTestServer()
+	body, _ := json.Marshal(CreateGameRequest{PlayerName: strings.Repeat("x", game.MaxPlayerNameLength+1)})
+	req, _ := http.NewRequest("POST", "/games", bytes.NewReader(body))
+	s.router.ServeHTTP(rr, req)
+
+	if e := decodeError(t, rr, http.StatusBadRequest); e.Code != "invalid_name" {
+		t.Errorf("expected invalid_name, but got %q", e.Code)
+	}
+	if len(s.games) != 0 || len(s.joinCodes) != 0 {
+		t.Errorf("expected no game or join code to be left behind, but there are %d games and %d codes", len(s.games), len(s.joinCodes))
+	}
+}
+
 func TestJoinGameHandler(t *testing.T) {
 	s, rr := setupTestServer()
 
@@ -107,17 +187,23 @@ func TestJoinGameHandler(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	var resp game.Player
+	var resp JoinGameResponse
 	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("could not parse response JSON: %v", err)
 	}
 
-	if resp.ID == "" {
-		t.Errorf("expected a player_id in response, but it was empty")
+	if resp.PlayerID == "" {
+		t.Errorf("expected a playerID in response, but it was empty")
 	}
 
-	if resp.Name != "Test Player" {
-		t.Errorf("expected player name to be 'Test Player', but got '%s'", resp.Name)
+	if resp.PlayerToken == "" {
+		t.Errorf("expected a playerToken in response, but it was empty")
+	} else if id, ok := g.PlayerForToken(resp.PlayerToken); !ok || id != resp.PlayerID {
+		t.Errorf("expected the token to identify player %s, but got %q", resp.PlayerID, id)
+	}
+
+	if resp.PlayerName != "Test Player" {
+		t.Errorf("expected player name to be 'Test Player', but got '%s'", resp.PlayerName)
 	}
 
 	if len(g.Players) != 1 {
@@ -130,9 +216,27 @@ func TestStartGameHandler(t *testing.T) {
 
 	// First, create a game and add players
 	g := game.NewGame()
-	g.AddPlayer("Player 1")
-	g.AddPlayer("Player 2")
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
 	s.games[g.ID] = g
+	token := issueToken(t, g, p1.ID)
+
+	// Only the host may start, and only once everyone else is ready.
+	start := func(token string) int {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/start", g.ID), nil)
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, authorize(req, token))
+		return rr.Code
+	}
+	if code := start(issueToken(t, g, p2.ID)); code != http.StatusForbidden {
+		t.Errorf("expected status %d when a guest starts, got %d", http.StatusForbidden, code)
+	}
+	if code := start(token); code != http.StatusBadRequest {
+		t.Errorf("expected status %d before everyone is ready, got %d", http.StatusBadRequest, code)
+	}
+	if err := g.SetReady(p2.ID, true); err != nil {
+		t.Fatalf("failed to ready player 2: %v", err)
+	}
 
 	// Prepare the start game request
 	url := fmt.Sprintf("/games/%s/start", g.ID)
@@ -141,14 +245,21 @@ func TestStartGameHandler(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// Starting requires a player token.
+	unauthorized := httptest.NewRecorder()
+	s.router.ServeHTTP(unauthorized, req)
+	if unauthorized.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d without a token, got %d", http.StatusUnauthorized, unauthorized.Code)
+	}
+
 	handler := http.Handler(s.router)
-	handler.ServeHTTP(rr, req)
+	handler.ServeHTTP(rr, authorize(req, token))
 
 	if status := rr.Code; status != http.StatusOK {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	var resp game.Game
+	var resp game.PlayerView
 	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("could not parse response JSON: %v", err)
 	}
@@ -184,7 +295,6 @@ func TestPlayCardHandler(t *testing.T) {
 
 	// Prepare the play card request for the opening round
 	playReq := PlayCardRequest{
-		PlayerID: p1.ID,
 		CardID:   secretCard.ID,
 		Location: "face_down_1",
 	}
@@ -197,7 +307,7 @@ func TestPlayCardHandler(t *testing.T) {
 	}
 
 	handler := http.Handler(s.router)
-	handler.ServeHTTP(rr, req)
+	handler.ServeHTTP(rr, authorize(req, issueToken(t, g, p1.ID)))
 
 	if status := rr.Code; status != http.StatusOK {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
@@ -205,6 +315,8 @@ func TestPlayCardHandler(t *testing.T) {
 		t.Logf("Response body: %s", rr.Body.String())
 	}
 
+	assertNoHandLeaked(t, rr.Body.Bytes(), g, p1.ID)
+
 	// Verify the card was moved from hand to placemat
 	updatedPlayer, ok := g.Players[p1.ID]
 	if !ok {
@@ -240,21 +352,14 @@ func TestPassHandler(t *testing.T) {
 	}
 
 	// It's P1's turn. Let's have them pass.
-	passReq := struct {
-		PlayerID string `json:"playerID"`
-	}{
-		PlayerID: p1.ID,
-	}
-	body, _ := json.Marshal(passReq)
-
 	url := fmt.Sprintf("/games/%s/pass", g.ID)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	req, err := http.NewRequest("POST", url, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	handler := http.Handler(s.router)
-	handler.ServeHTTP(rr, req)
+	handler.ServeHTTP(rr, authorize(req, issueToken(t, g, p1.ID)))
 
 	if status := rr.Code; status != http.StatusOK {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
@@ -267,6 +372,35 @@ func TestPassHandler(t *testing.T) {
 	}
 }
 
+func TestForfeitHandler(t *testing.T) {
+	s, rr := setupTestServer()
+
+	// Setup game with two players and start it
+	g := game.NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	s.games[g.ID] = g
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("failed to start game: %v", err)
+	}
+
+	// Player 2 walks away while it is Player 1's turn.
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/forfeit", g.ID), nil)
+	s.router.ServeHTTP(rr, authorize(req, issueToken(t, g, p2.ID)))
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", status, http.StatusOK, rr.Body.String())
+	}
+
+	var resp game.PlayerView
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("could not parse response JSON: %v", err)
+	}
+	if resp.State != game.StateGameOver || resp.Winner == nil || *resp.Winner != p1.Name {
+		t.Errorf("expected %s to win by forfeit, but got state '%s'", p1.Name, resp.State)
+	}
+}
+
 func TestAttackHandler(t *testing.T) {
 	s, rr := setupTestServer()
 
@@ -278,6 +412,7 @@ func TestAttackHandler(t *testing.T) {
 	if err := g.StartGame(); err != nil {
 		t.Fatalf("failed to start game: %v", err)
 	}
+	playOpeningSecrets(t, g)
 
 	// Manually set up the attacker's placemat for a valid attack
 	// In a real game, these would be played on previous turns.
@@ -289,8 +424,7 @@ func TestAttackHandler(t *testing.T) {
 
 	// Prepare the attack request
 	attackReq := AttackRequest{
-		AttackerID: p1.ID,
-		TargetID:   p2.ID,
+		TargetID: p2.ID,
 	}
 	body, _ := json.Marshal(attackReq)
 
@@ -301,13 +435,15 @@ func TestAttackHandler(t *testing.T) {
 	}
 
 	handler := http.Handler(s.router)
-	handler.ServeHTTP(rr, req)
+	handler.ServeHTTP(rr, authorize(req, issueToken(t, g, p1.ID)))
 
 	if status := rr.Code; status != http.StatusOK {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 		t.Logf("Response body: %s", rr.Body.String())
 	}
 
+	assertNoHandLeaked(t, rr.Body.Bytes(), g, p1.ID)
+
 	// Verify the target's population was reduced
 	updatedTarget, ok := g.Players[p2.ID]
 	if !ok {
@@ -355,13 +491,17 @@ func TestJoinGameHandler_Errors(t *testing.T) {
 		g := createGame(t, s)
 
 		// Add two players
-		joinGame(s, g.ID, "Player 1")
-		joinGame(s, g.ID, "Player 2")
+		var joined, guest JoinGameResponse
+		json.NewDecoder(joinGame(s, g.ID, "Player 1").Body).Decode(&joined)
+		json.NewDecoder(joinGame(s, g.ID, "Player 2").Body).Decode(&guest)
+		if err := g.SetReady(guest.PlayerID, true); err != nil {
+			t.Fatalf("failed to ready player 2: %v", err)
+		}
 
 		// Start the game
 		startReq, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/start", g.ID), nil)
 		startRR := httptest.NewRecorder()
-		s.router.ServeHTTP(startRR, startReq)
+		s.router.ServeHTTP(startRR, authorize(startReq, joined.PlayerToken))
 		if startRR.Code != http.StatusOK {
 			t.Fatalf("Failed to start game, status: %d", startRR.Code)
 		}
@@ -386,35 +526,114 @@ func TestGetGameHandler(t *testing.T) {
 		t.Fatalf("failed to start game: %v", err)
 	}
 
-	t.Run("full game view", func(t *testing.T) {
+	t.Run("spectator view", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		url := fmt.Sprintf("/games/%s", g.ID)
 		req, _ := http.NewRequest("GET", url, nil)
 
 		handler := http.Handler(s.router)
-		handler.ServeHTTP(rr, req)
+		handler.ServeHTTP(rr, authorize(req, watcherToken(t, g)))
 
 		if status := rr.Code; status != http.StatusOK {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 		}
 
+		var view game.SpectatorView
+		if err := json.Unmarshal(rr.Body.Bytes(), &view); err != nil {
+			t.Fatalf("could not parse response JSON: %v", err)
+		}
+
+		if len(view.Players) != 2 {
+			t.Fatalf("expected 2 players in the spectator view, but got %d", len(view.Players))
+		}
+		for _, p := range view.Players {
+			if p.HandSize == 0 {
+				t.Errorf("expected hand size to be visible, but it was 0 for player %s", p.Name)
+			}
+		}
+		assertNoHandLeaked(t, rr.Body.Bytes(), g, "")
+	})
+
+	t.Run("viewing requires a token", func(t *testing.T) {
+		for _, token := range []string{"", "not-a-token"} {
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/games/%s", g.ID), nil)
+			if token != "" {
+				authorize(req, token)
+			}
+			rr := httptest.NewRecorder()
+			s.router.ServeHTTP(rr, req)
+			if body := decodeError(t, rr, http.StatusUnauthorized); body.Code != CodeUnauthorized {
+				t.Errorf("token %q: expected code %s, but got %s", token, CodeUnauthorized, body.Code)
+			}
+		}
+	})
+
+	t.Run("full game view requires the admin token", func(t *testing.T) {
+		s.SetAdminToken("secret")
+		defer s.SetAdminToken("")
+
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/admin/games/%s", g.ID), nil)
+		s.router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d without the admin token, got %d", http.StatusUnauthorized, rr.Code)
+		}
+
+		rr = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", fmt.Sprintf("/admin/games/%s", g.ID), nil)
+		req.Header.Set("Authorization", "secret")
+		s.router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d for a token without the Bearer scheme, got %d", http.StatusUnauthorized, rr.Code)
+		}
+
+		rr = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", fmt.Sprintf("/admin/games/%s", g.ID), nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		s.router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d with the admin token, got %d", http.StatusOK, rr.Code)
+		}
+
 		var respGame game.Game
 		if err := json.Unmarshal(rr.Body.Bytes(), &respGame); err != nil {
 			t.Fatalf("could not parse response JSON: %v", err)
 		}
-
 		if len(respGame.Players[p2.ID].Hand) == 0 {
 			t.Errorf("expected to see other player's hand in full view, but it was empty")
 		}
 	})
 
+	t.Run("player ID without a token is rejected", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		url := fmt.Sprintf("/games/%s?playerID=%s", g.ID, p1.ID)
+		req, _ := http.NewRequest("GET", url, nil)
+		s.router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+		assertNoHandLeaked(t, rr.Body.Bytes(), g, "")
+	})
+
+	t.Run("another player's token is rejected", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		url := fmt.Sprintf("/games/%s?playerID=%s", g.ID, p1.ID)
+		req, _ := http.NewRequest("GET", url, nil)
+		s.router.ServeHTTP(rr, authorize(req, issueToken(t, g, p2.ID)))
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+
 	t.Run("player-specific view", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		url := fmt.Sprintf("/games/%s?playerID=%s", g.ID, p1.ID)
 		req, _ := http.NewRequest("GET", url, nil)
 
 		handler := http.Handler(s.router)
-		handler.ServeHTTP(rr, req)
+		handler.ServeHTTP(rr, authorize(req, issueToken(t, g, p1.ID)))
 
 		if status := rr.Code; status != http.StatusOK {
 			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
@@ -437,3 +656,276 @@ func TestGetGameHandler(t *testing.T) {
 		}
 	})
 }
+
+// TestConcurrentClients exercises every endpoint from several goroutines at
+// once. Run it with `go test -race` to check the HTTP layer for data races.
+func TestConcurrentClients(t *testing.T) {
+	s, _ := setupTestServer()
+	ts := httptest.NewServer(s.router)
+	defer ts.Close()
+
+	g := createGame(t, s)
+	post := func(path, token string, body interface{}) *http.Response {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", ts.URL+path, bytes.NewBuffer(data))
+		res, err := http.DefaultClient.Do(authorize(req, token))
+		if err != nil {
+			t.Errorf("POST %s failed: %v", path, err)
+			return nil
+		}
+		return res
+	}
+	get := func(path, token string) {
+		req, _ := http.NewRequest("GET", ts.URL+path, nil)
+		res, err := http.DefaultClient.Do(authorize(req, token))
+		if err != nil {
+			t.Errorf("GET %s failed: %v", path, err)
+			return
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+
+	// Join concurrently with readers polling the game.
+	var wg sync.WaitGroup
+	joined := make(chan JoinGameResponse, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			res := post(fmt.Sprintf("/games/%s/join", g.ID), "", JoinGameRequest{PlayerName: fmt.Sprintf("Player %d", i+1)})
+			if res == nil {
+				return
+			}
+			defer res.Body.Close()
+			var resp JoinGameResponse
+			json.NewDecoder(res.Body).Decode(&resp)
+			joined <- resp
+		}(i)
+		go func() {
+			defer wg.Done()
+			get(fmt.Sprintf("/games/%s", g.ID), "")
+		}()
+	}
+	wg.Wait()
+	close(joined)
+
+	tokens := map[string]string{}
+	for resp := range joined {
+		tokens[resp.PlayerID] = resp.PlayerToken
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("expected 2 players to join, but got %d", len(tokens))
+	}
+
+	// Everyone but the host readies up, then the host starts the game.
+	var hostToken string
+	for id, token := range tokens {
+		if g.IsHost(id) {
+			hostToken = token
+			continue
+		}
+		if res := post(fmt.Sprintf("/games/%s/ready", g.ID), token, nil); res != nil {
+			res.Body.Close()
+		}
+	}
+	if res := post(fmt.Sprintf("/games/%s/start", g.ID), hostToken, nil); res != nil {
+		res.Body.Close()
+	}
+
+	// Every player plays their secret card and passes while everyone polls.
+	for id, token := range tokens {
+		snapshot, _ := g.PlayerSnapshot(id)
+		for _, c := range snapshot.Hand {
+			if c.Type != game.TypeSecret {
+				continue
+			}
+			wg.Add(4)
+			go func(token, cardID string) {
+				defer wg.Done()
+				if res := post(fmt.Sprintf("/games/%s/play", g.ID), token, PlayCardRequest{CardID: cardID, Location: "face_down_1"}); res != nil {
+					res.Body.Close()
+				}
+			}(token, c.ID)
+			go func(token string) {
+				defer wg.Done()
+				if res := post(fmt.Sprintf("/games/%s/pass", g.ID), token, nil); res != nil {
+					res.Body.Close()
+				}
+			}(token)
+			go func(token string) {
+				defer wg.Done()
+				get(fmt.Sprintf("/games/%s", g.ID), token)
+			}(token)
+			go func() {
+				defer wg.Done()
+				get(fmt.Sprintf("/games/%s", g.ID), "")
+			}()
+		}
+	}
+	wg.Wait()
+
+	if state := g.CurrentState(); state != game.StateInProgress {
+		t.Errorf("expected game state to be '%s', but got '%s'", game.StateInProgress, state)
+	}
+}
+
+func TestActionsUseTokenIdentity(t *testing.T) {
+	s, rr := setupTestServer()
+
+	g := game.NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	s.games[g.ID] = g
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("failed to start game: %v", err)
+	}
+	playOpeningSecrets(t, g)
+
+	// Player 2 knows Player 1's ID and tries to pass on their behalf.
+	body, _ := json.Marshal(map[string]string{"playerID": p1.ID})
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/pass", g.ID), bytes.NewBuffer(body))
+	s.router.ServeHTTP(rr, authorize(req, issueToken(t, g, p2.ID)))
+
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected status %d when passing out of turn, got %d", http.StatusConflict, rr.Code)
+	}
+	if g.CurrentPlayerIndex != 0 {
+		t.Errorf("expected it to still be player 1's turn, but the index is %d", g.CurrentPlayerIndex)
+	}
+
+	// A token from another game is not accepted.
+	other := game.NewGame()
+	p3, _ := other.AddPlayer("Player 3")
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/games/%s/pass", g.ID), nil)
+	s.router.ServeHTTP(rr, authorize(req, issueToken(t, other, p3.ID)))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for a token from another game, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
+
+func TestSpectateHandler(t *testing.T) {
+	s, rr := setupTestServer()
+
+	g := game.NewGame()
+	g.AddPlayer("Player 1")
+	g.AddPlayer("Player 2")
+	s.games[g.ID] = g
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("failed to start game: %v", err)
+	}
+
+	body, _ := json.Marshal(SpectateRequest{Name: "Watcher"})
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/spectate", g.ID), bytes.NewBuffer(body))
+	s.router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var resp SpectateResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("could not parse response JSON: %v", err)
+	}
+	if resp.SpectatorToken == "" {
+		t.Fatal("expected a spectatorToken in response, but it was empty")
+	}
+	assertNoHandLeaked(t, rr.Body.Bytes(), g, "")
+
+	t.Run("spectator token shows the public view", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/games/%s", g.ID), nil)
+		s.router.ServeHTTP(rr, authorize(req, resp.SpectatorToken))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var view game.SpectatorView
+		if err := json.Unmarshal(rr.Body.Bytes(), &view); err != nil {
+			t.Fatalf("could not parse response JSON: %v", err)
+		}
+		// Only open streams and sockets count as watching.
+		if view.Spectators != 0 {
+			t.Errorf("expected no spectators, but got %d", view.Spectators)
+		}
+		assertNoHandLeaked(t, rr.Body.Bytes(), g, "")
+	})
+
+	t.Run("spectator token cannot act", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/pass", g.ID), nil)
+		s.router.ServeHTTP(rr, authorize(req, resp.SpectatorToken))
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+		}
+	})
+}
+
+func TestTickGames(t *testing.T) {
+	s, _ := setupTestServer()
+	g := createGameWith(t, s, CreateGameRequest{Options: game.GameOptions{TurnTimeoutSeconds: 30}})
+	p1, _ := g.AddPlayer("Player 1")
+	g.AddPlayer("Player 2")
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("failed to start game: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/games/%s", g.ID), nil)
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, authorize(req, issueToken(t, g, p1.ID)))
+	var view game.PlayerView
+	json.Unmarshal(rr.Body.Bytes(), &view)
+	if view.TurnDeadline == nil {
+		t.Fatal("expected the player view to carry a turn deadline")
+	}
+
+	s.tickGames(view.TurnDeadline.Add(-time.Second))
+	if g.CurrentState() != game.StateOpeningRound {
+		t.Fatalf("expected nothing to happen before the deadline, but the game is in state '%s'", g.CurrentState())
+	}
+	s.tickGames(view.TurnDeadline.Add(time.Second))
+	if g.CurrentState() != game.StateInProgress {
+		t.Errorf("expected the scheduler to finish the opening round, but the game is in state '%s'", g.CurrentState())
+	}
+}
+
+func TestTurnHandler(t *testing.T) {
+	s := NewServer()
+	g, p1, p2, token1, _ := startedGame(t, s)
+
+	turn := func(req TurnRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		r, _ := http.NewRequest("POST", fmt.Sprintf("/games/%s/turn", g.ID), bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, authorize(r, token1))
+		return rr
+	}
+
+	snapshot, _ := g.PlayerSnapshot(p1.ID)
+	card := snapshot.Hand[0]
+	before := g.CurrentVersion()
+
+	// A turn whose attack cannot be made is rejected as a whole.
+	rr := turn(TurnRequest{Plays: []PlayCardRequest{{CardID: card.ID, Location: "face_down_2"}}, TargetID: p1.ID})
+	body := decodeError(t, rr, http.StatusBadRequest)
+	if body.Code != "invalid_target" || body.Details["step"] != "1" {
+		t.Errorf("expected invalid_target at step 1, but got %+v", body)
+	}
+	if after, _ := g.PlayerSnapshot(p1.ID); len(after.Hand) != len(snapshot.Hand) || g.CurrentVersion() != before {
+		t.Fatal("expected the rejected turn to leave the game unchanged")
+	}
+
+	rr = turn(TurnRequest{Plays: []PlayCardRequest{{CardID: card.ID, Location: "face_down_2"}}})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var view game.PlayerView
+	json.Unmarshal(rr.Body.Bytes(), &view)
+	if view.Version != before+1 || view.CurrentTurnPlayerId != p2.ID {
+		t.Errorf("expected one version for the turn and player 2 to be up, but got version %d and player %s", view.Version, view.CurrentTurnPlayerId)
+	}
+	if len(view.PlayerHand) != len(snapshot.Hand)-1 {
+		t.Errorf("expected the card to be played, but the hand holds %d", len(view.PlayerHand))
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"nuclear-war-game-server/game"
+)
+
+// shutdownMessage is what clients on a stream or socket are told when the
+// server goes down.
+const shutdownMessage = "the server is restarting; reconnect in a moment to carry on"
+
+// ShutdownNotice is the last message a stream or socket gets before the
+// server closes it for a restart. Games are kept, so clients should
+// reconnect and resume from the last event they saw.
+type ShutdownNotice struct {
+	Message string `json:"message"`
+}
+
+// beginShutdown stops the server from taking new games, and tells every
+// stream, socket and long poll to wrap up. It is safe to call more than once.
+func (s *Server) beginShutdown() {
+	s.stopOnce.Do(func() {
+		s.draining.Store(true)
+		close(s.stopping)
+	})
+}
+
+// untilShutdown returns a copy of ctx that is also cancelled when the server
+// begins to shut down.
+func (s *Server) untilShutdown(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-s.stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// rejectWhileDraining writes an error and returns true if the server is
+// shutting down and should not start anything new.
+func (s *Server) rejectWhileDraining(w http.ResponseWriter) bool {
+	if !s.draining.Load() {
+		return false
+	}
+	w.Header().Set("Retry-After", "5")
+	writeError(w, http.StatusServiceUnavailable, CodeShuttingDown, "the server is shutting down and is not taking new games")
+	return true
+}
+
+// waitForSockets waits for the open WebSockets to close, or for ctx to end.
+// The HTTP server does not track them once they are upgraded.
+func (s *Server) waitForSockets(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.sockets.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for sockets to close: %w", ctx.Err())
+	}
+}
+
+// SaveGames writes every game to the store. A game that cannot be saved does
+// not stop the others from being saved; every failure is reported.
+func (s *Server) SaveGames() error {
+	s.mu.Lock()
+	games := make([]*game.Game, 0, len(s.games))
+	for _, g := range s.games {
+		games = append(games, g)
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, g := range games {
+		data, err := g.MarshalState()
+		if err == nil {
+			err = s.store.Save(g.ID, data)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("saving game %s: %w", g.ID, err))
+		}
+	}
+	slog.Info("saved games to the store", "saved", len(games)-len(errs), "games", len(games))
+	return errors.Join(errs...)
+}
+
+// RestoreGames loads every game in the store, and registers the join codes
+// of those still open. A game that cannot be loaded is skipped and reported,
+// and left in the store so it can be looked into. It returns the number of
+// games restored.
+func (s *Server) RestoreGames() (int, error) {
+	ids, err := s.store.List()
+	if err != nil {
+		return 0, fmt.Errorf("listing saved games: %w", err)
+	}
+
+	var errs []error
+	restored := 0
+	for _, id := range ids {
+		data, err := s.store.Load(id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("loading game %s: %w", id, err))
+			continue
+		}
+		g, err := game.RestoreGame(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("restoring game %s: %w", id, err))
+			continue
+		}
+
+		g.SetObserver(s.metrics)
+		s.mu.Lock()
+		s.games[g.ID] = g
+		if g.JoinCode != "" {
+			s.joinCodes[g.JoinCode] = g.ID
+		}
+		s.mu.Unlock()
+		restored++
+	}
+	if restored > 0 {
+		slog.Info("restored games from the store", "games", restored)
+	}
+	return restored, errors.Join(errs...)
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nuclear-war-game-server/game"
+	"nuclear-war-game-server/store"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestShutdown_NotifiesClients(t *testing.T) {
+	s := NewServer()
+	g, p1, p2, token1, token2 := startedGame(t, s)
+	ts := httptest.NewServer(s.router)
+	defer ts.Close()
+
+	conn := dialGame(t, ts, g, token1)
+	readUntil(t, conn, g, p1.ID, "view")
+	next := openStream(t, ts, g, p2.ID, token2, "")
+	next()
+
+	poll := make(chan time.Duration, 1)
+	go func() {
+		start := time.Now()
+		req, _ := http.NewRequest("GET", ts.URL+fmt.Sprintf("/games/%s?waitForVersion=%d&timeout=60", g.ID, g.CurrentVersion()), nil)
+		if res, err := http.DefaultClient.Do(authorize(req, token1)); err == nil {
+			res.Body.Close()
+		}
+		poll <- time.Since(start)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	s.beginShutdown()
+
+	t.Run("sockets are told and closed", func(t *testing.T) {
+		msg := readUntil(t, conn, g, p1.ID, "shutdown")
+		if msg.Code != CodeShuttingDown {
+			t.Errorf("expected code %s, but got %q", CodeShuttingDown, msg.Code)
+		}
+		_, _, err := conn.ReadMessage()
+		if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+			t.Errorf("expected a going-away close, but got %v", err)
+		}
+	})
+
+	t.Run("streams are told", func(t *testing.T) {
+		for {
+			ev := next()
+			if ev.event == "shutdown" {
+				break
+			}
+		}
+	})
+
+	t.Run("long polls are answered", func(t *testing.T) {
+		select {
+		case took := <-poll:
+			if took > 10*time.Second {
+				t.Errorf("expected the long poll to be answered at shutdown, but it took %s", took)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("expected the long poll to be answered at shutdown")
+		}
+	})
+
+	t.Run("new games are refused", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/games", nil)
+		rr := httptest.NewRecorder()
+		s.router.ServeHTTP(rr, req)
+		if body := decodeError(t, rr, http.StatusServiceUnavailable); body.Code != CodeShuttingDown {
+			t.Errorf("expected code %s, but got %s", CodeShuttingDown, body.Code)
+		}
+		if rr.Header().Get("Retry-After") == "" {
+			t.Error("expected a Retry-After header")
+		}
+	})
+}
+
+func TestSaveAndRestoreGames(t *testing.T) {
+	st := store.NewMemory()
+	s := NewServer()
+	s.SetStore(st)
+	started, p1, _, token1, _ := startedGame(t, s)
+	open := createGameWith(t, s, CreateGameRequest{Name: "Lobby"})
+	code := open.Summary().JoinCode
+
+	if err := s.SaveGames(); err != nil {
+		t.Fatalf("SaveGames failed: %v", err)
+	}
+
+	restarted := NewServer()
+	restarted.SetStore(st)
+	n, err := restarted.RestoreGames()
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 games to be restored, but got %d and %v", n, err)
+	}
+
+	t.Run("players keep their seats", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/games/"+started.ID, nil)
+		rr := httptest.NewRecorder()
+		restarted.router.ServeHTTP(rr, authorize(req, token1))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, but got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var view game.PlayerView
+		json.Unmarshal(rr.Body.Bytes(), &view)
+		if view.PlayerID != p1.ID || view.Version != started.CurrentVersion() {
+			t.Errorf("expected player 1's view at version %d, but got %+v", started.CurrentVersion(), view)
+		}
+	})
+
+	t.Run("join codes still work", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/join/"+code, nil)
+		rr := httptest.NewRecorder()
+		restarted.router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected the join code to resolve, but got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("unreadable games are reported and skipped", func(t *testing.T) {
+		st.Save("broken", []byte("{"))
+		again := NewServer()
+		again.SetStore(st)
+		n, err := again.RestoreGames()
+		if err == nil || n != 2 {
+			t.Errorf("expected 2 games and an error, but got %d and %v", n, err)
+		}
+	})
+}
+
+func TestServe_SavesGamesOnShutdown(t *testing.T) {
+	st := store.NewMemory()
+	s := NewServer()
+	s.SetStore(st)
+	socket := filepath.Join(t.TempDir(), "server.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(ctx, ListenConfig{Socket: socket, ShutdownTimeout: 5 * time.Second})
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	var res *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if res, err = client.Post("http://server/v1/games", "application/json", nil); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("could not create a game: %v", err)
+	}
+	var view game.SpectatorView
+	json.NewDecoder(res.Body).Decode(&view)
+	res.Body.Close()
+
+	cancel()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("expected a clean shutdown, but got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Serve did not return after shutdown")
+	}
+	if _, err := st.Load(view.GameID); err != nil {
+		t.Errorf("expected the game to be saved on shutdown, but got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"nuclear-war-game-server/game"
+)
+
+// streamKeepAlive is how often an idle event stream sends a comment, so that
+// proxies do not close it.
+const streamKeepAlive = 15 * time.Second
+
+// streamHandler serves the game as a Server-Sent Events stream. Each game
+// event is sent with its sequence number as the event ID, followed by a
+// "view" event carrying the caller's fresh view. A reconnecting client resumes
+// after the Last-Event-ID header, or the lastEventId query parameter.
+// EventSource cannot set headers, so the token may also be sent as the
+// "token" query parameter.
+func (s *Server) streamHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+
+	token := bearerToken(r)
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	playerID, ok := authenticateToken(r, g, token)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, "a valid player or spectator token is required")
+		return
+	}
+
+	lastSeq := g.LastSeq()
+	resume := r.Header.Get("Last-Event-ID")
+	if resume == "" {
+		resume = r.URL.Query().Get("lastEventId")
+	}
+	if resume != "" {
+		if lastSeq, err = strconv.ParseUint(resume, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, "invalid Last-Event-ID")
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "streaming is not supported")
+		return
+	}
+
+	changes, unsubscribe := g.Subscribe()
+	defer unsubscribe()
+	if playerID == "" {
+		defer g.Watch(token)()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	rc := holdOpen(w)
+	stream := &eventStream{feed: feed{game: g, playerID: playerID, lastSeq: lastSeq}, w: w, flusher: flusher, marshal: marshalFor(r),
+		extend: func() { s.extendWrite(rc, 0) }}
+	if err := stream.push(stream); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	refresh, stopRefresh := stream.refresher()
+	defer stopRefresh()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.stopping:
+			stream.send("shutdown", "", ShutdownNotice{Message: shutdownMessage})
+			return
+		case <-changes:
+			err = stream.push(stream)
+		case <-refresh:
+			err = stream.refresh(stream)
+		case <-keepAlive.C:
+			stream.extend()
+			if _, err = fmt.Fprint(w, ": keep-alive\n\n"); err == nil {
+				flusher.Flush()
+				stream.seen()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// eventStream is a single client's Server-Sent Events stream of a game.
+type eventStream struct {
+	feed
+	w       http.ResponseWriter
+	flusher http.Flusher
+	marshal func(interface{}) ([]byte, error)
+	// extend gives the stream time to write past the server's write timeout.
+	extend func()
+}
+
+// sendResync implements feedWriter. The notice has no ID, so it does not
+// move the client's Last-Event-ID.
+func (s *eventStream) sendResync(notice *ResyncNotice) error {
+	return s.send("resync", "", notice)
+}
+
+// sendEvent implements feedWriter. Each event's ID is its sequence number.
+func (s *eventStream) sendEvent(ev game.Event) error {
+	return s.send(string(ev.Type), strconv.FormatUint(ev.Seq, 10), ev)
+}
+
+// sendView implements feedWriter.
+func (s *eventStream) sendView(view interface{}) error {
+	return s.send("view", "", view)
+}
+
+// sendUnseated implements feedWriter.
+func (s *eventStream) sendUnseated() error {
+	return s.send("error", "", map[string]string{"error": "you are no longer seated in this game"})
+}
+
+// send writes one event to the stream. Events without an ID do not move the
+// client's Last-Event-ID.
+func (s *eventStream) send(event, id string, v interface{}) error {
+	data, err := s.marshal(v)
+	if err != nil {
+		return err
+	}
+	s.extend()
+	if id != "" {
+		if _, err := fmt.Fprintf(s.w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	s.seen()
+	return nil
+}
+
+// seen marks the streaming player as still connected. An open stream counts
+// as a connected player, even one who only watches between their turns.
+func (s *eventStream) seen() {
+	if s.playerID != "" {
+		s.game.MarkSeen(s.playerID, time.Now())
+	}
+}
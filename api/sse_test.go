@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"nuclear-war-game-server/game"
+)
+
+// sseEvent is one event read from a Server-Sent Events stream.
+type sseEvent struct {
+	id, event, data string
+}
+
+// openStream opens the game's event stream as viewerID and returns a function
+// that reads the next event from it, checking each for leaked cards.
+func openStream(t *testing.T, ts *httptest.Server, g *game.Game, viewerID, token, lastEventID string) func() sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+fmt.Sprintf("/games/%s/events", g.ID), nil)
+	if token != "" {
+		authorize(req, token)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("could not open stream: %v", err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected an event stream, but got %q", ct)
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	return func() sseEvent {
+		t.Helper()
+		var ev sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if ev.event != "" {
+					assertNoHandLeaked(t, []byte(ev.data), g, viewerID)
+					return ev
+				}
+			case strings.HasPrefix(line, "id: "):
+				ev.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				ev.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+		t.Fatalf("stream ended: %v", scanner.Err())
+		return ev
+	}
+}
+
+func TestStreamHandler(t *testing.T) {
+	s, _ := setupTestServer()
+	ts := httptest.NewServer(s.router)
+	defer ts.Close()
+
+	g := createGame(t, s)
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("failed to start game: %v", err)
+	}
+
+	t.Run("pushes events and the spectator view", func(t *testing.T) {
+		next := openStream(t, ts, g, "", watcherToken(t, g), "")
+		if ev := next(); ev.event != "view" {
+			t.Fatalf("expected the stream to start with a view, but got %q", ev.event)
+		}
+		if n := g.NewSpectatorView().Spectators; n != 1 {
+			t.Errorf("expected the open stream to count as a spectator, but got %d", n)
+		}
+
+		for _, c := range g.Players[p1.ID].Hand {
+			if c.Type == game.TypeSecret {
+				g.PlayCard(p1.ID, c.ID, "face_down_1")
+				break
+			}
+		}
+		ev := next()
+		// Events 1 to 3 are the two joins and the start.
+		if ev.event != string(game.EventCardPlayed) || ev.id != "4" {
+			t.Errorf("expected card_played with ID 4, but got %q with ID %q", ev.event, ev.id)
+		}
+		if ev := next(); ev.event != "view" || ev.id != "" {
+			t.Errorf("expected a view without an ID, but got %q with ID %q", ev.event, ev.id)
+		}
+	})
+
+	t.Run("resumes after Last-Event-ID", func(t *testing.T) {
+		next := openStream(t, ts, g, p2.ID, issueToken(t, g, p2.ID), "3")
+		if ev := next(); ev.id != "4" {
+			t.Errorf("expected to resume with event 4, but got %q", ev.id)
+		}
+		if ev := next(); ev.event != "view" || !strings.Contains(ev.data, `"playerID":"`+p2.ID+`"`) {
+			t.Errorf("expected player 2's view, but got %q", ev.data)
+		}
+	})
+
+	t.Run("an open stream keeps the player connected", func(t *testing.T) {
+		next := openStream(t, ts, g, p2.ID, issueToken(t, g, p2.ID), "")
+		next()
+		g.MarkSeen(p1.ID, time.Now().Add(-time.Hour))
+		g.MarkSeen(p2.ID, time.Now().Add(-time.Hour))
+		for _, c := range g.Players[p2.ID].Hand {
+			if c.Type == game.TypeSecret {
+				g.PlayCard(p2.ID, c.ID, "face_down_1")
+				break
+			}
+		}
+		next()
+		next()
+		deadline := time.Now().Add(2 * time.Second)
+		for g.ConnectedPlayers(time.Now()) != 1 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := g.ConnectedPlayers(time.Now()); got != 1 {
+			t.Errorf("expected player 2 to be connected by the stream, but %d players are", got)
+		}
+	})
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", ts.URL+fmt.Sprintf("/games/%s/events?token=bogus", g.ID), nil)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, res.StatusCode)
+		}
+	})
+}
+
+func TestStreamHandler_Resync(t *testing.T) {
+	s := NewServer()
+	ts := httptest.NewServer(s.router)
+	t.Cleanup(ts.Close) // After the streams are closed
+	g, p1, _, token1, _ := startedGame(t, s)
+
+	// Enough passes that the first events are no longer kept.
+	for g.FirstSeq() <= 2 {
+		if err := g.Apply(game.PassTurnAction{PlayerID: g.PlayerOrder[g.CurrentPlayerIndex]}); err != nil {
+			t.Fatalf("pass failed unexpectedly: %v", err)
+		}
+	}
+
+	next := openStream(t, ts, g, p1.ID, token1, "1")
+	ev := next()
+	if ev.event != "resync" || ev.id != "" {
+		t.Fatalf("expected a resync without an ID first, but got %q with ID %q", ev.event, ev.id)
+	}
+	var notice ResyncNotice
+	if err := json.Unmarshal([]byte(ev.data), &notice); err != nil {
+		t.Fatalf("could not parse the notice: %v", err)
+	}
+	if notice.LastEventID != 1 || notice.FirstEventID != g.FirstSeq() {
+		t.Errorf("expected the notice to span 1 to %d, but got %+v", g.FirstSeq(), notice)
+	}
+	if ev := next(); ev.id != strconv.FormatUint(g.FirstSeq(), 10) {
+		t.Errorf("expected the events to resume at %d, but got %q", g.FirstSeq(), ev.id)
+	}
+
+	t.Run("not sent without a gap", func(t *testing.T) {
+		next := openStream(t, ts, g, p1.ID, token1, strconv.FormatUint(g.FirstSeq()-1, 10))
+		if ev := next(); ev.event == "resync" {
+			t.Error("expected no resync when every missed event is kept")
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// The v2 API serves the same handlers as v1, but every JSON field name is
+// camelCase with initialisms written as words: "player_order" becomes
+// "playerOrder" and "gameID" becomes "gameId". Values are left alone, and so
+// are the keys of maps that hold data rather than fields.
+// Request bodies need no translation, since field names are matched without
+// regard to case.
+
+// v2Key is the context key marking a request made to the v2 API.
+type v2Key struct{}
+
+// isV2 reports whether the request was made to the v2 API.
+func isV2(r *http.Request) bool {
+	v, _ := r.Context().Value(v2Key{}).(bool)
+	return v
+}
+
+// fieldName matches JSON object keys that are field names rather than data,
+// such as player IDs keying a map.
+var fieldName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// initialism matches an upper-case "ID" ending a word of a field name.
+var initialism = regexp.MustCompile(`([a-z0-9])ID([A-Z0-9]|$)`)
+
+// v2Name returns the v2 spelling of a v1 field name.
+func v2Name(name string) string {
+	if !fieldName.MatchString(name) {
+		return name
+	}
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return initialism.ReplaceAllString(strings.Join(parts, ""), "${1}Id${2}")
+}
+
+// dataMaps lists the fields whose values are maps keyed by data rather than
+// by field names: error details, the arguments of a legal move, and the
+// players keyed by ID. Their keys are sent as they are, so a v2 error still
+// carries details such as "cardID" next to ones that were camelCase all
+// along, such as "retryAfter"; v2Description tells clients so.
+var dataMaps = map[string]bool{"details": true, "args": true, "players": true}
+
+// renameV2 renames the keys of every object in a decoded JSON value.
+func renameV2(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			name := v2Name(k)
+			if data, ok := child.(map[string]interface{}); ok && dataMaps[name] {
+				out[name] = renameValues(data)
+				continue
+			}
+			out[name] = renameV2(child)
+		}
+		return out
+	case []interface{}:
+		for i, child := range v {
+			v[i] = renameV2(child)
+		}
+	}
+	return v
+}
+
+// renameValues renames the keys inside the values of a data map, keeping
+// the map's own keys.
+func renameValues(data map[string]interface{}) map[string]interface{} {
+	for k, child := range data {
+		data[k] = renameV2(child)
+	}
+	return data
+}
+
+// toV2JSON rewrites an encoded v1 JSON document with v2 field names.
+func toV2JSON(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	out, err := json.Marshal(renameV2(v))
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// marshalFor returns the JSON encoder for the API version of the request,
+// for streams that frame their own messages.
+func marshalFor(r *http.Request) func(interface{}) ([]byte, error) {
+	if !isV2(r) {
+		return json.Marshal
+	}
+	return func(v interface{}) ([]byte, error) {
+		body, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		body, err = toV2JSON(body)
+		return bytes.TrimSuffix(body, []byte("\n")), err
+	}
+}
+
+// v2Schema marks requests as made to the v2 API and rewrites their JSON
+// responses with v2 field names. Other responses, such as event streams and
+// WebSocket upgrades, pass straight through; those handlers encode for v2
+// themselves.
+func v2Schema(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), v2Key{}, true))
+		vw := &v2Writer{ResponseWriter: w}
+		next.ServeHTTP(vw, r)
+		vw.finish()
+	})
+}
+
+// v2Writer buffers a JSON response so it can be rewritten once complete.
+type v2Writer struct {
+	http.ResponseWriter
+	wroteHeader bool
+	status      int
+	buf         *bytes.Buffer // Non-nil while a JSON body is being buffered
+}
+
+func (w *v2Writer) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.status, w.buf = status, new(bytes.Buffer)
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *v2Writer) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buf != nil {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// finish sends a buffered JSON response, rewritten for v2.
+func (w *v2Writer) finish() {
+	if w.buf == nil {
+		return
+	}
+	body := w.buf.Bytes()
+	if len(body) > 0 {
+		if rewritten, err := toV2JSON(body); err == nil {
+			body = rewritten
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
+
+// Flush implements http.Flusher for streams, which are never buffered.
+func (w *v2Writer) Flush() {
+	if w.buf == nil {
+		http.NewResponseController(w.ResponseWriter).Flush()
+	}
+}
+
+// Hijack implements http.Hijacker for WebSocket upgrades.
+func (w *v2Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *v2Writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"nuclear-war-game-server/game"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// socketWriteWait is how long a single write to a socket may take.
+	socketWriteWait = 10 * time.Second
+	// socketPongWait is how long a socket may stay silent before it is closed.
+	socketPongWait = 60 * time.Second
+	// socketPingPeriod is how often the server pings; it must be shorter than
+	// socketPongWait, and than game.DisconnectAfter since each pong marks the
+	// player as still connected.
+	socketPingPeriod = 20 * time.Second
+	// maxSocketMessage is the largest command a client may send.
+	maxSocketMessage = 4096
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// SocketCommand is an action sent by a client over the game's WebSocket.
+// The acting player is the one the socket was opened for.
+type SocketCommand struct {
+	RequestID string            `json:"requestId,omitempty"` // Echoed back in the reply
+	Action    game.ActionType   `json:"action"`
+	CardID    string            `json:"cardID,omitempty"`
+	Location  string            `json:"location,omitempty"`
+	TargetID  string            `json:"targetID,omitempty"` // The player to attack or target with Propaganda
+	Plays     []PlayCardRequest `json:"plays,omitempty"`    // The cards a turn plays
+	PlayerID  string            `json:"playerID,omitempty"` // The player to kick or make host
+	Ready     *bool             `json:"ready,omitempty"`
+	Options   *game.GameOptions `json:"options,omitempty"`
+	// Version, if set, is the version of the view the command was sent
+	// against; the command is rejected if the game has moved on since.
+	Version *uint64 `json:"version,omitempty"`
+}
+
+// SocketMessage is sent by the server over the game's WebSocket.
+type SocketMessage struct {
+	// Type is "view" for the caller's view of the game, "event" for a game
+	// event, "ack" when a command was applied and "error" when it was not.
+	// "shutdown" is sent just before the server closes the socket to restart,
+	// and "resync" when events the client asked for are no longer kept.
+	Type      string        `json:"type"`
+	RequestID string        `json:"requestId,omitempty"`
+	View      interface{}   `json:"view,omitempty"`
+	Event     *game.Event   `json:"event,omitempty"`
+	Resync    *ResyncNotice `json:"resync,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	// Code and Details are those of the matching HTTP error response.
+	Code    string            `json:"code,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// socketError returns the error message for a command the engine rejected.
+func socketError(requestID string, err error) SocketMessage {
+	_, body := gameErrorResponse(err)
+	return SocketMessage{Type: "error", RequestID: requestID, Error: body.Message, Code: body.Code, Details: body.Details}
+}
+
+// action turns the command into the game action playerID is taking.
+func (c SocketCommand) action(playerID string) (game.Action, error) {
+	switch c.Action {
+	case game.ActionStartGame:
+		return game.StartGameAction{PlayerID: playerID}, nil
+	case game.ActionPlayCard:
+		return game.PlayCardAction{PlayerID: playerID, CardID: c.CardID, Location: c.Location, TargetID: c.TargetID}, nil
+	case game.ActionPass:
+		return game.PassTurnAction{PlayerID: playerID}, nil
+	case game.ActionAttack:
+		return game.AttackAction{AttackerID: playerID, TargetID: c.TargetID}, nil
+	case game.ActionTurn:
+		return turnAction(playerID, c.Plays, c.TargetID), nil
+	case game.ActionReady:
+		return game.ReadyAction{PlayerID: playerID, Ready: c.Ready == nil || *c.Ready}, nil
+	case game.ActionKick:
+		return game.KickPlayerAction{PlayerID: playerID, TargetID: c.PlayerID}, nil
+	case game.ActionTransferHost:
+		return game.TransferHostAction{PlayerID: playerID, TargetID: c.PlayerID}, nil
+	case game.ActionUpdateOptions:
+		if c.Options == nil {
+			return nil, fmt.Errorf("%w: options are required", game.ErrInvalidOptions)
+		}
+		return game.UpdateOptionsAction{PlayerID: playerID, Options: *c.Options}, nil
+	case game.ActionLeave:
+		return game.LeaveAction{PlayerID: playerID}, nil
+	case game.ActionForfeit:
+		return game.ForfeitAction{PlayerID: playerID}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", game.ErrUnknownAction, c.Action)
+}
+
+// socketHandler upgrades the request to a WebSocket that pushes the caller's
+// view of the game and the game's events whenever it changes, and accepts
+// commands from seated players.
+// Browsers cannot set headers on a WebSocket, so the token may also be sent
+// as the "token" query parameter. "since" resumes the events after a
+// sequence number.
+func (s *Server) socketHandler(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGameFromRequest(r)
+	if err != nil {
+		writeGameNotFound(w, err)
+		return
+	}
+
+	token := bearerToken(r)
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	playerID, ok := authenticateToken(r, g, token)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, "a valid player or spectator token is required")
+		return
+	}
+
+	lastSeq := g.LastSeq()
+	if v := r.URL.Query().Get("since"); v != "" {
+		if lastSeq, err = strconv.ParseUint(v, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, "invalid since")
+			return
+		}
+	}
+
+	// Counted before the upgrade, while http.Server.Shutdown still waits for
+	// the request, so that waitForSockets cannot miss it.
+	s.sockets.Add(1)
+	defer s.sockets.Done()
+	// Once upgraded, the socket keeps its own read and write deadlines in
+	// place of the server's timeouts.
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written the error response.
+		return
+	}
+	defer conn.Close()
+	if playerID == "" {
+		defer g.Watch(token)()
+	}
+
+	session := &socketSession{feed: feed{game: g, playerID: playerID, lastSeq: lastSeq}, conn: conn, marshal: marshalFor(r), stopping: s.stopping}
+	if l := requestLogFor(r); l != nil {
+		session.requestID = l.id
+	}
+	session.run()
+}
+
+// socketSession is a single client's WebSocket connection to a game.
+// Only run writes to the connection.
+type socketSession struct {
+	feed
+	conn      *websocket.Conn
+	marshal   func(interface{}) ([]byte, error)
+	stopping  <-chan struct{} // Closed when the server shuts down
+	requestID string          // ID of the request that opened the socket
+}
+
+// run pushes updates and replies to the client until the connection closes.
+func (s *socketSession) run() {
+	changes, unsubscribe := s.game.Subscribe()
+	defer unsubscribe()
+
+	replies := make(chan SocketMessage, 16)
+	closed := make(chan struct{})
+	go s.readCommands(replies, closed)
+
+	ping := time.NewTicker(socketPingPeriod)
+	defer ping.Stop()
+
+	refresh, stopRefresh := s.refresher()
+	defer stopRefresh()
+
+	if err := s.push(s); err != nil {
+		return
+	}
+	for {
+		var err error
+		select {
+		case <-closed:
+			return
+		case <-s.stopping:
+			s.shutdown()
+			// Wait for a command being applied, so the game is saved after it.
+			s.conn.Close()
+			<-closed
+			return
+		case msg := <-replies:
+			err = s.write(msg)
+		case <-changes:
+			err = s.push(s)
+		case <-refresh:
+			err = s.refresh(s)
+		case <-ping.C:
+			s.conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			err = s.conn.WriteMessage(websocket.PingMessage, nil)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// sendResync implements feedWriter.
+func (s *socketSession) sendResync(notice *ResyncNotice) error {
+	return s.write(SocketMessage{Type: "resync", Resync: notice})
+}
+
+// sendEvent implements feedWriter.
+func (s *socketSession) sendEvent(ev game.Event) error {
+	return s.write(SocketMessage{Type: "event", Event: &ev})
+}
+
+// sendView implements feedWriter.
+func (s *socketSession) sendView(view interface{}) error {
+	return s.write(SocketMessage{Type: "view", View: view})
+}
+
+// sendUnseated implements feedWriter.
+func (s *socketSession) sendUnseated() error {
+	return s.write(SocketMessage{Type: "error", Error: "you are no longer seated in this game", Code: CodeUnauthorized})
+}
+
+// shutdown tells the client the server is going down, and closes the
+// connection with a going-away close frame.
+func (s *socketSession) shutdown() {
+	if err := s.write(SocketMessage{Type: "shutdown", Error: shutdownMessage, Code: CodeShuttingDown}); err != nil {
+		return
+	}
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server restarting")
+	s.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(socketWriteWait))
+}
+
+// write sends a message to the client.
+func (s *socketSession) write(msg SocketMessage) error {
+	data, err := s.marshal(msg)
+	if err != nil {
+		return err
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+	return s.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// readCommands applies the commands the client sends and queues the replies.
+// It closes closed when the connection is gone.
+func (s *socketSession) readCommands(replies chan<- SocketMessage, closed chan<- struct{}) {
+	defer close(closed)
+	s.conn.SetReadLimit(maxSocketMessage)
+	s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		// An open socket counts as a connected player, even one who only
+		// watches between their turns.
+		if s.playerID != "" {
+			s.game.MarkSeen(s.playerID, time.Now())
+		}
+		return s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	})
+
+	for {
+		var cmd SocketCommand
+		if err := s.conn.ReadJSON(&cmd); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				slog.Warn("socket error", "request_id", s.requestID, "game_id", s.game.ID, "err", err)
+			}
+			return
+		}
+		s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+		start := time.Now()
+		reply := s.apply(cmd)
+		s.logCommand(cmd, reply, time.Since(start))
+		select {
+		case replies <- reply:
+		default:
+			// The client is not reading its replies; drop the connection.
+			return
+		}
+	}
+}
+
+// apply performs a command for the session's player.
+func (s *socketSession) apply(cmd SocketCommand) SocketMessage {
+	if s.playerID == "" {
+		return SocketMessage{Type: "error", RequestID: cmd.RequestID, Error: "spectators cannot take actions", Code: CodeUnauthorized}
+	}
+	s.game.MarkSeen(s.playerID, time.Now())
+	a, err := cmd.action(s.playerID)
+	if err == nil {
+		if cmd.Version != nil {
+			err = s.game.ApplyAtVersion(a, *cmd.Version)
+		} else {
+			err = s.game.Apply(a)
+		}
+	}
+	if err != nil {
+		return socketError(cmd.RequestID, err)
+	}
+	return SocketMessage{Type: "ack", RequestID: cmd.RequestID}
+}
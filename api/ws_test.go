@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nuclear-war-game-server/game"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialGame opens a WebSocket to the game as the holder of token.
+func dialGame(t *testing.T, ts *httptest.Server, g *game.Game, token string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + fmt.Sprintf("/games/%s/ws", g.ID)
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("could not open socket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readUntil reads socket messages until one of the given type arrives, or
+// returns the next message if msgType is empty. Each raw message is checked
+// for leaked cards.
+func readUntil(t *testing.T, conn *websocket.Conn, g *game.Game, viewerID, msgType string) SocketMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("could not read from socket: %v", err)
+		}
+		assertNoHandLeaked(t, data, g, viewerID)
+		var msg SocketMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("could not parse socket message: %v", err)
+		}
+		if msgType == "" || msg.Type == msgType {
+			return msg
+		}
+	}
+}
+
+// readAll reads socket messages until one of each of the given types has
+// arrived, in any order, and returns the first message of each type.
+func readAll(t *testing.T, conn *websocket.Conn, g *game.Game, viewerID string, msgTypes ...string) map[string]SocketMessage {
+	t.Helper()
+	seen := map[string]SocketMessage{}
+	for len(seen) < len(msgTypes) {
+		msg := readUntil(t, conn, g, viewerID, "")
+		for _, msgType := range msgTypes {
+			if _, ok := seen[msgType]; !ok && msg.Type == msgType {
+				seen[msgType] = msg
+			}
+		}
+	}
+	return seen
+}
+
+func TestSocketHandler(t *testing.T) {
+	s, _ := setupTestServer()
+	ts := httptest.NewServer(s.router)
+	defer ts.Close()
+
+	g := createGame(t, s)
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("failed to start game: %v", err)
+	}
+	token := issueToken(t, g, p1.ID)
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		url := "ws" + strings.TrimPrefix(ts.URL, "http") + fmt.Sprintf("/games/%s/ws?token=bogus", g.ID)
+		_, res, err := websocket.DefaultDialer.Dial(url, nil)
+		if err == nil || res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected the socket to be refused with %d", http.StatusUnauthorized)
+		}
+	})
+
+	conn := dialGame(t, ts, g, token)
+	first := readUntil(t, conn, g, p1.ID, "view")
+	view, _ := json.Marshal(first.View)
+	var pv game.PlayerView
+	json.Unmarshal(view, &pv)
+	if pv.PlayerID != p1.ID || len(pv.PlayerHand) == 0 {
+		t.Fatalf("expected the socket to start with player 1's view, but got %s", view)
+	}
+
+	watcher := dialGame(t, ts, g, watcherToken(t, g))
+	readUntil(t, watcher, g, "", "view")
+
+	t.Run("applies commands and pushes the result", func(t *testing.T) {
+		var secret *game.Card
+		for _, c := range pv.PlayerHand {
+			if c.Type == game.TypeSecret {
+				secret = c
+			}
+		}
+		conn.WriteJSON(SocketCommand{RequestID: "1", Action: game.ActionPlayCard, CardID: secret.ID, Location: "face_down_1"})
+
+		msgs := readAll(t, conn, g, p1.ID, "ack", "event", "view")
+		if msgs["ack"].RequestID != "1" {
+			t.Errorf("expected the ack to echo request ID 1, but got '%s'", msgs["ack"].RequestID)
+		}
+		ev := msgs["event"].Event
+		if ev.Type != game.EventCardPlayed {
+			t.Errorf("expected a card_played event, but got %s", ev.Type)
+		}
+		if strings.Contains(ev.Message, secret.Name) {
+			t.Error("expected the event not to name a face-down card")
+		}
+
+		// Spectators are told too, with the spectator redaction.
+		readUntil(t, watcher, g, "", "event")
+		readUntil(t, watcher, g, "", "view")
+	})
+
+	t.Run("reports rejected commands", func(t *testing.T) {
+		conn.WriteJSON(SocketCommand{RequestID: "2", Action: game.ActionAttack, TargetID: p2.ID})
+		msg := readUntil(t, conn, g, p1.ID, "error")
+		if msg.RequestID != "2" || msg.Error == "" {
+			t.Errorf("expected an error for request 2, but got %+v", msg)
+		}
+		if msg.Code != "invalid_phase" {
+			t.Errorf("expected code invalid_phase, but got '%s'", msg.Code)
+		}
+	})
+
+	t.Run("rejects commands sent against a stale version", func(t *testing.T) {
+		stale := pv.Version
+		conn.WriteJSON(SocketCommand{RequestID: "3", Action: game.ActionPass, Version: &stale})
+		msg := readUntil(t, conn, g, p1.ID, "error")
+		if msg.RequestID != "3" || !strings.Contains(msg.Error, game.ErrVersionMismatch.Error()) {
+			t.Errorf("expected a version mismatch for request 3, but got %+v", msg)
+		}
+	})
+
+	t.Run("spectators cannot act", func(t *testing.T) {
+		watcher.WriteJSON(SocketCommand{Action: game.ActionPass})
+		readUntil(t, watcher, g, "", "error")
+	})
+
+	t.Run("pongs keep the player connected", func(t *testing.T) {
+		g.MarkSeen(p1.ID, time.Now().Add(-time.Hour))
+		g.MarkSeen(p2.ID, time.Now().Add(-time.Hour))
+		if err := conn.WriteControl(websocket.PongMessage, nil, time.Now().Add(time.Second)); err != nil {
+			t.Fatalf("could not send a pong: %v", err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for g.ConnectedPlayers(time.Now()) != 1 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := g.ConnectedPlayers(time.Now()); got != 1 {
+			t.Errorf("expected player 1 to be connected again, but %d players are", got)
+		}
+	})
+}
+
+func TestSocketHandler_Resync(t *testing.T) {
+	s := NewServer()
+	ts := httptest.NewServer(s.router)
+	t.Cleanup(ts.Close) // After the sockets are closed
+	g, p1, _, token1, _ := startedGame(t, s)
+	for g.FirstSeq() <= 2 {
+		if err := g.Apply(game.PassTurnAction{PlayerID: g.PlayerOrder[g.CurrentPlayerIndex]}); err != nil {
+			t.Fatalf("pass failed unexpectedly: %v", err)
+		}
+	}
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + fmt.Sprintf("/games/%s/ws?since=1&token=%s", g.ID, token1)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("could not open socket: %v", err)
+	}
+	defer conn.Close()
+	msg := readUntil(t, conn, g, p1.ID, "")
+	if msg.Type != "resync" || msg.Resync == nil || msg.Resync.FirstEventID != g.FirstSeq() {
+		t.Fatalf("expected a resync to event %d first, but got %+v", g.FirstSeq(), msg)
+	}
+	if msg := readUntil(t, conn, g, p1.ID, "event"); msg.Event.Seq != g.FirstSeq() {
+		t.Errorf("expected the events to resume at %d, but got %d", g.FirstSeq(), msg.Event.Seq)
+	}
+}
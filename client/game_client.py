@@ -3,11 +3,29 @@ import requests
 import json
 import time
 import textwrap
+import threading
+import uuid
+from datetime import datetime, timezone
 
-BASE_URL = "http://localhost:8080"
+try:
+    import websocket  # pip install websocket-client
+except ImportError:
+    websocket = None
+
+BASE_URL = "http://localhost:8080/v1"
 
 # --- Curses Helper Functions ---
 
+def seconds_left(deadline):
+    """Returns the whole seconds until an RFC 3339 deadline from the server."""
+    # Go sends nanoseconds, which datetime cannot parse; drop the fraction.
+    stamp, _, rest = deadline.partition('.')
+    offset = rest.lstrip('0123456789') if rest else deadline[19:]
+    if offset in ('', 'Z'):
+        offset = '+00:00'
+    end = datetime.fromisoformat(stamp[:19] + offset)
+    return max(0, int((end - datetime.now(timezone.utc)).total_seconds()))
+
 def draw_menu(stdscr, title, options, selected_idx):
     h, w = stdscr.getmaxyx()
     stdscr.clear()
@@ -87,9 +105,17 @@ def draw_game_state(stdscr, game_state, player_id):
         })
     all_players.extend(game_state.get('opponents', []))
 
+    waiting = game_state.get('state') == 'waiting_for_players'
     for p in all_players:
         pop = p.get('population', 0)
         elim_status = "(ELIMINATED)" if p.get('isEliminated') else f"(Pop: {pop:,})"
+        if p.get('id') != player_id and not p.get('connected', True):
+            elim_status += " (DISCONNECTED)"
+        if waiting:
+            if p.get('id') == game_state.get('hostID'):
+                elim_status = "(HOST)"
+            else:
+                elim_status = "(READY)" if p.get('isReady') or (p.get('id') == player_id and game_state.get('isReady')) else "(NOT READY)"
         player_info = f"- {p.get('name', 'Unknown')} {elim_status}"
 
         attr = curses.A_NORMAL
@@ -103,7 +129,7 @@ def draw_game_state(stdscr, game_state, player_id):
 
     # --- Section 3: Turn Indicator / Game Ready Message ---
     y_offset = player_y + 2 # Dynamic positioning with extra space
-    if game_state.get('state') == 'waiting_for_players' and len(all_players) >= 2:
+    if waiting and any(cmd['name'] == 'start' for cmd in game_state.get('availableCommands', [])):
         start_msg = "*** GAME READY TO START! Type 'start' to begin ***"
         stdscr.attron(curses.A_BOLD | curses.color_pair(1))
         stdscr.addstr(y_offset, max(2, (w - len(start_msg)) // 2), start_msg)
@@ -112,6 +138,8 @@ def draw_game_state(stdscr, game_state, player_id):
         current_turn_player_id = game_state.get('currentTurnPlayerId')
         if current_turn_player_id == player_id:
             turn_msg = "It's YOUR turn!"
+            if game_state.get('turnDeadline'):
+                turn_msg += f" ({seconds_left(game_state['turnDeadline'])}s left)"
             stdscr.attron(curses.A_BOLD | curses.color_pair(1))
             stdscr.addstr(y_offset, max(2, (w - len(turn_msg)) // 2), turn_msg)
             stdscr.attroff(curses.A_BOLD | curses.color_pair(1))
@@ -148,9 +176,14 @@ def draw_game_state(stdscr, game_state, player_id):
             stdscr.addstr(cmd_y_start -1 + cmd_idx, 4, cmd_str[:w-6])
             cmd_idx += 1
         
-        if any(cmd['name'] == 'play' for cmd in commands):
-            hint = "Play locations: active, deterrent_1, deterrent_2"
-            stdscr.addstr(cmd_y_start -1 + cmd_idx + 1, 4, hint, curses.A_DIM)
+        # The server lists the legal values of each parameter.
+        for cmd_data in commands:
+            if cmd_data['name'] != 'play':
+                continue
+            for param in cmd_data.get('params', []):
+                if param['name'] == 'location':
+                    hint = "Play locations: " + ", ".join(param.get('values', []))
+                    stdscr.addstr(cmd_y_start -1 + cmd_idx + 1, 4, hint[:w-6], curses.A_DIM)
     else:
         stdscr.addstr(cmd_y_start -1, 4, "(No commands available right now)")
 
@@ -172,22 +205,82 @@ def join_game(game_id, player_name):
     res.raise_for_status()
     return res.json()
 
-def get_game_state(game_id, player_id):
-    res = requests.get(f"{BASE_URL}/games/{game_id}?playerID={player_id}")
+def join_game_by_code(code, player_name):
+    res = requests.post(f"{BASE_URL}/join/{code}", json={"playerName": player_name})
     res.raise_for_status()
     return res.json()
 
-def start_game(game_id):
-    res = requests.post(f"{BASE_URL}/games/{game_id}/start")
+def auth_headers(token):
+    return {"Authorization": f"Bearer {token}"}
+
+_state_cache = {}
+
+def get_game_state(game_id, token):
+    # Revalidate with the last ETag so an unchanged game costs an empty 304.
+    headers = auth_headers(token)
+    cached = _state_cache.get(game_id)
+    if cached:
+        headers["If-None-Match"] = cached[0]
+    res = requests.get(f"{BASE_URL}/games/{game_id}", headers=headers)
+    if res.status_code == 304 and cached:
+        return cached[1]
+    res.raise_for_status()
+    state = res.json()
+    if res.headers.get("ETag"):
+        _state_cache[game_id] = (res.headers["ETag"], state)
+    return state
+
+def start_game(game_id, token):
+    res = requests.post(f"{BASE_URL}/games/{game_id}/start", headers=auth_headers(token))
     res.raise_for_status()
     return res.json()
 
-def post_command(game_id, player_id, command, args):
-    payload = {"playerID": player_id, **args}
-    res = requests.post(f"{BASE_URL}/games/{game_id}/{command}", json=payload)
+def post_command(game_id, token, command, args, version=None):
+    # The same Idempotency-Key on the retry means a command whose response was
+    # lost is not applied twice, and If-Match refuses it if the game has moved
+    # on since the view the player acted on.
+    headers = auth_headers(token)
+    headers["Idempotency-Key"] = str(uuid.uuid4())
+    if version is not None:
+        headers["If-Match"] = str(version)
+    url = f"{BASE_URL}/games/{game_id}/{command}"
+    try:
+        res = requests.post(url, json=args, headers=headers, timeout=10)
+    except requests.exceptions.ConnectionError:
+        res = requests.post(url, json=args, headers=headers, timeout=10)
     # Don't raise for status, as we want to handle game-specific errors
     return res
 
+class GameSocket:
+    """Keeps the latest view pushed by the server's WebSocket and sends commands over it."""
+
+    def __init__(self, game_id, token):
+        url = BASE_URL.replace("http", "ws", 1) + f"/games/{game_id}/ws"
+        self.view = None
+        self.error = None
+        self.ws = websocket.create_connection(url, header=[f"Authorization: Bearer {token}"])
+        threading.Thread(target=self._read, daemon=True).start()
+
+    def _read(self):
+        while True:
+            try:
+                msg = json.loads(self.ws.recv())
+            except Exception as e:
+                self.error = e
+                return
+            if msg.get('type') == 'view':
+                self.view = msg['view']
+            elif msg.get('type') in ('error', 'shutdown'):
+                # A shutdown message says the server is restarting; it carries
+                # its explanation in 'error' too.
+                self.error = msg.get('error')
+
+    def send(self, command, args, version=None):
+        msg = {'action': command, **args}
+        if version is not None:
+            msg['version'] = version
+        self.ws.send(json.dumps(msg))
+
 # --- Main Application Logic ---
 
 def main(stdscr):
@@ -213,13 +306,14 @@ def main(stdscr):
     try:
         if current_option == 0: # Create Game
             game_info = create_game()
-            game_id = game_info['id']
+            game_id = game_info['gameID']
+            join_code = game_info.get('joinCode', game_id)
 
-            # Display the Game ID so it can be shared
+            # Display the join code so it can be shared
             stdscr.clear()
             h, w = stdscr.getmaxyx()
-            msg1 = f"Game Created! Your Game ID is: {game_id}"
-            msg2 = "Share this ID with other players so they can join."
+            msg1 = f"Game Created! Your join code is: {join_code}"
+            msg2 = "Share this code with other players so they can join."
             msg3 = "Press any key to continue..."
             stdscr.addstr(h//2 - 2, w//2 - len(msg1)//2, msg1)
             stdscr.addstr(h//2, w//2 - len(msg2)//2, msg2)
@@ -233,16 +327,23 @@ def main(stdscr):
             while not player_name:
                 player_name = get_input(stdscr, "Enter your name: ")
             player_info = join_game(game_id, player_name)
-            player_id = player_info['id']
+            player_id = player_info['playerID']
+            token = player_info['playerToken']
         else: # Join Game
-            game_id = ""
-            while not game_id:
-                game_id = get_input(stdscr, "Enter Game ID: ")
+            code = ""
+            while not code:
+                code = get_input(stdscr, "Enter join code or Game ID: ").strip()
             player_name = ""
             while not player_name:
                 player_name = get_input(stdscr, "Enter your name: ")
-            player_info = join_game(game_id, player_name)
-            player_id = player_info['id']
+            # Game IDs are UUIDs; anything shorter is a join code.
+            if len(code) == 36:
+                player_info = join_game(code, player_name)
+            else:
+                player_info = join_game_by_code(code, player_name)
+            game_id = player_info['gameID']
+            player_id = player_info['playerID']
+            token = player_info['playerToken']
     except requests.exceptions.RequestException as e:
         stdscr.addstr(10, 2, f"Error connecting to server: {e}")
         stdscr.addstr(12, 2, "Press any key to exit.")
@@ -250,13 +351,35 @@ def main(stdscr):
         stdscr.getch()
         return
 
-    game_loop(stdscr, game_id, player_id)
+    game_loop(stdscr, game_id, player_id, token)
 
-def game_loop(stdscr, game_id, player_id):
+def game_loop(stdscr, game_id, player_id, token):
     """Main loop for handling game state updates and user input."""
+    # With websocket-client installed, the server pushes every change over a
+    # single connection; otherwise the client falls back to polling.
+    socket = None
+    if websocket is not None:
+        try:
+            socket = GameSocket(game_id, token)
+        except Exception:
+            socket = None
+
+    def fetch_state():
+        if socket is not None and socket.view is not None:
+            return socket.view
+        return get_game_state(game_id, token)
+
+    def send(command, args):
+        # Commands are tied to the view they were typed against.
+        version = game_state.get('version')
+        if socket is not None:
+            socket.send(command, args, version)
+        else:
+            post_command(game_id, token, command, args, version)
+
     while True:
         try:
-            game_state = get_game_state(game_id, player_id)
+            game_state = fetch_state()
             draw_game_state(stdscr, game_state, player_id)
         except requests.exceptions.RequestException as e:
             # Display a non-blocking error message
@@ -270,7 +393,7 @@ def game_loop(stdscr, game_id, player_id):
             break
         elif key == curses.KEY_ENTER or key in [10, 13]:
             # Get latest command list from server
-            game_state = get_game_state(game_id, player_id)
+            game_state = fetch_state()
             commands = game_state.get('availableCommands', [])
 
             # Show command prompt
@@ -292,18 +415,43 @@ def game_loop(stdscr, game_id, player_id):
             command = parts[0].lower()
             args = {}
             if command == 'play':
-                if len(parts) == 3:
+                if len(parts) in (3, 4):
                     args = {'cardID': parts[1], 'location': parts[2]}
-                    post_command(game_id, player_id, 'play', args)
+                    if len(parts) == 4:
+                        args['targetID'] = parts[3]
+                    send('play', args)
+            elif command == 'turn':
+                # Each cardID:location[:targetID] is a play; a bare player ID
+                # is the player to attack afterwards.
+                args = {'plays': []}
+                for part in parts[1:]:
+                    fields = part.split(':')
+                    if len(fields) == 1:
+                        args['targetID'] = fields[0]
+                        continue
+                    play = {'cardID': fields[0], 'location': fields[1]}
+                    if len(fields) == 3:
+                        play['targetID'] = fields[2]
+                    args['plays'].append(play)
+                send('turn', args)
             elif command == 'attack':
                 if len(parts) == 2:
                     # Note: The API expects 'targetID', not 'target_id'
                     args = {'targetID': parts[1]}
-                    post_command(game_id, player_id, 'attack', args)
+                    send('attack', args)
             elif command == 'pass':
-                post_command(game_id, player_id, 'pass', {})
+                send('pass', {})
             elif command == 'start':
-                start_game(game_id)
+                send('start', {})
+            elif command in ('leave', 'forfeit'):
+                send(command, {})
+                if command == 'leave':
+                    break
+            elif command == 'ready':
+                send('ready', {'ready': not game_state.get('isReady', False)})
+            elif command in ('kick', 'transfer_host'):
+                if len(parts) == 2:
+                    send(command, {'playerID': parts[1]})
 
         time.sleep(0.5) # Refresh rate
 
@@ -0,0 +1,277 @@
+// Package config resolves the server's settings from command-line flags and
+// environment variables. A flag wins over its environment variable, which
+// wins over the default.
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"nuclear-war-game-server/store"
+)
+
+// envPrefix starts the name of every environment variable the server reads.
+const envPrefix = "NUCLEAR_WAR_"
+
+// Config holds the server's resolved settings.
+type Config struct {
+	Addr        string // TCP address to listen on, such as ":8080"
+	Socket      string // Unix socket to listen on instead of Addr, if set
+	TLSCert     string // Certificate file; TLS is on when set
+	TLSKey      string // Private key file for TLSCert
+	Store       string // Persistence backend: "memory" or "file"
+	DataDir     string // Directory of the file store
+	CatalogPath string // Card catalog JSON file; the built-in deck if empty
+
+	ReadHeaderTimeout time.Duration // Time allowed to read a request's headers
+	ReadTimeout       time.Duration // Time allowed to read a whole request
+	WriteTimeout      time.Duration // Time allowed to write a response
+	IdleTimeout       time.Duration // Time an idle keep-alive connection is kept
+	ShutdownTimeout   time.Duration // Time a shutdown waits for requests to finish
+
+	RateLimit       float64 // Requests per second from one IP address; 0 disables
+	RateBurst       int     // Requests one IP address may make at once
+	TokenRateLimit  float64 // Requests per second with one player or spectator token; 0 disables
+	TokenRateBurst  int     // Requests one token may make at once
+	CreateRateLimit float64 // Games created per second from one IP address; 0 disables
+	CreateRateBurst int     // Games one IP address may create at once
+	MaxBodyBytes    int64   // Largest request body accepted; 0 disables the limit
+
+	LogLevel   slog.Level
+	LogFormat  string // "text" or "json"
+	AdminToken string // Bearer token for the admin endpoints; empty disables them
+}
+
+// Load resolves the settings from args, which excludes the program name, and
+// from the environment as returned by getenv. It fails on a setting that
+// cannot be parsed or on a combination that cannot work, and returns
+// flag.ErrHelp if help was asked for.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	var c Config
+	var level string
+	var errs []error
+	fs := newFlagSet(&c, &level, getenv, &errs)
+	fs.SetOutput(io.Discard)
+	c.AdminToken = getenv(envPrefix + "ADMIN_TOKEN")
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		errs = append(errs, fmt.Errorf("unexpected arguments: %v", fs.Args()))
+	}
+	if err := c.LogLevel.UnmarshalText([]byte(level)); err != nil {
+		errs = append(errs, fmt.Errorf("log level: %w", err))
+	}
+	errs = append(errs, c.validate())
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// Usage writes the flags, their defaults and their environment variables to w.
+func Usage(w io.Writer) {
+	var c Config
+	var level string
+	var errs []error
+	fs := newFlagSet(&c, &level, func(string) string { return "" }, &errs)
+	fs.SetOutput(w)
+	fmt.Fprintf(w, "Usage of %s:\n", fs.Name())
+	fs.PrintDefaults()
+	fmt.Fprintf(w, "  $%sADMIN_TOKEN\n    \tbearer token for the admin endpoints; they are disabled if it is unset\n", envPrefix)
+}
+
+// newFlagSet registers the flags that fill in c and level. Each flag defaults
+// to its environment variable, read with getenv, or else to its built-in
+// default. Environment variables that cannot be parsed are added to errs.
+func newFlagSet(c *Config, level *string, getenv func(string) string, errs *[]error) *flag.FlagSet {
+	env := func(name, def string) string {
+		if v := getenv(envPrefix + name); v != "" {
+			return v
+		}
+		return def
+	}
+	envDuration := func(name string, def time.Duration) time.Duration {
+		v := getenv(envPrefix + name)
+		if v == "" {
+			return def
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s%s: %w", envPrefix, name, err))
+			return def
+		}
+		return d
+	}
+	envFloat := func(name string, def float64) float64 {
+		v := getenv(envPrefix + name)
+		if v == "" {
+			return def
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s%s: %w", envPrefix, name, err))
+			return def
+		}
+		return f
+	}
+	envInt := func(name string, def int64) int64 {
+		v := getenv(envPrefix + name)
+		if v == "" {
+			return def
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s%s: %w", envPrefix, name, err))
+			return def
+		}
+		return n
+	}
+
+	fs := flag.NewFlagSet("nuclear-war-server", flag.ContinueOnError)
+	fs.StringVar(&c.Addr, "addr", env("ADDR", ":8080"), "TCP `address` to listen on ($NUCLEAR_WAR_ADDR)")
+	fs.StringVar(&c.Socket, "socket", env("SOCKET", ""), "Unix socket `path` to listen on instead of -addr ($NUCLEAR_WAR_SOCKET)")
+	fs.StringVar(&c.TLSCert, "tls-cert", env("TLS_CERT", ""), "TLS certificate `file`; serves HTTPS when set ($NUCLEAR_WAR_TLS_CERT)")
+	fs.StringVar(&c.TLSKey, "tls-key", env("TLS_KEY", ""), "TLS private key `file` ($NUCLEAR_WAR_TLS_KEY)")
+	fs.StringVar(&c.Store, "store", env("STORE", "memory"), "persistence `backend`: memory or file ($NUCLEAR_WAR_STORE)")
+	fs.StringVar(&c.DataDir, "data-dir", env("DATA_DIR", "data"), "`directory` of the file store ($NUCLEAR_WAR_DATA_DIR)")
+	fs.StringVar(&c.CatalogPath, "catalog", env("CATALOG", ""), "card catalog JSON `file`; the built-in deck if empty ($NUCLEAR_WAR_CATALOG)")
+	fs.DurationVar(&c.ReadHeaderTimeout, "read-header-timeout", envDuration("READ_HEADER_TIMEOUT", 10*time.Second), "time allowed to read request headers ($NUCLEAR_WAR_READ_HEADER_TIMEOUT)")
+	fs.DurationVar(&c.ReadTimeout, "read-timeout", envDuration("READ_TIMEOUT", 30*time.Second), "time allowed to read a whole request ($NUCLEAR_WAR_READ_TIMEOUT)")
+	fs.DurationVar(&c.WriteTimeout, "write-timeout", envDuration("WRITE_TIMEOUT", 30*time.Second), "time allowed to write a response; streams and long polls get longer ($NUCLEAR_WAR_WRITE_TIMEOUT)")
+	fs.DurationVar(&c.IdleTimeout, "idle-timeout", envDuration("IDLE_TIMEOUT", 2*time.Minute), "time an idle keep-alive connection is kept open ($NUCLEAR_WAR_IDLE_TIMEOUT)")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", envDuration("SHUTDOWN_TIMEOUT", 15*time.Second), "time a shutdown waits for requests to finish before saving the games ($NUCLEAR_WAR_SHUTDOWN_TIMEOUT)")
+	fs.Float64Var(&c.RateLimit, "rate-limit", envFloat("RATE_LIMIT", 20), "requests per second allowed from one IP address; 0 disables ($NUCLEAR_WAR_RATE_LIMIT)")
+	fs.IntVar(&c.RateBurst, "rate-burst", int(envInt("RATE_BURST", 40)), "requests one IP address may make at once ($NUCLEAR_WAR_RATE_BURST)")
+	fs.Float64Var(&c.TokenRateLimit, "token-rate-limit", envFloat("TOKEN_RATE_LIMIT", 10), "requests per second allowed with one player or spectator token; 0 disables ($NUCLEAR_WAR_TOKEN_RATE_LIMIT)")
+	fs.IntVar(&c.TokenRateBurst, "token-rate-burst", int(envInt("TOKEN_RATE_BURST", 20)), "requests one token may make at once ($NUCLEAR_WAR_TOKEN_RATE_BURST)")
+	fs.Float64Var(&c.CreateRateLimit, "create-rate-limit", envFloat("CREATE_RATE_LIMIT", 0.1), "games per second one IP address may create; 0 disables ($NUCLEAR_WAR_CREATE_RATE_LIMIT)")
+	fs.IntVar(&c.CreateRateBurst, "create-rate-burst", int(envInt("CREATE_RATE_BURST", 5)), "games one IP address may create at once ($NUCLEAR_WAR_CREATE_RATE_BURST)")
+	fs.Int64Var(&c.MaxBodyBytes, "max-body-bytes", envInt("MAX_BODY_BYTES", 1<<20), "largest request body accepted, in bytes; 0 disables ($NUCLEAR_WAR_MAX_BODY_BYTES)")
+	fs.StringVar(level, "log-level", env("LOG_LEVEL", "info"), "log `level`: debug, info, warn or error ($NUCLEAR_WAR_LOG_LEVEL)")
+	fs.StringVar(&c.LogFormat, "log-format", env("LOG_FORMAT", "text"), "log `format`: text or json ($NUCLEAR_WAR_LOG_FORMAT)")
+	return fs
+}
+
+// validate checks the settings against each other and the filesystem.
+func (c Config) validate() error {
+	var errs []error
+	if c.Socket == "" {
+		if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+			errs = append(errs, fmt.Errorf("address %q: %w", c.Addr, err))
+		}
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		errs = append(errs, errors.New("-tls-cert and -tls-key must be set together"))
+	} else if c.TLSCert != "" {
+		if _, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey); err != nil {
+			errs = append(errs, fmt.Errorf("TLS certificate: %w", err))
+		}
+	}
+	known := false
+	for _, b := range store.Backends {
+		known = known || c.Store == b
+	}
+	if !known {
+		errs = append(errs, fmt.Errorf("unknown store %q (want one of %v)", c.Store, store.Backends))
+	}
+	if c.Store == "file" && c.DataDir == "" {
+		errs = append(errs, errors.New("the file store needs -data-dir"))
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		errs = append(errs, fmt.Errorf("unknown log format %q (want text or json)", c.LogFormat))
+	}
+	if c.ReadHeaderTimeout < 0 || c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 || c.ShutdownTimeout < 0 {
+		errs = append(errs, errors.New("timeouts cannot be negative"))
+	}
+	for _, l := range []struct {
+		name  string
+		rate  float64
+		burst int
+	}{
+		{"-rate-limit", c.RateLimit, c.RateBurst},
+		{"-token-rate-limit", c.TokenRateLimit, c.TokenRateBurst},
+		{"-create-rate-limit", c.CreateRateLimit, c.CreateRateBurst},
+	} {
+		if l.rate < 0 {
+			errs = append(errs, fmt.Errorf("%s cannot be negative", l.name))
+		} else if l.rate > 0 && l.burst < 1 {
+			errs = append(errs, fmt.Errorf("%s needs a burst of at least 1", l.name))
+		}
+	}
+	if c.MaxBodyBytes < 0 {
+		errs = append(errs, errors.New("-max-body-bytes cannot be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// LogValue implements slog.LogValuer, logging the settings as a group with
+// secrets redacted.
+func (c Config) LogValue() slog.Value {
+	var attrs []slog.Attr
+	for _, kv := range c.settings() {
+		attrs = append(attrs, slog.String(kv[0], kv[1]))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// settings returns the name and shown value of every setting.
+func (c Config) settings() [][2]string {
+	listen := c.Addr
+	if c.Socket != "" {
+		listen = "unix:" + c.Socket
+	}
+	tlsSetting := "off"
+	if c.TLSCert != "" {
+		tlsSetting = fmt.Sprintf("cert=%s key=%s", c.TLSCert, c.TLSKey)
+	}
+	storeSetting := c.Store
+	if c.Store == "file" {
+		storeSetting += " dir=" + c.DataDir
+	}
+	catalog := c.CatalogPath
+	if catalog == "" {
+		catalog = "built-in"
+	}
+	rate := func(perSecond float64, burst int) string {
+		if perSecond == 0 {
+			return "off"
+		}
+		return fmt.Sprintf("%g/s burst=%d", perSecond, burst)
+	}
+	maxBody := "off"
+	if c.MaxBodyBytes > 0 {
+		maxBody = strconv.FormatInt(c.MaxBodyBytes, 10)
+	}
+	admin := "disabled"
+	if c.AdminToken != "" {
+		admin = "enabled"
+	}
+
+	return [][2]string{
+		{"listen", listen},
+		{"tls", tlsSetting},
+		{"store", storeSetting},
+		{"catalog", catalog},
+		{"read-header-timeout", c.ReadHeaderTimeout.String()},
+		{"read-timeout", c.ReadTimeout.String()},
+		{"write-timeout", c.WriteTimeout.String()},
+		{"idle-timeout", c.IdleTimeout.String()},
+		{"shutdown-timeout", c.ShutdownTimeout.String()},
+		{"rate-limit", rate(c.RateLimit, c.RateBurst)},
+		{"token-rate-limit", rate(c.TokenRateLimit, c.TokenRateBurst)},
+		{"create-rate-limit", rate(c.CreateRateLimit, c.CreateRateBurst)},
+		{"max-body-bytes", maxBody},
+		{"log-level", c.LogLevel.String()},
+		{"log-format", c.LogFormat},
+		{"admin", admin},
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env returns a getenv function reading from vars.
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+// logged returns the settings c logs at startup, by name.
+func logged(c Config) map[string]string {
+	settings := map[string]string{}
+	for _, attr := range c.LogValue().Group() {
+		settings[attr.Key] = attr.Value.String()
+	}
+	return settings
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		c, err := Load(nil, env(nil))
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if c.Addr != ":8080" || c.Store != "memory" || c.LogLevel != slog.LevelInfo || c.ReadHeaderTimeout != 10*time.Second || c.ShutdownTimeout != 15*time.Second {
+			t.Errorf("unexpected defaults: %+v", c)
+		}
+	})
+
+	t.Run("flags win over the environment", func(t *testing.T) {
+		vars := map[string]string{
+			"NUCLEAR_WAR_ADDR":         "127.0.0.1:9000",
+			"NUCLEAR_WAR_LOG_LEVEL":    "debug",
+			"NUCLEAR_WAR_IDLE_TIMEOUT": "30s",
+			"NUCLEAR_WAR_ADMIN_TOKEN":  "secret",
+		}
+		c, err := Load([]string{"-addr", ":9001", "-store", "file", "-data-dir", t.TempDir()}, env(vars))
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if c.Addr != ":9001" || c.LogLevel != slog.LevelDebug || c.IdleTimeout != 30*time.Second || c.Store != "file" {
+			t.Errorf("unexpected settings: %+v", c)
+		}
+		if c.AdminToken != "secret" {
+			t.Error("expected the admin token to be read from the environment")
+		}
+		settings := logged(c)
+		for name, value := range settings {
+			if strings.Contains(value, "secret") {
+				t.Errorf("expected the logged settings not to show the admin token, but %s is %q", name, value)
+			}
+		}
+		if settings["admin"] != "enabled" {
+			t.Errorf("expected the admin endpoints to be logged as enabled, but got %q", settings["admin"])
+		}
+	})
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		vars := map[string]string{"NUCLEAR_WAR_IDLE_TIMEOUT": "soon", "NUCLEAR_WAR_RATE_LIMIT": "fast"}
+		_, err := Load([]string{"-addr", "nope", "-store", "redis", "-log-level", "loud", "-log-format", "xml", "-tls-cert", "cert.pem", "-token-rate-burst", "0", "-max-body-bytes", "-1"}, env(vars))
+		if err == nil {
+			t.Fatal("expected Load to fail")
+		}
+		for _, want := range []string{"NUCLEAR_WAR_IDLE_TIMEOUT", "NUCLEAR_WAR_RATE_LIMIT", "nope", "redis", "log level", "log format", "-tls-key", "-token-rate-limit needs a burst", "-max-body-bytes"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("expected the error to mention %q, but got:\n%v", want, err)
+			}
+		}
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		vars := map[string]string{"NUCLEAR_WAR_CREATE_RATE_LIMIT": "0.5", "NUCLEAR_WAR_MAX_BODY_BYTES": "4096"}
+		c, err := Load([]string{"-rate-limit", "0", "-write-timeout", "5s"}, env(vars))
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if c.RateLimit != 0 || c.CreateRateLimit != 0.5 || c.MaxBodyBytes != 4096 || c.WriteTimeout != 5*time.Second {
+			t.Errorf("unexpected settings: %+v", c)
+		}
+		if got := logged(c)["create-rate-limit"]; got != "0.5/s burst=5" {
+			t.Errorf("expected the create limit to be logged, but got %q", got)
+		}
+	})
+
+	t.Run("a socket replaces the address", func(t *testing.T) {
+		c, err := Load([]string{"-addr", "", "-socket", "/tmp/nuclear-war.sock"}, env(nil))
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if got := logged(c)["listen"]; got != "unix:/tmp/nuclear-war.sock" {
+			t.Errorf("expected the socket to be logged as the listen address, but got %q", got)
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		if _, err := Load([]string{"-help"}, env(nil)); !errors.Is(err, flag.ErrHelp) {
+			t.Errorf("expected flag.ErrHelp, but got %v", err)
+		}
+	})
+}
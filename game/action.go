@@ -0,0 +1,262 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ActionType identifies the kind of an Action.
+type ActionType string
+
+const (
+	ActionJoin      ActionType = "join"
+	ActionStartGame ActionType = "start"
+	ActionPlayCard  ActionType = "play"
+	ActionPass      ActionType = "pass"
+	ActionAttack    ActionType = "attack"
+	ActionTurn      ActionType = "turn"
+
+	ActionReady         ActionType = "ready"
+	ActionKick          ActionType = "kick"
+	ActionTransferHost  ActionType = "transfer_host"
+	ActionUpdateOptions ActionType = "options"
+	ActionLeave         ActionType = "leave"
+	ActionForfeit       ActionType = "forfeit"
+)
+
+// Action is a single, typed mutation of the game state.
+// Every change to a Game goes through Game.Apply, so the HTTP layer, bots and
+// replays all share the same locking, turn and phase checks.
+type Action interface {
+	// Type returns the kind of the action.
+	Type() ActionType
+	// Actor returns the ID of the player taking the action, or "" when the
+	// action is taken by the server itself.
+	Actor() string
+	// Validate checks the action against the current state without changing it.
+	Validate(g *Game) error
+	// Apply performs the action. It is only called after Validate succeeded.
+	// If it fails, whatever it changed is undone.
+	Apply(g *Game) error
+}
+
+// actionRule describes who may take an action type. When an action is legal
+// is declared separately in the state transition table.
+type actionRule struct {
+	// requiresTurn reports whether only the current player may take the action
+	// in the given state.
+	requiresTurn func(state GameState) bool
+	// hostOnly reports whether only the host may take the action. The server
+	// itself is not bound by it.
+	hostOnly bool
+}
+
+func always(GameState) bool { return true }
+
+func never(GameState) bool { return false }
+
+var actionRules = map[ActionType]actionRule{
+	ActionJoin:      {requiresTurn: never},
+	ActionStartGame: {requiresTurn: never, hostOnly: true},
+	// Secret cards are played simultaneously during the opening round.
+	ActionPlayCard: {requiresTurn: func(state GameState) bool { return state != StateOpeningRound }},
+	ActionPass:     {requiresTurn: always},
+	ActionAttack:   {requiresTurn: always},
+	ActionTurn:     {requiresTurn: always},
+
+	ActionReady:         {requiresTurn: never},
+	ActionKick:          {requiresTurn: never, hostOnly: true},
+	ActionTransferHost:  {requiresTurn: never, hostOnly: true},
+	ActionUpdateOptions: {requiresTurn: never, hostOnly: true},
+	ActionLeave:         {requiresTurn: never},
+	ActionForfeit:       {requiresTurn: never},
+}
+
+// Apply validates and performs an action as a single step.
+// It takes the game lock, checks the action against the state transition table
+// and the turn rules, runs the action's own validation and, on success, bumps
+// the version and commits the events the action emitted.
+func (g *Game) Apply(a Action) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.applyAsPlayer(a)
+}
+
+// ApplyAtVersion is Apply for a caller that acted on a view of the game at
+// version. The action is rejected with ErrVersionMismatch, without being
+// validated, if the game has moved on since then.
+func (g *Game) ApplyAtVersion(a Action, version uint64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Version != version {
+		err := newError(ErrVersionMismatch, fmt.Sprintf("game has changed: it is at version %d, not %d", g.Version, version),
+			"version", strconv.FormatUint(g.Version, 10), "expected", strconv.FormatUint(version, 10))
+		g.observeAction(a.Type(), err)
+		return err
+	}
+	return g.applyAsPlayer(a)
+}
+
+// applyAsPlayer applies an action a player sent. A player whose seat a bot
+// took over gets it back by taking an action that succeeds; a rejected one
+// leaves the bot in charge.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) applyAsPlayer(a Action) error {
+	if err := g.applyLocked(a, time.Now()); err != nil {
+		return err
+	}
+	if p, ok := g.Players[a.Actor()]; ok {
+		p.IsBot = false
+	}
+	return nil
+}
+
+// applyLocked is Apply without the locking. now is the time the action is
+// taken, which starts the next turn clock.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) applyLocked(a Action, now time.Time) (err error) {
+	defer func() { g.observeAction(a.Type(), err) }()
+	g.pending = g.pending[:0]
+
+	if err := g.checkRules(a.Type(), a.Actor()); err != nil {
+		return err
+	}
+	if err := a.Validate(g); err != nil {
+		return err
+	}
+
+	g.recordSpectatorBaseline()
+	// Validate cannot foresee everything, so an action that fails part way
+	// through is undone rather than left half applied.
+	snapshot := g.snapshot()
+	g.applying, g.transitionFrom = a.Type(), g.State
+	err = a.Apply(g)
+	g.applying, g.transitionFrom = "", ""
+	if err != nil {
+		g.restore(snapshot)
+		return err
+	}
+
+	g.Version++
+	g.updateTurnClock(now)
+	g.commitEvents()
+	g.recordSpectatorFrame()
+	g.notifySubscribers()
+	return nil
+}
+
+// checkRules enforces the transition, membership and turn rules shared by all
+// actions of the given type taken by actorID.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) checkRules(actionType ActionType, actorID string) error {
+	rule, ok := actionRules[actionType]
+	if !ok {
+		return newError(ErrUnknownAction, fmt.Sprintf("unknown action type: %s", actionType), "action", string(actionType))
+	}
+	if _, ok := lookupTransition(g.State, actionType); !ok {
+		return newError(ErrInvalidPhase, fmt.Sprintf("cannot %s while the game is in state %s", actionType, g.State),
+			"action", string(actionType), "state", string(g.State))
+	}
+
+	if actorID == "" {
+		return nil
+	}
+	actor, ok := g.Players[actorID]
+	if !ok {
+		return newError(ErrPlayerNotFound, fmt.Sprintf("player with ID %s not found", actorID), "playerID", actorID)
+	}
+	if rule.hostOnly && actorID != g.HostID {
+		return newError(ErrNotHost, fmt.Sprintf("only the host can %s", actionType), "action", string(actionType))
+	}
+
+	isCurrent := len(g.PlayerOrder) > g.CurrentPlayerIndex && g.PlayerOrder[g.CurrentPlayerIndex] == actorID
+
+	// An eliminated player may only act during their own Final Strike.
+	if actor.IsEliminated && !(g.State == StateFinalStrike && isCurrent) {
+		return newError(ErrPlayerEliminated, fmt.Sprintf("player %s is eliminated and cannot %s", actor.Name, actionType), "playerID", actorID)
+	}
+
+	if rule.requiresTurn(g.State) && !isCurrent {
+		if g.State == StateFinalStrike {
+			current, currentID := g.currentTurn()
+			return newError(ErrNotYourTurn, fmt.Sprintf("it is player %s's Final Strike, not player %s's", current, actor.Name),
+				"currentPlayerID", currentID)
+		}
+		_, currentID := g.currentTurn()
+		return newError(ErrNotYourTurn, fmt.Sprintf("it is not player %s's turn", actor.Name), "currentPlayerID", currentID)
+	}
+	return nil
+}
+
+// gameSnapshot holds the parts of a game that actions change, so that a
+// failed action can be undone. Tokens are left out: the actions that revoke
+// them cannot fail once they have.
+type gameSnapshot struct {
+	players            map[string]*Player
+	saved              map[string]Player
+	playerOrder        []string
+	hostID             string
+	joinCode           string
+	state              GameState
+	currentPlayerIndex int
+	winner             *Player
+	deck               []*Card
+	populationDeck     []*Card
+	discardPile        []*Card
+	populationBank     int64
+	options            GameOptions
+	pending            int
+}
+
+// snapshot records the state an action can change, for restore to return to.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) snapshot() gameSnapshot {
+	s := gameSnapshot{
+		players:            make(map[string]*Player, len(g.Players)),
+		saved:              make(map[string]Player, len(g.Players)),
+		playerOrder:        append([]string(nil), g.PlayerOrder...),
+		hostID:             g.HostID,
+		joinCode:           g.JoinCode,
+		state:              g.State,
+		currentPlayerIndex: g.CurrentPlayerIndex,
+		winner:             g.Winner,
+		deck:               copyCards(g.Deck),
+		populationDeck:     copyCards(g.PopulationDeck),
+		discardPile:        copyCards(g.DiscardPile),
+		populationBank:     g.PopulationBank,
+		options:            g.Options,
+		pending:            len(g.pending),
+	}
+	for id, p := range g.Players {
+		saved := *p
+		saved.Hand = copyCards(p.Hand)
+		saved.Placemat = p.Placemat.clone()
+		s.players[id] = p
+		s.saved[id] = saved
+	}
+	return s
+}
+
+// restore returns the game to a snapshot. Players are restored in place, so
+// pointers to them stay valid.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) restore(s gameSnapshot) {
+	g.Players = make(map[string]*Player, len(s.players))
+	for id, p := range s.players {
+		*p = s.saved[id]
+		g.Players[id] = p
+	}
+	g.PlayerOrder = s.playerOrder
+	g.HostID = s.hostID
+	g.JoinCode = s.joinCode
+	g.State = s.state
+	g.CurrentPlayerIndex = s.currentPlayerIndex
+	g.Winner = s.winner
+	g.Deck = s.deck
+	g.PopulationDeck = s.populationDeck
+	g.DiscardPile = s.discardPile
+	g.PopulationBank = s.populationBank
+	g.Options = s.options
+	g.pending = g.pending[:s.pending]
+}
@@ -0,0 +1,230 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	t.Run("bumps the version and records events", func(t *testing.T) {
+		g := NewGame()
+		p1, _ := g.AddPlayer("Player 1")
+		p2, _ := g.AddPlayer("Player 2")
+		g.PlayerOrder = []string{p1.ID, p2.ID}
+		g.CurrentPlayerIndex = 0
+		g.State = StateInProgress
+		before, seq, logged := g.Version, g.lastSeq, len(g.TurnLog)
+
+		if err := g.Apply(PassTurnAction{PlayerID: p1.ID}); err != nil {
+			t.Fatalf("Apply failed unexpectedly: %v", err)
+		}
+
+		if g.Version != before+1 {
+			t.Errorf("expected version to be %d, but got %d", before+1, g.Version)
+		}
+
+		events := g.EventsSince(seq)
+		if len(events) != 2 {
+			t.Fatalf("expected 2 events, but got %d", len(events))
+		}
+		if events[0].Type != EventTurnPassed || events[1].Type != EventTurnAdvanced {
+			t.Errorf("expected turn_passed then turn_advanced, but got %s then %s", events[0].Type, events[1].Type)
+		}
+		if events[1].Seq != seq+2 || events[1].Version != g.Version {
+			t.Errorf("expected second event to have seq %d and version %d, but got seq %d and version %d", seq+2, g.Version, events[1].Seq, events[1].Version)
+		}
+		if len(g.TurnLog) != logged+2 {
+			t.Errorf("expected turn log to have 2 new entries, but got %d", len(g.TurnLog)-logged)
+		}
+	})
+
+	t.Run("rejected actions do not change the version", func(t *testing.T) {
+		g := NewGame()
+		p1, _ := g.AddPlayer("Player 1")
+		p2, _ := g.AddPlayer("Player 2")
+		g.PlayerOrder = []string{p1.ID, p2.ID}
+		g.CurrentPlayerIndex = 0
+		g.State = StateInProgress
+		before, seq := g.Version, g.lastSeq
+
+		if err := g.Apply(PassTurnAction{PlayerID: p2.ID}); err == nil {
+			t.Fatal("expected an error when passing out of turn, but got nil")
+		}
+		if g.Version != before {
+			t.Errorf("expected version to stay %d, but got %d", before, g.Version)
+		}
+		if len(g.EventsSince(seq)) != 0 {
+			t.Errorf("expected no events after a rejected action")
+		}
+	})
+
+	t.Run("failed actions are undone", func(t *testing.T) {
+		g := NewGame()
+		p1, _ := g.AddPlayer("Player 1")
+		p2, _ := g.AddPlayer("Player 2")
+		if err := g.StartGame(); err != nil {
+			t.Fatalf("StartGame failed unexpectedly: %v", err)
+		}
+		g.State = StateInProgress
+		before, hand, deck, population := g.Version, len(p1.Hand), len(g.Deck), p2.Population
+
+		err := g.Apply(halfAppliedAction{PassTurnAction{PlayerID: p1.ID}})
+		if !errors.Is(err, errHalfApplied) {
+			t.Fatalf("expected the action to fail, but got %v", err)
+		}
+		if len(p1.Hand) != hand || len(g.Deck) != deck || len(g.DiscardPile) != 0 {
+			t.Errorf("expected the cards to be put back, but the hand has %d, the deck %d and the discard pile %d",
+				len(p1.Hand), len(g.Deck), len(g.DiscardPile))
+		}
+		if p2.Population != population || g.State != StateInProgress || g.CurrentPlayerIndex != 0 {
+			t.Errorf("expected the game to be left as it was, but it is %s at player %d with %d population left to player 2",
+				g.State, g.CurrentPlayerIndex, p2.Population)
+		}
+		if g.Players[p2.ID] != p2 {
+			t.Error("expected players to keep their identity")
+		}
+		if g.Version != before || len(g.EventsSince(before)) != 0 {
+			t.Error("expected no version bump or events after a failed action")
+		}
+	})
+
+	t.Run("rejects actions in the wrong phase", func(t *testing.T) {
+		g := NewGame()
+		p1, _ := g.AddPlayer("Player 1")
+		_, _ = g.AddPlayer("Player 2")
+
+		if err := g.Apply(PassTurnAction{PlayerID: p1.ID}); err == nil {
+			t.Error("expected an error when passing before the game has started, but got nil")
+		}
+	})
+
+	t.Run("rejects unknown players", func(t *testing.T) {
+		g := NewGame()
+		_, _ = g.AddPlayer("Player 1")
+		_, _ = g.AddPlayer("Player 2")
+		g.State = StateInProgress
+
+		if err := g.Apply(PassTurnAction{PlayerID: "nobody"}); err == nil {
+			t.Error("expected an error for an unknown player, but got nil")
+		}
+	})
+}
+
+var errHalfApplied = errors.New("failed half way")
+
+// halfAppliedAction passes the turn, but only after drawing and discarding a
+// card and wiping out the other players' population, and then fails.
+type halfAppliedAction struct {
+	PassTurnAction
+}
+
+func (a halfAppliedAction) Apply(g *Game) error {
+	player := g.Players[a.PlayerID]
+	player.Hand = append(player.Hand, g.drawCard())
+	g.DiscardPile = append(g.DiscardPile, player.Hand[0])
+	player.Hand = player.Hand[1:]
+	for _, p := range g.Players {
+		if p.ID != player.ID {
+			p.Population = 0
+		}
+	}
+	if err := a.PassTurnAction.Apply(g); err != nil {
+		return err
+	}
+	return errHalfApplied
+}
+
+func TestApplyAtVersion(t *testing.T) {
+	g := NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	g.PlayerOrder = []string{p1.ID, p2.ID}
+	g.CurrentPlayerIndex = 0
+	g.State = StateInProgress
+	seen := g.Version
+
+	if err := g.ApplyAtVersion(PassTurnAction{PlayerID: p1.ID}, seen); err != nil {
+		t.Fatalf("ApplyAtVersion failed unexpectedly: %v", err)
+	}
+
+	// Player 2 acts on the view from before Player 1 passed.
+	err := g.ApplyAtVersion(PassTurnAction{PlayerID: p2.ID}, seen)
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("expected ErrVersionMismatch, but got %v", err)
+	}
+	if g.Version != seen+1 {
+		t.Errorf("expected version to stay %d, but got %d", seen+1, g.Version)
+	}
+
+	if err := g.ApplyAtVersion(PassTurnAction{PlayerID: p2.ID}, g.CurrentVersion()); err != nil {
+		t.Errorf("expected the action to apply at the current version, but got %v", err)
+	}
+}
+
+func TestAttack_RequiresTurn(t *testing.T) {
+	g := NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	p1.Population = 25000000
+	g.PlayerOrder = []string{p1.ID, p2.ID}
+	g.CurrentPlayerIndex = 0
+	g.State = StateInProgress
+
+	// Player 2 has an attack ready but it is Player 1's turn.
+	p2.Placemat.ActiveCards = []*Card{
+		{ID: "d1", Type: TypeDeliverySystem, Name: "B-52"},
+		{ID: "w1", Type: TypeWarhead, Name: "10 Megaton", WarheadSize: 10},
+	}
+
+	if err := g.Attack(p2.ID, p1.ID); err == nil {
+		t.Fatal("expected an error when attacking out of turn, but got nil")
+	}
+	if p1.Population != 25000000 {
+		t.Errorf("expected population to be unchanged, but got %d", p1.Population)
+	}
+}
+
+func TestPassTurn_DeclinesFinalStrike(t *testing.T) {
+	g := NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	p3, _ := g.AddPlayer("Player 3")
+	g.PlayerOrder = []string{p1.ID, p2.ID, p3.ID}
+	p2.IsEliminated = true
+	g.CurrentPlayerIndex = 1
+	g.State = StateFinalStrike
+
+	if err := g.PassTurn(p2.ID); err != nil {
+		t.Fatalf("PassTurn failed unexpectedly: %v", err)
+	}
+
+	if g.State != StateInProgress {
+		t.Errorf("expected game state to be '%s', but got '%s'", StateInProgress, g.State)
+	}
+	if g.CurrentPlayerIndex != 2 {
+		t.Errorf("expected CurrentPlayerIndex to be 2, but got %d", g.CurrentPlayerIndex)
+	}
+}
+
+func TestFirstSeq(t *testing.T) {
+	g, p1, p2 := turnGame()
+	if got := g.FirstSeq(); got != 1 {
+		t.Errorf("expected the first event to be 1 before any, but got %d", got)
+	}
+	for i := 0; i < maxEvents; i++ {
+		player := p1
+		if i%2 == 1 {
+			player = p2
+		}
+		if err := g.Apply(PassTurnAction{PlayerID: player.ID}); err != nil {
+			t.Fatalf("pass %d failed unexpectedly: %v", i, err)
+		}
+	}
+	// Every pass commits two events, so only the later half are kept.
+	if got, want := g.FirstSeq(), g.LastSeq()-maxEvents+1; got != want {
+		t.Errorf("expected the oldest kept event to be %d, but got %d", want, got)
+	}
+	if events := g.EventsSince(0); events[0].Seq != g.FirstSeq() {
+		t.Errorf("expected EventsSince to start at FirstSeq, but it starts at %d", events[0].Seq)
+	}
+}
@@ -0,0 +1,51 @@
+package game
+
+// botAction picks the next move for a seat played by the server. The bot
+// arms one delivery system and one warhead, then attacks the strongest
+// opponent; when it has nothing useful to do it passes.
+// This is an internal function and assumes a lock is already held.
+func botAction(g *Game, p *Player) Action {
+	switch g.State {
+	case StateOpeningRound:
+		if p.Placemat.FaceDownCard1 != nil {
+			return nil
+		}
+		for _, card := range p.Hand {
+			if card.Type == TypeSecret {
+				return PlayCardAction{PlayerID: p.ID, CardID: card.ID, Location: "face_down_1"}
+			}
+		}
+		return nil
+	case StateInProgress, StateFinalStrike:
+		deliverySystem, warhead := attackCards(p)
+		if deliverySystem != nil && warhead != nil {
+			if target := strongestOpponent(g, p); target != nil {
+				return AttackAction{AttackerID: p.ID, TargetID: target.ID}
+			}
+		}
+		if g.State == StateInProgress {
+			for _, card := range p.Hand {
+				if (card.Type == TypeDeliverySystem && deliverySystem == nil) || (card.Type == TypeWarhead && warhead == nil) {
+					return PlayCardAction{PlayerID: p.ID, CardID: card.ID, Location: "face_up"}
+				}
+			}
+		}
+		return PassTurnAction{PlayerID: p.ID}
+	}
+	return nil
+}
+
+// strongestOpponent returns the surviving opponent with the largest population.
+func strongestOpponent(g *Game, p *Player) *Player {
+	var target *Player
+	for _, id := range g.PlayerOrder {
+		other := g.Players[id]
+		if other.ID == p.ID || other.IsEliminated {
+			continue
+		}
+		if target == nil || other.Population > target.Population {
+			target = other
+		}
+	}
+	return target
+}
@@ -1,76 +1,185 @@
 package game
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"os"
+	"sync/atomic"
 )
 
-// createPopulationDeck creates the initial 20 population cards.
-func createPopulationDeck() []*Card {
-	return []*Card{
-		{ID: "pop-1m-1", Name: "1 Million", Type: "Population", Value: 1000000},
-		{ID: "pop-1m-2", Name: "1 Million", Type: "Population", Value: 1000000},
-		{ID: "pop-1m-3", Name: "1 Million", Type: "Population", Value: 1000000},
-		{ID: "pop-1m-4", Name: "1 Million", Type: "Population", Value: 1000000},
-		{ID: "pop-1m-5", Name: "1 Million", Type: "Population", Value: 1000000},
-		{ID: "pop-2m-1", Name: "2 Million", Type: "Population", Value: 2000000},
-		{ID: "pop-2m-2", Name: "2 Million", Type: "Population", Value: 2000000},
-		{ID: "pop-2m-3", Name: "2 Million", Type: "Population", Value: 2000000},
-		{ID: "pop-2m-4", Name: "2 Million", Type: "Population", Value: 2000000},
-		{ID: "pop-5m-1", Name: "5 Million", Type: "Population", Value: 5000000},
-		{ID: "pop-5m-2", Name: "5 Million", Type: "Population", Value: 5000000},
-		{ID: "pop-5m-3", Name: "5 Million", Type: "Population", Value: 5000000},
-		{ID: "pop-10m-1", Name: "10 Million", Type: "Population", Value: 10000000},
-		{ID: "pop-10m-2", Name: "10 Million", Type: "Population", Value: 10000000},
-		{ID: "pop-10m-3", Name: "10 Million", Type: "Population", Value: 10000000},
-		{ID: "pop-25m-1", Name: "25 Million", Type: "Population", Value: 25000000},
-		{ID: "pop-25m-2", Name: "25 Million", Type: "Population", Value: 25000000},
-		{ID: "pop-25m-3", Name: "25 Million", Type: "Population", Value: 25000000},
-		{ID: "pop-25m-4", Name: "25 Million", Type: "Population", Value: 25000000},
-		{ID: "pop-25m-5", Name: "25 Million", Type: "Population", Value: 25000000},
+// handSize is the number of cards each player is dealt, including their
+// secret card.
+const handSize = 9
+
+// minPopulationCards is the smallest population deck that can deal a full
+// game of any size.
+const minPopulationCards = 20
+
+// CatalogEntry describes one kind of card and how many copies of it a deck
+// holds. The copies get IDs "<id>-0", "<id>-1" and so on.
+type CatalogEntry struct {
+	Card
+	Count int `json:"count"`
+}
+
+// Catalog lists the cards new games are dealt from.
+type Catalog struct {
+	Population []CatalogEntry `json:"population"`
+	Deck       []CatalogEntry `json:"deck"`
+}
+
+// catalog is the catalog new games are dealt from.
+var catalog atomic.Pointer[Catalog]
+
+func init() {
+	catalog.Store(DefaultCatalog())
+}
+
+// SetCatalog makes new games deal from c. Games already created keep their
+// cards.
+func SetCatalog(c *Catalog) {
+	catalog.Store(c)
+}
+
+// CurrentCatalog returns the catalog new games are dealt from.
+func CurrentCatalog() *Catalog {
+	return catalog.Load()
+}
+
+// LoadCatalog reads and validates a catalog from a JSON file.
+func LoadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var c Catalog
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("parsing card catalog %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("card catalog %s: %w", path, err)
+	}
+	return &c, nil
 }
 
-// createNuclearWarDeck creates the 100-card Nuclear War deck.
-// Note: This is a sample deck. We can adjust the card distribution later.
-func createNuclearWarDeck() []*Card {
-	deck := make([]*Card, 0, 100)
+// Hash returns a digest of the catalog, "sha256:" followed by hex, that
+// changes whenever any card or count does.
+func (c *Catalog) Hash() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		// A catalog is plain data, so this cannot happen.
+		panic(fmt.Sprintf("encoding card catalog: %v", err))
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
 
-	// Propaganda (20 cards)
-	for i := 0; i < 20; i++ {
-		deck = append(deck, &Card{ID: fmt.Sprintf("prop-%d", i), Name: "Propaganda", Type: TypePropaganda, Description: "Steal 1 million population from a player."})
+// Validate checks that every card is well formed and that the catalog holds
+// enough cards to deal a game of MaxPlayers.
+func (c *Catalog) Validate() error {
+	ids := map[string]bool{}
+	populationCards := 0
+	for _, e := range c.Population {
+		if err := e.validate(ids); err != nil {
+			return err
+		}
+		if e.Type != TypePopulation || e.Value <= 0 {
+			return fmt.Errorf("population card %q must have type %q and a positive value", e.ID, TypePopulation)
+		}
+		populationCards += e.Count
+	}
+	if populationCards < minPopulationCards {
+		return fmt.Errorf("the population deck has %d cards, but at least %d are needed", populationCards, minPopulationCards)
 	}
 
-	// Delivery Systems (30 cards)
-	for i := 0; i < 15; i++ {
-		deck = append(deck, &Card{ID: fmt.Sprintf("missile-%d", i), Name: "ICBM", Type: TypeDeliverySystem, CarryingCapacity: 100, Description: "Carries a warhead up to 100 megatons."})
+	drawable := 0
+	for _, e := range c.Deck {
+		if err := e.validate(ids); err != nil {
+			return err
+		}
+		switch e.Type {
+		case TypeWarhead:
+			if e.WarheadSize <= 0 {
+				return fmt.Errorf("warhead %q must have a positive warhead_size", e.ID)
+			}
+		case TypeDeliverySystem:
+			if e.CarryingCapacity <= 0 {
+				return fmt.Errorf("delivery system %q must have a positive carrying_capacity", e.ID)
+			}
+		case TypePropaganda, TypeAntiMissile, TypeSecret, TypeTopSecret:
+		default:
+			return fmt.Errorf("card %q has unknown type %q", e.ID, e.Type)
+		}
+		if e.Type != TypeSecret {
+			drawable += e.Count
+		}
 	}
-	for i := 0; i < 15; i++ {
-		deck = append(deck, &Card{ID: fmt.Sprintf("bomber-%d", i), Name: "B-52 Bomber", Type: TypeDeliverySystem, CarryingCapacity: 200, Description: "Carries multiple warheads up to a total of 200 megatons."})
+	if need := handSize * MaxPlayers; drawable < need {
+		return fmt.Errorf("the deck has %d cards besides secrets, but at least %d are needed", drawable, need)
 	}
+	return nil
+}
 
-	// Warheads (30 cards)
-	for i := 0; i < 10; i++ {
-		deck = append(deck, &Card{ID: fmt.Sprintf("wh-10m-%d", i), Name: "10 Megaton Warhead", Type: TypeWarhead, WarheadSize: 10, Description: "A 10-megaton warhead."})
+// validate checks the fields every entry needs, and that its ID is not in ids.
+func (e CatalogEntry) validate(ids map[string]bool) error {
+	if e.ID == "" || e.Name == "" {
+		return fmt.Errorf("every card needs an id and a name")
 	}
-	for i := 0; i < 10; i++ {
-		deck = append(deck, &Card{ID: fmt.Sprintf("wh-25m-%d", i), Name: "25 Megaton Warhead", Type: TypeWarhead, WarheadSize: 25, Description: "A 25-megaton warhead."})
+	if ids[e.ID] {
+		return fmt.Errorf("card id %q is used twice", e.ID)
 	}
-	for i := 0; i < 10; i++ {
-		deck = append(deck, &Card{ID: fmt.Sprintf("wh-100m-%d", i), Name: "100 Megaton Warhead", Type: TypeWarhead, WarheadSize: 100, Description: "A 100-megaton warhead."})
+	ids[e.ID] = true
+	if e.Count <= 0 {
+		return fmt.Errorf("card %q must have a positive count", e.ID)
 	}
+	return nil
+}
 
-	// Anti-Missiles (10 cards)
-	for i := 0; i < 10; i++ {
-		deck = append(deck, &Card{ID: fmt.Sprintf("anti-missile-%d", i), Name: "Anti-Missile System", Type: TypeAntiMissile, Intercepts: []string{"ICBM"}, Description: "Intercepts ICBMs."})
+// cards returns a fresh copy of every card the entries describe.
+func cards(entries []CatalogEntry) []*Card {
+	var out []*Card
+	for _, e := range entries {
+		for i := 0; i < e.Count; i++ {
+			card := e.Card
+			card.ID = fmt.Sprintf("%s-%d", e.ID, i)
+			out = append(out, &card)
+		}
 	}
+	return out
+}
 
-	// Secrets (10 cards)
-	for i := 0; i < 10; i++ {
-		deck = append(deck, &Card{ID: fmt.Sprintf("secret-%d", i), Name: "Secret: Spy Network", Type: TypeSecret, Description: "Look at another player's hand."})
+// DefaultCatalog returns the built-in catalog: 20 population cards and a
+// 100-card Nuclear War deck.
+// Note: This is a sample deck. We can adjust the card distribution later.
+func DefaultCatalog() *Catalog {
+	population := func(id, name string, value int64, count int) CatalogEntry {
+		return CatalogEntry{Card: Card{ID: id, Name: name, Type: TypePopulation, Value: value}, Count: count}
+	}
+	return &Catalog{
+		Population: []CatalogEntry{
+			population("pop-1m", "1 Million", 1000000, 5),
+			population("pop-2m", "2 Million", 2000000, 4),
+			population("pop-5m", "5 Million", 5000000, 3),
+			population("pop-10m", "10 Million", 10000000, 3),
+			population("pop-25m", "25 Million", 25000000, 5),
+		},
+		Deck: []CatalogEntry{
+			{Card: Card{ID: "prop", Name: "Propaganda", Type: TypePropaganda, Description: "Steal 1 million population from a player."}, Count: 20},
+			{Card: Card{ID: "missile", Name: "ICBM", Type: TypeDeliverySystem, CarryingCapacity: 100, Description: "Carries a warhead up to 100 megatons."}, Count: 15},
+			{Card: Card{ID: "bomber", Name: "B-52 Bomber", Type: TypeDeliverySystem, CarryingCapacity: 200, Description: "Carries multiple warheads up to a total of 200 megatons."}, Count: 15},
+			{Card: Card{ID: "wh-10m", Name: "10 Megaton Warhead", Type: TypeWarhead, WarheadSize: 10, Description: "A 10-megaton warhead."}, Count: 10},
+			{Card: Card{ID: "wh-25m", Name: "25 Megaton Warhead", Type: TypeWarhead, WarheadSize: 25, Description: "A 25-megaton warhead."}, Count: 10},
+			{Card: Card{ID: "wh-100m", Name: "100 Megaton Warhead", Type: TypeWarhead, WarheadSize: 100, Description: "A 100-megaton warhead."}, Count: 10},
+			{Card: Card{ID: "anti-missile", Name: "Anti-Missile System", Type: TypeAntiMissile, Intercepts: []string{"ICBM"}, Description: "Intercepts ICBMs."}, Count: 10},
+			{Card: Card{ID: "secret", Name: "Secret: Spy Network", Type: TypeSecret, Description: "Look at another player's hand."}, Count: 10},
+		},
 	}
-
-	return deck
 }
 
 // ShuffleCards shuffles a slice of cards.
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultCatalog(t *testing.T) {
+	c := DefaultCatalog()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("expected the default catalog to be valid, but got %v", err)
+	}
+
+	population, deck := cards(c.Population), cards(c.Deck)
+	if len(population) != 20 || len(deck) != 100 {
+		t.Errorf("expected 20 population cards and 100 deck cards, but got %d and %d", len(population), len(deck))
+	}
+	seen := map[string]bool{}
+	for _, card := range append(population, deck...) {
+		if seen[card.ID] {
+			t.Errorf("card ID %s is dealt twice", card.ID)
+		}
+		seen[card.ID] = true
+	}
+}
+
+func TestCatalog_Hash(t *testing.T) {
+	a, b := DefaultCatalog(), DefaultCatalog()
+	if a.Hash() != b.Hash() {
+		t.Error("expected equal catalogs to hash alike")
+	}
+	b.Deck[0].Count++
+	if a.Hash() == b.Hash() {
+		t.Error("expected a changed count to change the hash")
+	}
+	if !strings.HasPrefix(a.Hash(), "sha256:") {
+		t.Errorf("expected a sha256 digest, but got %s", a.Hash())
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "catalog.json")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("a valid catalog deals new games", func(t *testing.T) {
+		c := DefaultCatalog()
+		c.Deck[0].Name = "Leaflets"
+		data, _ := json.Marshal(c)
+
+		loaded, err := LoadCatalog(write(t, string(data)))
+		if err != nil {
+			t.Fatalf("LoadCatalog failed: %v", err)
+		}
+		SetCatalog(loaded)
+		defer SetCatalog(DefaultCatalog())
+
+		g := NewGame()
+		found := false
+		for _, card := range g.Deck {
+			found = found || card.Name == "Leaflets"
+		}
+		if !found {
+			t.Error("expected a new game to be dealt from the loaded catalog")
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		edit func(c *Catalog)
+		want string
+	}{
+		"duplicate IDs":     {func(c *Catalog) { c.Deck[1].ID = c.Deck[0].ID }, "used twice"},
+		"unknown type":      {func(c *Catalog) { c.Deck[0].Type = "Nuke" }, "unknown type"},
+		"too small a deck":  {func(c *Catalog) { c.Deck = c.Deck[:1] }, "at least"},
+		"no count":          {func(c *Catalog) { c.Population[0].Count = 0 }, "positive count"},
+		"harmless warheads": {func(c *Catalog) { c.Deck[3].WarheadSize = 0 }, "warhead_size"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := DefaultCatalog()
+			tc.edit(c)
+			data, _ := json.Marshal(c)
+			if _, err := LoadCatalog(write(t, string(data))); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected an error mentioning %q, but got %v", tc.want, err)
+			}
+		})
+	}
+
+	t.Run("unknown fields are rejected", func(t *testing.T) {
+		if _, err := LoadCatalog(write(t, `{"population": [], "deck": [], "jokers": 2}`)); err == nil {
+			t.Error("expected an unknown field to be rejected")
+		}
+	})
+}
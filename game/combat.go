@@ -4,40 +4,47 @@ import (
 	"fmt"
 )
 
-// Attack handles a player's action to attack another player.
-func (g *Game) Attack(attackerID, targetID string) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+// AttackAction launches the attacker's face-up delivery system and warhead at a target.
+type AttackAction struct {
+	AttackerID string
+	TargetID   string
+}
+
+// Type implements Action.
+func (a AttackAction) Type() ActionType { return ActionAttack }
 
-	// 1. Find attacker and target players.
-	attacker, ok := g.Players[attackerID]
+// Actor implements Action.
+func (a AttackAction) Actor() string { return a.AttackerID }
+
+// Validate implements Action.
+func (a AttackAction) Validate(g *Game) error {
+	attacker := g.Players[a.AttackerID]
+	target, ok := g.Players[a.TargetID]
 	if !ok {
-		return fmt.Errorf("attacker with ID %s not found", attackerID)
+		return newError(ErrPlayerNotFound, fmt.Sprintf("target with ID %s not found", a.TargetID), "targetID", a.TargetID)
 	}
-	target, ok := g.Players[targetID]
-	if !ok {
-		return fmt.Errorf("target with ID %s not found", targetID)
+	if target.ID == attacker.ID {
+		return newError(ErrInvalidTarget, "you cannot attack yourself", "targetID", a.TargetID)
+	}
+	if target.IsEliminated {
+		return newError(ErrInvalidTarget, fmt.Sprintf("player %s is already eliminated", target.Name), "targetID", a.TargetID)
 	}
 
-	isFinalStrike := g.State == StateFinalStrike
-
-	// If it's a final strike, only the current (eliminated) player can attack.
-	if isFinalStrike {
-		if attacker.ID != g.PlayerOrder[g.CurrentPlayerIndex] {
-			return fmt.Errorf("it is player %s's Final Strike, not player %s's", g.Players[g.PlayerOrder[g.CurrentPlayerIndex]].Name, attacker.Name)
-		}
-	} else {
-		// In a normal turn, an eliminated player cannot attack.
-		if attacker.IsEliminated {
-			return fmt.Errorf("player %s is eliminated and cannot attack", attacker.Name)
-		}
-		// TODO: Add check to ensure it's the attacker's actual turn.
+	// Validate the attack combination on the attacker's placemat.
+	deliverySystem, warhead := attackCards(attacker)
+	if deliverySystem == nil {
+		return newError(ErrNotArmed, fmt.Sprintf("attacker %s has no delivery system in play", attacker.Name), "missing", TypeDeliverySystem)
+	}
+	if warhead == nil {
+		return newError(ErrNotArmed, fmt.Sprintf("attacker %s has no warhead in play", attacker.Name), "missing", TypeWarhead)
 	}
 
-	// 2. Validate the attack combination on the attacker's placemat.
-	var deliverySystem *Card
-	var warhead *Card
+	// TODO: Check if the warhead size is compatible with the delivery system's payload.
+	return nil
+}
 
+// attackCards returns the delivery system and warhead an attack would use.
+func attackCards(attacker *Player) (deliverySystem, warhead *Card) {
 	for _, card := range attacker.Placemat.ActiveCards {
 		if card.Type == TypeDeliverySystem {
 			deliverySystem = card
@@ -45,28 +52,33 @@ func (g *Game) Attack(attackerID, targetID string) error {
 			warhead = card
 		}
 	}
+	return deliverySystem, warhead
+}
 
-	if deliverySystem == nil {
-		return fmt.Errorf("attacker %s has no delivery system in play", attacker.Name)
+// interceptor returns the face-up Anti-Missile that stops an attack on
+// target, or nil if the attack gets through.
+func interceptor(target *Player) *Card {
+	for _, card := range target.Placemat.ActiveCards {
+		if card.Type == TypeAntiMissile {
+			return card
+		}
 	}
+	return nil
+}
 
-	if warhead == nil {
-		return fmt.Errorf("attacker %s has no warhead in play", attacker.Name)
-	}
+// Apply implements Action.
+func (a AttackAction) Apply(g *Game) error {
+	attacker := g.Players[a.AttackerID]
+	target := g.Players[a.TargetID]
+	deliverySystem, warhead := attackCards(attacker)
 
-	// TODO: Check if the warhead size is compatible with the delivery system's payload.
+	isFinalStrike := g.State == StateFinalStrike
 
-	fmt.Printf("Player %s is attacking Player %s with a %d megaton warhead on a %s!\n",
+	g.emit(EventAttack, attacker.ID, target.ID, "Player %s is attacking Player %s with a %d megaton warhead on a %s!",
 		attacker.Name, target.Name, warhead.WarheadSize, deliverySystem.Name)
 
-	// 3. Check for defense.
-	var antiMissile *Card
-	for _, card := range target.Placemat.ActiveCards {
-		if card.Type == TypeAntiMissile {
-			antiMissile = card
-			break
-		}
-	}
+	// 1. Check for defense.
+	antiMissile := interceptor(target)
 
 	// Remove attacker's cards regardless of outcome.
 	newAttackerCards := []*Card{}
@@ -78,7 +90,7 @@ func (g *Game) Attack(attackerID, targetID string) error {
 	attacker.Placemat.ActiveCards = newAttackerCards
 
 	if antiMissile != nil {
-		fmt.Printf("Player %s's attack was intercepted by an Anti-Missile!\n", attacker.Name)
+		g.emit(EventIntercepted, attacker.ID, target.ID, "Player %s's attack was intercepted by an Anti-Missile!", attacker.Name)
 		// Remove the used anti-missile card from the target's placemat.
 		newTargetCards := []*Card{}
 		for _, card := range target.Placemat.ActiveCards {
@@ -91,39 +103,31 @@ func (g *Game) Attack(attackerID, targetID string) error {
 		// 1 megaton = 1 million population
 		damage := int64(warhead.WarheadSize) * 1000000
 		target.Population -= damage
-		fmt.Printf("Attack successful! Player %s loses %d population.\n", target.Name, damage)
+		g.emit(EventDamage, attacker.ID, target.ID, "Attack successful! Player %s loses %d population.", target.Name, damage)
 
 		if target.Population <= 0 {
 			target.Population = 0
 			if !target.IsEliminated {
-				target.IsEliminated = true
-				fmt.Printf("Player %s has been eliminated!\n", target.Name)
-
-				// Set up the Final Strike
-				g.State = StateFinalStrike
-				for i, playerID := range g.PlayerOrder {
-					if playerID == target.ID {
-						g.CurrentPlayerIndex = i
-						break
-					}
-				}
-				fmt.Printf("Player %s gets a Final Strike! It is now their turn.\n", target.Name)
-				// End the current attack function here. The next action must be an attack from the eliminated player.
-				return nil
+				// End the attack here. The next action must come from the eliminated player.
+				return g.eliminate(target)
 			}
 		}
 	}
 
 	// If this was a Final Strike, reset state before checking for winner and advancing turn.
 	if isFinalStrike {
-		g.State = StateInProgress
-		fmt.Printf("Player %s has completed their Final Strike.\n", attacker.Name)
+		if err := g.setState(StateInProgress); err != nil {
+			return err
+		}
+		g.emit(EventFinalStrike, attacker.ID, "", "Player %s has completed their Final Strike.", attacker.Name)
 	}
 
-	// 5. Check for a winner.
-	g.checkForWinner()
+	// 2. Check for a winner.
+	if err := g.checkForWinner(); err != nil {
+		return err
+	}
 
-	// 6. An attack is a turn-ending action, but only if the game isn't over.
+	// 3. An attack is a turn-ending action, but only if the game isn't over.
 	if g.State != StateGameOver {
 		g.AdvanceTurn()
 	}
@@ -131,9 +135,35 @@ func (g *Game) Attack(attackerID, targetID string) error {
 	return nil
 }
 
+// eliminate knocks a player whose population has run out of the game and
+// hands them their Final Strike.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) eliminate(target *Player) error {
+	target.IsEliminated = true
+	g.emit(EventPlayerEliminated, target.ID, "", "Player %s has been eliminated!", target.Name)
+
+	// Set up the Final Strike
+	if err := g.setState(StateFinalStrike); err != nil {
+		return err
+	}
+	for i, playerID := range g.PlayerOrder {
+		if playerID == target.ID {
+			g.CurrentPlayerIndex = i
+			break
+		}
+	}
+	g.emit(EventFinalStrike, target.ID, "", "Player %s gets a Final Strike! It is now their turn.", target.Name)
+	return nil
+}
+
+// Attack handles a player's action to attack another player.
+func (g *Game) Attack(attackerID, targetID string) error {
+	return g.Apply(AttackAction{AttackerID: attackerID, TargetID: targetID})
+}
+
 // checkForWinner checks if there is only one player left and declares them the winner.
 // This is an internal function and assumes a lock is already held.
-func (g *Game) checkForWinner() {
+func (g *Game) checkForWinner() error {
 	activePlayers := []*Player{}
 	for _, player := range g.Players {
 		if !player.IsEliminated {
@@ -142,8 +172,11 @@ func (g *Game) checkForWinner() {
 	}
 
 	if len(activePlayers) == 1 {
+		if err := g.setState(StateGameOver); err != nil {
+			return err
+		}
 		g.Winner = activePlayers[0]
-		g.State = StateGameOver
-		fmt.Printf("Player %s has won the game!\n", g.Winner.Name)
+		g.emit(EventGameOver, g.Winner.ID, "", "Player %s has won the game!", g.Winner.Name)
 	}
+	return nil
 }
@@ -0,0 +1,56 @@
+package game
+
+import "errors"
+
+// Sentinel errors returned by the engine, so callers can tell rule violations
+// apart with errors.Is instead of matching on messages. Errors are usually
+// returned as an *Error that wraps one of these.
+var (
+	ErrUnknownAction    = errors.New("unknown action")
+	ErrInvalidPhase     = errors.New("not allowed in the current phase")
+	ErrPlayerNotFound   = errors.New("player not found")
+	ErrNotHost          = errors.New("only the host may do this")
+	ErrNotYourTurn      = errors.New("not your turn")
+	ErrPlayerEliminated = errors.New("player is eliminated")
+	ErrInvalidTarget    = errors.New("invalid target")
+	ErrNotReady         = errors.New("players are not ready")
+	ErrNotEnoughPlayers = errors.New("not enough players")
+	ErrGameFull         = errors.New("game is full")
+	ErrInvalidName      = errors.New("invalid name")
+	ErrInvalidOptions   = errors.New("invalid options")
+	ErrCardNotInHand    = errors.New("card not in hand")
+	ErrCardNotPlayable  = errors.New("card cannot be played now")
+	ErrInvalidLocation  = errors.New("invalid card location")
+	ErrSlotOccupied     = errors.New("slot is occupied")
+	ErrNotArmed         = errors.New("no delivery system or warhead in play")
+	ErrVersionMismatch  = errors.New("game has changed")
+	ErrInvalidState     = errors.New("the game cannot move on from its current state")
+)
+
+// Error is an error returned by the engine. It reads as a message for the
+// player, unwraps to one of the sentinel errors above and carries the values
+// the message was built from, such as the offending card ID.
+type Error struct {
+	Err     error
+	Message string
+	Details map[string]string
+}
+
+// Error implements error.
+func (e *Error) Error() string { return e.Message }
+
+// Unwrap returns the sentinel error.
+func (e *Error) Unwrap() error { return e.Err }
+
+// newError returns an *Error wrapping err. details are alternating keys and
+// values.
+func newError(err error, message string, details ...string) *Error {
+	e := &Error{Err: err, Message: message}
+	if len(details) > 0 {
+		e.Details = make(map[string]string, len(details)/2)
+		for i := 0; i+1 < len(details); i += 2 {
+			e.Details[details[i]] = details[i+1]
+		}
+	}
+	return e
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	g := NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	g.PlayerOrder = []string{p1.ID, p2.ID}
+	g.CurrentPlayerIndex = 0
+	g.State = StateInProgress
+	card := &Card{ID: "card-1", Name: "Test Card", Type: TypeWarhead}
+	g.Players[p1.ID].Hand = []*Card{card}
+	g.Players[p1.ID].Placemat.FaceDownCard1 = &Card{ID: "card-2"}
+
+	tests := []struct {
+		name    string
+		action  Action
+		want    error
+		details map[string]string
+	}{
+		{"out of turn", PassTurnAction{PlayerID: p2.ID}, ErrNotYourTurn, map[string]string{"currentPlayerID": p1.ID}},
+		{"wrong phase", StartGameAction{PlayerID: p1.ID}, ErrInvalidPhase, map[string]string{"state": string(StateInProgress)}},
+		{"unknown player", PassTurnAction{PlayerID: "nobody"}, ErrPlayerNotFound, map[string]string{"playerID": "nobody"}},
+		{"card not in hand", PlayCardAction{PlayerID: p1.ID, CardID: "missing", Location: "face_up"}, ErrCardNotInHand, map[string]string{"cardID": "missing"}},
+		{"slot occupied", PlayCardAction{PlayerID: p1.ID, CardID: card.ID, Location: "face_down_1"}, ErrSlotOccupied, map[string]string{"location": "face_down_1"}},
+		{"invalid location", PlayCardAction{PlayerID: p1.ID, CardID: card.ID, Location: "pocket"}, ErrInvalidLocation, map[string]string{"location": "pocket"}},
+		{"unarmed attack", AttackAction{AttackerID: p1.ID, TargetID: p2.ID}, ErrNotArmed, map[string]string{"missing": TypeDeliverySystem}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := g.Apply(tt.action)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, but got %v", tt.want, err)
+			}
+			var gameErr *Error
+			if !errors.As(err, &gameErr) {
+				t.Fatalf("expected an *Error, but got %T", err)
+			}
+			for key, want := range tt.details {
+				if got := gameErr.Details[key]; got != want {
+					t.Errorf("expected detail %s to be '%s', but got '%s'", key, want, got)
+				}
+			}
+		})
+	}
+}
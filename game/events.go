@@ -0,0 +1,145 @@
+package game
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// EventType identifies the kind of an Event.
+type EventType string
+
+const (
+	EventGameStarted      EventType = "game_started"
+	EventCardPlayed       EventType = "card_played"
+	EventPropaganda       EventType = "propaganda"
+	EventSecretRevealed   EventType = "secret_revealed"
+	EventTurnPassed       EventType = "turn_passed"
+	EventTurnAdvanced     EventType = "turn_advanced"
+	EventAttack           EventType = "attack"
+	EventIntercepted      EventType = "intercepted"
+	EventDamage           EventType = "damage"
+	EventPlayerEliminated EventType = "player_eliminated"
+	EventFinalStrike      EventType = "final_strike"
+	EventGameOver         EventType = "game_over"
+
+	EventPlayerJoined   EventType = "player_joined"
+	EventPlayerReady    EventType = "player_ready"
+	EventPlayerKicked   EventType = "player_kicked"
+	EventHostChanged    EventType = "host_changed"
+	EventOptionsUpdated EventType = "options_updated"
+
+	EventPlayerLeft      EventType = "player_left"
+	EventPlayerForfeited EventType = "player_forfeited"
+
+	EventTurnTimeout      EventType = "turn_timeout"
+	EventBotTakeover      EventType = "bot_takeover"
+	EventAutoActionFailed EventType = "auto_action_failed"
+)
+
+// maxEvents is the number of committed events a game keeps in memory.
+const maxEvents = 500
+
+// Event records something that happened in the game as the result of an action.
+// Events only carry public information, so they can be shown to every client.
+type Event struct {
+	Seq      uint64    `json:"seq"`
+	Version  uint64    `json:"version"`
+	Type     EventType `json:"type"`
+	PlayerID string    `json:"playerId,omitempty"`
+	TargetID string    `json:"targetId,omitempty"`
+	Message  string    `json:"message"`
+	Time     time.Time `json:"time"`
+}
+
+// emit queues an event to be committed when the current action succeeds.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) emit(eventType EventType, playerID, targetID, format string, args ...interface{}) {
+	g.pending = append(g.pending, Event{
+		Type:     eventType,
+		PlayerID: playerID,
+		TargetID: targetID,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// commitEvents stamps the queued events with a sequence number and the current
+// version, appends them to the event log and the turn log, and clears the queue.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) commitEvents() {
+	now := time.Now()
+	for _, ev := range g.pending {
+		g.lastSeq++
+		ev.Seq = g.lastSeq
+		ev.Version = g.Version
+		ev.Time = now
+		g.log(slog.LevelDebug, ev.Message, "seq", ev.Seq, "event", ev.Type, "player_id", ev.PlayerID, "target_id", ev.TargetID)
+		g.events = append(g.events, ev)
+		g.TurnLog = append(g.TurnLog, ev.Message)
+		if g.observer != nil {
+			g.observer.EventCommitted(g.ID, ev)
+		}
+	}
+	if len(g.events) > maxEvents {
+		g.events = append([]Event(nil), g.events[len(g.events)-maxEvents:]...)
+	}
+	g.pending = g.pending[:0]
+}
+
+// EventsSince returns the committed events with a sequence number greater than seq.
+func (g *Game) EventsSince(seq uint64) []Event {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	events := []Event{}
+	for _, ev := range g.events {
+		if ev.Seq > seq {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+// Subscribe returns a channel that receives a value whenever the game changes,
+// and a function that ends the subscription. Notifications are coalesced: a
+// subscriber that falls behind gets one notification for several changes.
+func (g *Game) Subscribe() (<-chan struct{}, func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	g.subscribers[ch] = struct{}{}
+	return ch, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		delete(g.subscribers, ch)
+	}
+}
+
+// notifySubscribers tells every subscriber that the game has changed.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) notifySubscribers() {
+	for ch := range g.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// FirstSeq returns the sequence number of the oldest event still kept, or the
+// number the next event will get if none are. A client that last saw an
+// event before FirstSeq()-1 has missed events that EventsSince cannot return.
+func (g *Game) FirstSeq() uint64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if len(g.events) == 0 {
+		return g.lastSeq + 1
+	}
+	return g.events[0].Seq
+}
+
+// LastSeq returns the sequence number of the last committed event.
+func (g *Game) LastSeq() uint64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.lastSeq
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// TurnAction takes a whole turn at once: the player's card plays, in order,
+// followed by an attack or, if there is no attack, a pass. Either every step
+// succeeds or the game is left exactly as it was. A play that ends the turn,
+// such as Propaganda eliminating its target, ends the whole action there.
+type TurnAction struct {
+	PlayerID string
+	Plays    []PlayCardAction // Deterrents, face-down cards and face-up cards
+	// AttackTargetID is the player to attack once the cards are played. The
+	// turn ends with a pass if it is empty.
+	AttackTargetID string
+}
+
+// Type implements Action.
+func (a TurnAction) Type() ActionType { return ActionTurn }
+
+// Actor implements Action.
+func (a TurnAction) Actor() string { return a.PlayerID }
+
+// steps returns the actions making up the turn, in the order they are taken.
+func (a TurnAction) steps() []Action {
+	steps := make([]Action, 0, len(a.Plays)+1)
+	for _, play := range a.Plays {
+		play.PlayerID = a.PlayerID
+		steps = append(steps, play)
+	}
+	if a.AttackTargetID != "" {
+		steps = append(steps, AttackAction{AttackerID: a.PlayerID, TargetID: a.AttackTargetID})
+	} else {
+		steps = append(steps, PassTurnAction{PlayerID: a.PlayerID})
+	}
+	return steps
+}
+
+// Validate implements Action. Each step can only be checked against the game
+// as the steps before it leave it, so the whole turn is tried out and then
+// undone.
+func (a TurnAction) Validate(g *Game) error {
+	snapshot := g.snapshot()
+	defer g.restore(snapshot)
+	return a.run(g)
+}
+
+// Apply implements Action. A turn that fails part way through is undone
+// along with any other failed action.
+func (a TurnAction) Apply(g *Game) error {
+	return a.run(g)
+}
+
+// run takes each step of the turn in turn, under the same rules as if it had
+// been applied on its own, and stops at the first that fails. It also stops,
+// successfully, after a step that changes the state or whose turn it is: the
+// player's turn is over, so the steps left are not theirs to take.
+// This is an internal function and assumes a lock is already held.
+func (a TurnAction) run(g *Game) error {
+	applying, from := g.applying, g.transitionFrom
+	defer func() { g.applying, g.transitionFrom = applying, from }()
+
+	for i, step := range a.steps() {
+		state, current := g.State, g.CurrentPlayerIndex
+		if err := g.checkRules(step.Type(), step.Actor()); err != nil {
+			return stepError(i, err)
+		}
+		if err := step.Validate(g); err != nil {
+			return stepError(i, err)
+		}
+		g.applying, g.transitionFrom = step.Type(), g.State
+		if err := step.Apply(g); err != nil {
+			return stepError(i, err)
+		}
+		if g.State != state || g.CurrentPlayerIndex != current {
+			return nil
+		}
+	}
+	return nil
+}
+
+// stepError adds the index of the failing step to an error, counting the
+// plays from 0 and the closing attack or pass last.
+func stepError(i int, err error) error {
+	var e *Error
+	if !errors.As(err, &e) {
+		return fmt.Errorf("step %d: %w", i, err)
+	}
+	details := map[string]string{"step": strconv.Itoa(i)}
+	for k, v := range e.Details {
+		details[k] = v
+	}
+	return &Error{Err: e.Err, Message: fmt.Sprintf("step %d: %s", i, e.Message), Details: details}
+}
+
+// TakeTurn plays cards and then attacks targetID, or passes if it is empty,
+// as a single action.
+func (g *Game) TakeTurn(playerID string, plays []PlayCardAction, targetID string) error {
+	return g.Apply(TurnAction{PlayerID: playerID, Plays: plays, AttackTargetID: targetID})
+}
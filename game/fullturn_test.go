@@ -0,0 +1,134 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+// turnGame returns a game in progress where it is Player 1's turn.
+func turnGame() (*Game, *Player, *Player) {
+	g := NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	g.PlayerOrder = []string{p1.ID, p2.ID}
+	g.CurrentPlayerIndex = 0
+	g.State = StateInProgress
+	p2.Population = 25000000
+	return g, p1, p2
+}
+
+func TestTakeTurn(t *testing.T) {
+	t.Run("plays the cards and attacks as one action", func(t *testing.T) {
+		g, p1, p2 := turnGame()
+		p1.Hand = []*Card{
+			{ID: "d1", Type: TypeDeliverySystem, Name: "ICBM"},
+			{ID: "w1", Type: TypeWarhead, Name: "10 Megaton", WarheadSize: 10},
+			{ID: "x1", Type: TypeAntiMissile},
+		}
+		before := g.CurrentVersion()
+
+		plays := []PlayCardAction{
+			{CardID: "d1", Location: "face_up"},
+			{CardID: "w1", Location: "face_up"},
+			{CardID: "x1", Location: "face_down_2"},
+		}
+		if err := g.TakeTurn(p1.ID, plays, p2.ID); err != nil {
+			t.Fatalf("TakeTurn failed unexpectedly: %v", err)
+		}
+
+		if p2.Population != 15000000 {
+			t.Errorf("expected the attack to land, but player 2 has %d population", p2.Population)
+		}
+		if len(p1.Hand) != 0 || p1.Placemat.FaceDownCard2 == nil {
+			t.Errorf("expected every card to be played, but the hand holds %d", len(p1.Hand))
+		}
+		if got := g.CurrentVersion(); got != before+1 {
+			t.Errorf("expected the turn to be a single version (%d), but the game is at %d", before+1, got)
+		}
+		if g.PlayerOrder[g.CurrentPlayerIndex] != p2.ID {
+			t.Error("expected the turn to pass to player 2")
+		}
+	})
+
+	t.Run("ends with a pass without an attack target", func(t *testing.T) {
+		g, p1, p2 := turnGame()
+		p1.Hand = []*Card{{ID: "x1", Type: TypeAntiMissile}}
+
+		if err := g.TakeTurn(p1.ID, []PlayCardAction{{CardID: "x1", Location: "deterrent_1"}}, ""); err != nil {
+			t.Fatalf("TakeTurn failed unexpectedly: %v", err)
+		}
+		if p1.Placemat.Deterrent1 == nil || g.PlayerOrder[g.CurrentPlayerIndex] != p2.ID {
+			t.Error("expected the deterrent to be placed and the turn passed")
+		}
+	})
+
+	t.Run("a failing step leaves the game untouched", func(t *testing.T) {
+		g, p1, p2 := turnGame()
+		p1.Hand = []*Card{
+			{ID: "prop", Type: TypePropaganda, Name: "Propaganda"},
+			{ID: "d1", Type: TypeDeliverySystem, Name: "ICBM"},
+		}
+		before, log := g.CurrentVersion(), len(g.TurnLog)
+		p1Population, p2Population := p1.Population, p2.Population
+
+		// The attack has no warhead, so it fails after both plays succeed.
+		plays := []PlayCardAction{
+			{CardID: "prop", Location: "face_up", TargetID: p2.ID},
+			{CardID: "d1", Location: "face_up"},
+		}
+		err := g.TakeTurn(p1.ID, plays, p2.ID)
+		if !errors.Is(err, ErrNotArmed) {
+			t.Fatalf("expected ErrNotArmed, but got %v", err)
+		}
+		var e *Error
+		if !errors.As(err, &e) || e.Details["step"] != "2" {
+			t.Errorf("expected the error to name step 2, but got %+v", e)
+		}
+
+		if len(p1.Hand) != 2 || len(p1.Placemat.ActiveCards) != 0 {
+			t.Errorf("expected the plays to be undone, but the hand holds %d and %d cards are face up", len(p1.Hand), len(p1.Placemat.ActiveCards))
+		}
+		if p1.Population != p1Population || p2.Population != p2Population {
+			t.Error("expected the Propaganda to be undone")
+		}
+		if len(g.DiscardPile) != 0 {
+			t.Errorf("expected the discard pile to be restored, but it holds %d cards", len(g.DiscardPile))
+		}
+		if g.CurrentVersion() != before || len(g.TurnLog) != log {
+			t.Error("expected no version bump or log entries for a rejected turn")
+		}
+		if g.PlayerOrder[g.CurrentPlayerIndex] != p1.ID || g.State != StateInProgress {
+			t.Error("expected it to still be player 1's turn")
+		}
+	})
+
+	t.Run("ends where Propaganda eliminates its target", func(t *testing.T) {
+		g, p1, p2 := turnGame()
+		p2.Population = propagandaSteal
+		p1.Hand = []*Card{
+			{ID: "prop", Type: TypePropaganda, Name: "Propaganda"},
+			{ID: "x1", Type: TypeAntiMissile},
+		}
+
+		plays := []PlayCardAction{
+			{CardID: "prop", Location: "face_up", TargetID: p2.ID},
+			{CardID: "x1", Location: "deterrent_1"},
+		}
+		if err := g.TakeTurn(p1.ID, plays, ""); err != nil {
+			t.Fatalf("TakeTurn failed unexpectedly: %v", err)
+		}
+		if !p2.IsEliminated || g.State != StateFinalStrike || g.PlayerOrder[g.CurrentPlayerIndex] != p2.ID {
+			t.Errorf("expected player 2's Final Strike, but the game is %s on %s", g.State, g.PlayerOrder[g.CurrentPlayerIndex])
+		}
+		if len(p1.Hand) != 1 || p1.Placemat.Deterrent1 != nil {
+			t.Error("expected the plays after the elimination to be left out")
+		}
+	})
+
+	t.Run("is rejected out of turn", func(t *testing.T) {
+		g, _, p2 := turnGame()
+		if err := g.TakeTurn(p2.ID, nil, ""); !errors.Is(err, ErrNotYourTurn) {
+			t.Errorf("expected ErrNotYourTurn, but got %v", err)
+		}
+	})
+}
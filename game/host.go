@@ -0,0 +1,200 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IsHost checks if the player is the game's host.
+func (g *Game) IsHost(playerID string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return playerID != "" && g.HostID == playerID
+}
+
+// unreadyPlayers returns the names of the seated players, other than the host,
+// who are not ready to start.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) unreadyPlayers() []string {
+	names := []string{}
+	for _, id := range g.PlayerOrder {
+		if p := g.Players[id]; id != g.HostID && !p.IsReady {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// ReadyAction marks a player as ready, or no longer ready, to start the game.
+// The host does not ready up: starting the game is their signal.
+type ReadyAction struct {
+	PlayerID string
+	Ready    bool
+}
+
+// Type implements Action.
+func (a ReadyAction) Type() ActionType { return ActionReady }
+
+// Actor implements Action.
+func (a ReadyAction) Actor() string { return a.PlayerID }
+
+// Validate implements Action.
+func (a ReadyAction) Validate(g *Game) error {
+	if a.PlayerID == g.HostID {
+		return newError(ErrInvalidTarget, "the host does not need to ready up", "playerID", a.PlayerID)
+	}
+	return nil
+}
+
+// Apply implements Action.
+func (a ReadyAction) Apply(g *Game) error {
+	player := g.Players[a.PlayerID]
+	player.IsReady = a.Ready
+	if a.Ready {
+		g.emit(EventPlayerReady, player.ID, "", "Player %s is ready.", player.Name)
+	} else {
+		g.emit(EventPlayerReady, player.ID, "", "Player %s is no longer ready.", player.Name)
+	}
+	return nil
+}
+
+// SetReady marks a player as ready, or no longer ready, to start the game.
+func (g *Game) SetReady(playerID string, ready bool) error {
+	return g.Apply(ReadyAction{PlayerID: playerID, Ready: ready})
+}
+
+// KickPlayerAction removes a player from a game that has not started yet.
+// The kicked player's tokens stop working.
+type KickPlayerAction struct {
+	PlayerID string // The host
+	TargetID string
+}
+
+// Type implements Action.
+func (a KickPlayerAction) Type() ActionType { return ActionKick }
+
+// Actor implements Action.
+func (a KickPlayerAction) Actor() string { return a.PlayerID }
+
+// Validate implements Action.
+func (a KickPlayerAction) Validate(g *Game) error {
+	if _, ok := g.Players[a.TargetID]; !ok {
+		return newError(ErrPlayerNotFound, fmt.Sprintf("player with ID %s not found", a.TargetID), "playerID", a.TargetID)
+	}
+	if a.TargetID == g.HostID {
+		return newError(ErrInvalidTarget, "the host cannot be kicked", "playerID", a.TargetID)
+	}
+	return nil
+}
+
+// Apply implements Action.
+func (a KickPlayerAction) Apply(g *Game) error {
+	target := g.Players[a.TargetID]
+	g.removePlayer(target.ID)
+	g.emit(EventPlayerKicked, target.ID, "", "Player %s was removed from the game by the host.", target.Name)
+	return nil
+}
+
+// Kick removes a player from the game on the host's behalf.
+func (g *Game) Kick(hostID, targetID string) error {
+	return g.Apply(KickPlayerAction{PlayerID: hostID, TargetID: targetID})
+}
+
+// removePlayer takes a player out of the game and revokes their tokens.
+// If the player was the host, the next player in seating order takes over.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) removePlayer(playerID string) {
+	delete(g.Players, playerID)
+	delete(g.lastSeen, playerID)
+	for i, id := range g.PlayerOrder {
+		if id == playerID {
+			g.PlayerOrder = append(g.PlayerOrder[:i], g.PlayerOrder[i+1:]...)
+			break
+		}
+	}
+	for token, id := range g.playerTokens {
+		if id == playerID {
+			delete(g.playerTokens, token)
+		}
+	}
+	if g.HostID == playerID {
+		g.HostID = ""
+		if len(g.PlayerOrder) > 0 {
+			g.HostID = g.PlayerOrder[0]
+		}
+	}
+}
+
+// TransferHostAction hands the host role to another seated player.
+type TransferHostAction struct {
+	PlayerID string // The current host
+	TargetID string
+}
+
+// Type implements Action.
+func (a TransferHostAction) Type() ActionType { return ActionTransferHost }
+
+// Actor implements Action.
+func (a TransferHostAction) Actor() string { return a.PlayerID }
+
+// Validate implements Action.
+func (a TransferHostAction) Validate(g *Game) error {
+	if _, ok := g.Players[a.TargetID]; !ok {
+		return newError(ErrPlayerNotFound, fmt.Sprintf("player with ID %s not found", a.TargetID), "playerID", a.TargetID)
+	}
+	if a.TargetID == g.HostID {
+		return newError(ErrInvalidTarget, fmt.Sprintf("player %s is already the host", g.Players[a.TargetID].Name), "playerID", a.TargetID)
+	}
+	return nil
+}
+
+// Apply implements Action.
+func (a TransferHostAction) Apply(g *Game) error {
+	target := g.Players[a.TargetID]
+	g.HostID = target.ID
+	g.emit(EventHostChanged, target.ID, "", "Player %s is now the host.", target.Name)
+	return nil
+}
+
+// TransferHost makes another player the host.
+func (g *Game) TransferHost(hostID, targetID string) error {
+	return g.Apply(TransferHostAction{PlayerID: hostID, TargetID: targetID})
+}
+
+// UpdateOptionsAction replaces the game's options before it starts.
+// Everyone has to ready up again, since they agreed to the old settings.
+type UpdateOptionsAction struct {
+	PlayerID string // The host
+	Options  GameOptions
+}
+
+// Type implements Action.
+func (a UpdateOptionsAction) Type() ActionType { return ActionUpdateOptions }
+
+// Actor implements Action.
+func (a UpdateOptionsAction) Actor() string { return a.PlayerID }
+
+// Validate implements Action.
+func (a UpdateOptionsAction) Validate(g *Game) error {
+	return a.Options.Validate()
+}
+
+// Apply implements Action.
+func (a UpdateOptionsAction) Apply(g *Game) error {
+	g.Options = a.Options
+	for _, p := range g.Players {
+		p.IsReady = false
+	}
+	g.emit(EventOptionsUpdated, a.PlayerID, "", "The host changed the game options. Everyone needs to ready up again.")
+	return nil
+}
+
+// UpdateOptions changes the game options on the host's behalf.
+func (g *Game) UpdateOptions(hostID string, opts GameOptions) error {
+	return g.Apply(UpdateOptionsAction{PlayerID: hostID, Options: opts})
+}
+
+// notReadyError describes the players a start is waiting for.
+func notReadyError(names []string) error {
+	return newError(ErrNotReady, fmt.Sprintf("waiting for players to ready up: %s", strings.Join(names, ", ")), "players", strings.Join(names, ","))
+}
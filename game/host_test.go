@@ -0,0 +1,106 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestHost(t *testing.T) {
+	setup := func() (*Game, *Player, *Player) {
+		g := NewGame()
+		host, _ := g.AddPlayer("Host")
+		guest, _ := g.AddPlayer("Guest")
+		return g, host, guest
+	}
+
+	t.Run("the first player to join is the host", func(t *testing.T) {
+		g, host, _ := setup()
+		if g.HostID != host.ID {
+			t.Errorf("expected host to be %s, but got %s", host.ID, g.HostID)
+		}
+		if g.Summary().Host != "Host" {
+			t.Errorf("expected the summary to name the host, but got '%s'", g.Summary().Host)
+		}
+	})
+
+	t.Run("start requires the host and a ready table", func(t *testing.T) {
+		g, host, guest := setup()
+		if err := g.Apply(StartGameAction{PlayerID: guest.ID}); err == nil {
+			t.Error("expected an error when a guest starts the game, but got nil")
+		}
+		if err := g.Apply(StartGameAction{PlayerID: host.ID}); err == nil {
+			t.Error("expected an error when starting before everyone is ready, but got nil")
+		}
+		if err := g.SetReady(guest.ID, true); err != nil {
+			t.Fatalf("SetReady failed unexpectedly: %v", err)
+		}
+		if err := g.Apply(StartGameAction{PlayerID: host.ID}); err != nil {
+			t.Fatalf("expected the host to start a ready game, but got: %v", err)
+		}
+		if g.State != StateOpeningRound {
+			t.Errorf("expected game state to be '%s', but got '%s'", StateOpeningRound, g.State)
+		}
+	})
+
+	t.Run("kick removes the player and revokes their token", func(t *testing.T) {
+		g, host, guest := setup()
+		token, _ := g.IssuePlayerToken(guest.ID)
+
+		if err := g.Kick(guest.ID, host.ID); err == nil {
+			t.Error("expected an error when a guest kicks the host, but got nil")
+		}
+		if err := g.Kick(host.ID, guest.ID); err != nil {
+			t.Fatalf("Kick failed unexpectedly: %v", err)
+		}
+		if g.HasPlayer(guest.ID) || len(g.PlayerOrder) != 1 {
+			t.Error("expected the kicked player to be removed")
+		}
+		if _, ok := g.PlayerForToken(token); ok {
+			t.Error("expected the kicked player's token to stop working")
+		}
+	})
+
+	t.Run("transfer host", func(t *testing.T) {
+		g, host, guest := setup()
+		if err := g.TransferHost(host.ID, guest.ID); err != nil {
+			t.Fatalf("TransferHost failed unexpectedly: %v", err)
+		}
+		if !g.IsHost(guest.ID) || g.IsHost(host.ID) {
+			t.Error("expected the guest to be the new host")
+		}
+		if err := g.Kick(host.ID, guest.ID); err == nil {
+			t.Error("expected the former host to lose host actions, but got nil")
+		}
+	})
+
+	t.Run("updating options resets readiness", func(t *testing.T) {
+		g, host, guest := setup()
+		g.SetReady(guest.ID, true)
+
+		if err := g.UpdateOptions(host.ID, GameOptions{SpectatorDelaySeconds: -1}); err == nil {
+			t.Error("expected an error for invalid options, but got nil")
+		}
+		if err := g.UpdateOptions(host.ID, GameOptions{SpectatorDelaySeconds: 30}); err != nil {
+			t.Fatalf("UpdateOptions failed unexpectedly: %v", err)
+		}
+		if g.Options.SpectatorDelaySeconds != 30 {
+			t.Errorf("expected the spectator delay to be 30, but got %d", g.Options.SpectatorDelaySeconds)
+		}
+		if guest.IsReady {
+			t.Error("expected the guest to have to ready up again")
+		}
+	})
+
+	t.Run("start is only offered once everyone is ready", func(t *testing.T) {
+		g, host, guest := setup()
+		if hasCommand(g.getAvailableCommands(host.ID), "start") {
+			t.Error("expected start not to be offered before everyone is ready")
+		}
+		if hasCommand(g.getAvailableCommands(guest.ID), "start") {
+			t.Error("expected start never to be offered to a guest")
+		}
+		g.SetReady(guest.ID, true)
+		if !hasCommand(g.getAvailableCommands(host.ID), "start") {
+			t.Error("expected start to be offered to the host once everyone is ready")
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// joinCodeAlphabet leaves out letters that are easily confused when read
+// aloud or typed: I, L and O.
+const joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// JoinCodeLength is the number of letters in a join code.
+const JoinCodeLength = 6
+
+// NewJoinCode returns a random join code.
+func NewJoinCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(joinCodeAlphabet)))
+	for i := 0; i < JoinCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("could not generate join code: %w", err)
+		}
+		b.WriteByte(joinCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// NormalizeJoinCode turns a join code as typed by a player into its canonical
+// form, ignoring case, spaces and dashes.
+func NormalizeJoinCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// SetJoinCode sets the code players can use to join the game.
+// Join codes are only valid until the game starts.
+func (g *Game) SetJoinCode(code string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.JoinCode = code
+}
+
+// HasJoinCode checks if code is the game's current join code.
+func (g *Game) HasJoinCode(code string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.JoinCode != "" && g.JoinCode == code && g.State == StateWaitingForPlayers
+}
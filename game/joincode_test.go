@@ -0,0 +1,50 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewJoinCode(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code, err := NewJoinCode()
+		if err != nil {
+			t.Fatalf("NewJoinCode failed unexpectedly: %v", err)
+		}
+		if len(code) != JoinCodeLength {
+			t.Fatalf("expected a code of length %d, but got '%s'", JoinCodeLength, code)
+		}
+		if strings.ContainsAny(code, "ILO0123456789") {
+			t.Fatalf("expected no confusable characters, but got '%s'", code)
+		}
+	}
+}
+
+func TestNormalizeJoinCode(t *testing.T) {
+	if got := NormalizeJoinCode("abc-def"); got != "ABCDEF" {
+		t.Errorf("expected 'ABCDEF', but got '%s'", got)
+	}
+	if got := NormalizeJoinCode(" abc def "); got != "ABCDEF" {
+		t.Errorf("expected 'ABCDEF', but got '%s'", got)
+	}
+}
+
+func TestJoinCode_ExpiresOnStart(t *testing.T) {
+	g := NewGame()
+	g.SetJoinCode("ABCDEF")
+	g.AddPlayer("Player 1")
+	g.AddPlayer("Player 2")
+
+	if !g.HasJoinCode("ABCDEF") {
+		t.Fatal("expected the join code to be valid before the game starts")
+	}
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("StartGame failed unexpectedly: %v", err)
+	}
+	if g.HasJoinCode("ABCDEF") {
+		t.Error("expected the join code to expire when the game starts")
+	}
+	if code := g.Summary().JoinCode; code != "" {
+		t.Errorf("expected no join code in the summary of a started game, but got '%s'", code)
+	}
+}
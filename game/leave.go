@@ -0,0 +1,170 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// ForfeitPopulation rules decide what happens to a forfeiting player's population.
+const (
+	ForfeitToBank  = "bank"    // Returned to the population bank (the default)
+	ForfeitSplit   = "split"   // Shared evenly among the surviving players
+	ForfeitDestroy = "destroy" // Removed from the game
+)
+
+// DisconnectAfter is how long a player may go without making a request before
+// they are shown as disconnected.
+const DisconnectAfter = 30 * time.Second
+
+// LeaveAction takes a player out of a game that has not started yet.
+// If the host leaves, the next player in seating order becomes host.
+type LeaveAction struct {
+	PlayerID string
+}
+
+// Type implements Action.
+func (a LeaveAction) Type() ActionType { return ActionLeave }
+
+// Actor implements Action.
+func (a LeaveAction) Actor() string { return a.PlayerID }
+
+// Validate implements Action.
+func (a LeaveAction) Validate(g *Game) error { return nil }
+
+// Apply implements Action.
+func (a LeaveAction) Apply(g *Game) error {
+	player := g.Players[a.PlayerID]
+	wasHost := player.ID == g.HostID
+	g.removePlayer(player.ID)
+	g.emit(EventPlayerLeft, player.ID, "", "Player %s left the game.", player.Name)
+	if host, ok := g.Players[g.HostID]; ok && wasHost {
+		g.emit(EventHostChanged, host.ID, "", "Player %s is now the host.", host.Name)
+	}
+	return nil
+}
+
+// Leave takes a player out of a game that has not started yet.
+func (g *Game) Leave(playerID string) error {
+	return g.Apply(LeaveAction{PlayerID: playerID})
+}
+
+// ForfeitAction concedes the game. The player is eliminated without a Final
+// Strike, their cards go to the discard pile and their population is handled
+// according to the game's forfeit rule. A Final Strike that is underway is
+// resolved before anyone can forfeit.
+type ForfeitAction struct {
+	PlayerID string
+}
+
+// Type implements Action.
+func (a ForfeitAction) Type() ActionType { return ActionForfeit }
+
+// Actor implements Action.
+func (a ForfeitAction) Actor() string { return a.PlayerID }
+
+// Validate implements Action.
+func (a ForfeitAction) Validate(g *Game) error {
+	if g.Players[a.PlayerID].IsEliminated {
+		return newError(ErrPlayerEliminated, fmt.Sprintf("player %s is already eliminated; pass to decline the Final Strike", g.Players[a.PlayerID].Name), "playerID", a.PlayerID)
+	}
+	return nil
+}
+
+// Apply implements Action.
+func (a ForfeitAction) Apply(g *Game) error {
+	player := g.Players[a.PlayerID]
+	_, currentID := g.currentTurn()
+
+	g.discardAll(player)
+	g.redistributePopulation(player)
+	player.IsEliminated = true
+	g.emit(EventPlayerForfeited, player.ID, "", "Player %s has forfeited the game.", player.Name)
+
+	if err := g.checkForWinner(); err != nil {
+		return err
+	}
+	if g.State == StateGameOver {
+		return nil
+	}
+
+	// The forfeiting player may have been the last one the opening round was
+	// waiting for.
+	if g.State == StateOpeningRound && g.openingRoundComplete() {
+		g.ResolveOpeningSecrets()
+		if err := g.setState(StateInProgress); err != nil {
+			return err
+		}
+	}
+
+	if currentID == player.ID {
+		g.AdvanceTurn()
+	}
+	return nil
+}
+
+// Forfeit concedes the game on a player's behalf.
+func (g *Game) Forfeit(playerID string) error {
+	return g.Apply(ForfeitAction{PlayerID: playerID})
+}
+
+// discardAll moves every card in a player's hand and on their placemat to the
+// discard pile.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) discardAll(p *Player) {
+	g.DiscardPile = append(g.DiscardPile, p.Hand...)
+	g.DiscardPile = append(g.DiscardPile, p.Placemat.ActiveCards...)
+	for _, card := range []*Card{p.Placemat.FaceDownCard1, p.Placemat.FaceDownCard2, p.Placemat.Deterrent1, p.Placemat.Deterrent2} {
+		if card != nil {
+			g.DiscardPile = append(g.DiscardPile, card)
+		}
+	}
+	p.Hand = []*Card{}
+	p.Placemat = Placemat{}
+}
+
+// redistributePopulation empties a forfeiting player's population according to
+// the game's forfeit rule.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) redistributePopulation(p *Player) {
+	population := p.Population
+	p.Population = 0
+
+	switch g.Options.ForfeitPopulation {
+	case ForfeitDestroy:
+	case ForfeitSplit:
+		survivors := []*Player{}
+		for _, id := range g.PlayerOrder {
+			if other := g.Players[id]; other.ID != p.ID && !other.IsEliminated {
+				survivors = append(survivors, other)
+			}
+		}
+		if len(survivors) == 0 {
+			g.PopulationBank += population
+			return
+		}
+		share := population / int64(len(survivors))
+		for _, other := range survivors {
+			other.Population += share
+		}
+		// Whatever does not divide evenly goes back to the bank.
+		g.PopulationBank += population - share*int64(len(survivors))
+	default:
+		g.PopulationBank += population
+	}
+}
+
+// MarkSeen records that a player made a request at the given time.
+func (g *Game) MarkSeen(playerID string, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.Players[playerID]; ok {
+		g.lastSeen[playerID] = now
+	}
+}
+
+// isConnected reports whether a player has made a request recently.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) isConnected(playerID string, now time.Time) bool {
+	seen, ok := g.lastSeen[playerID]
+	return ok && now.Sub(seen) < DisconnectAfter
+}
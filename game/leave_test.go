@@ -0,0 +1,144 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeave(t *testing.T) {
+	g := NewGame()
+	host, _ := g.AddPlayer("Host")
+	guest, _ := g.AddPlayer("Guest")
+
+	if err := g.Leave(host.ID); err != nil {
+		t.Fatalf("Leave failed unexpectedly: %v", err)
+	}
+	if g.HasPlayer(host.ID) {
+		t.Error("expected the player to be removed")
+	}
+	if g.HostID != guest.ID {
+		t.Errorf("expected the host role to pass to %s, but got %s", guest.ID, g.HostID)
+	}
+
+	g.AddPlayer("Player 3")
+	g.SetReady(g.PlayerOrder[1], true)
+	g.StartGame()
+	if err := g.Leave(guest.ID); err == nil {
+		t.Error("expected an error when leaving a started game, but got nil")
+	}
+}
+
+func TestForfeit(t *testing.T) {
+	setup := func(rule string) (*Game, *Player, *Player, *Player) {
+		g := NewGame()
+		g.Options.ForfeitPopulation = rule
+		p1, _ := g.AddPlayer("Player 1")
+		p2, _ := g.AddPlayer("Player 2")
+		p3, _ := g.AddPlayer("Player 3")
+		for _, p := range []*Player{p1, p2, p3} {
+			p.Population = 10000000
+		}
+		p2.Population = 10000001
+		g.State = StateInProgress
+		return g, p1, p2, p3
+	}
+
+	t.Run("eliminates the player and discards their cards", func(t *testing.T) {
+		g, p1, p2, _ := setup("")
+		p2.Hand = []*Card{{ID: "h1"}}
+		p2.Placemat.ActiveCards = []*Card{{ID: "a1"}}
+		p2.Placemat.FaceDownCard2 = &Card{ID: "f2"}
+		bank := g.PopulationBank
+
+		if err := g.Forfeit(p2.ID); err != nil {
+			t.Fatalf("Forfeit failed unexpectedly: %v", err)
+		}
+		if !p2.IsEliminated || p2.Population != 0 {
+			t.Error("expected the player to be eliminated with no population")
+		}
+		if g.State != StateInProgress {
+			t.Errorf("expected no Final Strike, but the game is in state '%s'", g.State)
+		}
+		if len(p2.Hand) != 0 || len(g.DiscardPile) != 3 {
+			t.Errorf("expected 3 cards in the discard pile, but got %d", len(g.DiscardPile))
+		}
+		if g.PopulationBank != bank+10000001 {
+			t.Errorf("expected the population to go to the bank, but the bank has %d", g.PopulationBank)
+		}
+		if g.CurrentPlayerIndex != 0 || p1.IsEliminated {
+			t.Error("expected the turn to stay with player 1")
+		}
+	})
+
+	t.Run("the current player's turn moves on", func(t *testing.T) {
+		g, p1, _, _ := setup("")
+		if err := g.Forfeit(p1.ID); err != nil {
+			t.Fatalf("Forfeit failed unexpectedly: %v", err)
+		}
+		if g.CurrentPlayerIndex != 1 {
+			t.Errorf("expected CurrentPlayerIndex to be 1, but got %d", g.CurrentPlayerIndex)
+		}
+	})
+
+	t.Run("split shares the population among survivors", func(t *testing.T) {
+		g, p1, p2, p3 := setup(ForfeitSplit)
+		bank := g.PopulationBank
+		g.Forfeit(p2.ID)
+		if p1.Population != 15000000 || p3.Population != 15000000 {
+			t.Errorf("expected each survivor to gain 5000000, but got %d and %d", p1.Population, p3.Population)
+		}
+		if g.PopulationBank != bank+1 {
+			t.Errorf("expected the remainder to go to the bank, but the bank has %d", g.PopulationBank)
+		}
+	})
+
+	t.Run("destroy removes the population", func(t *testing.T) {
+		g, _, p2, _ := setup(ForfeitDestroy)
+		bank := g.PopulationBank
+		g.Forfeit(p2.ID)
+		if g.PopulationBank != bank {
+			t.Errorf("expected the bank to be unchanged, but got %d", g.PopulationBank)
+		}
+	})
+
+	t.Run("the last player standing wins", func(t *testing.T) {
+		g, p1, p2, p3 := setup("")
+		g.Forfeit(p2.ID)
+		g.Forfeit(p3.ID)
+		if g.State != StateGameOver || g.Winner != p1 {
+			t.Errorf("expected player 1 to win, but the game is in state '%s'", g.State)
+		}
+	})
+
+	t.Run("completes the opening round", func(t *testing.T) {
+		g, p1, p2, p3 := setup("")
+		g.State = StateOpeningRound
+		p1.Placemat.FaceDownCard1 = &Card{ID: "s1", Type: TypeSecret}
+		p3.Placemat.FaceDownCard1 = &Card{ID: "s3", Type: TypeSecret}
+
+		if err := g.Forfeit(p2.ID); err != nil {
+			t.Fatalf("Forfeit failed unexpectedly: %v", err)
+		}
+		if g.State != StateInProgress {
+			t.Errorf("expected game state to be '%s', but got '%s'", StateInProgress, g.State)
+		}
+	})
+}
+
+func TestMarkSeen(t *testing.T) {
+	g := NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+
+	g.MarkSeen(p1.ID, time.Now())
+	view := g.NewPlayerView(p2.ID)
+	if !view.Opponents[0].Connected {
+		t.Error("expected a player who was just seen to be connected")
+	}
+
+	g.MarkSeen(p1.ID, time.Now().Add(-2*DisconnectAfter))
+	view = g.NewPlayerView(p2.ID)
+	if view.Opponents[0].Connected {
+		t.Error("expected a player who has not been seen for a while to be disconnected")
+	}
+}
@@ -0,0 +1,149 @@
+package game
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"time"
+)
+
+// Visibility controls whether a game is listed in the lobby.
+type Visibility string
+
+const (
+	VisibilityPublic  Visibility = "public"
+	VisibilityPrivate Visibility = "private" // Not listed; joined by ID only
+)
+
+// MaxGameNameLength is the longest display name a game may have.
+const MaxGameNameLength = 64
+
+// MaxPlayerNameLength is the longest name a player may have.
+const MaxPlayerNameLength = 64
+
+// passwordIterations is the PBKDF2 work factor for join passwords, as
+// recommended by OWASP for HMAC-SHA256.
+const passwordIterations = 600_000
+
+// LobbySettings describes how a game is presented in the lobby.
+type LobbySettings struct {
+	Name       string
+	Visibility Visibility
+	Password   string // Optional; required to join when set
+}
+
+// GameSummary is the lobby listing for a game. It only contains information
+// that anyone browsing the lobby may see.
+type GameSummary struct {
+	ID          string      `json:"gameID"`
+	JoinCode    string      `json:"joinCode,omitempty"`
+	Name        string      `json:"name"`
+	Host        string      `json:"host"`
+	State       GameState   `json:"state"`
+	Players     int         `json:"players"`
+	MaxPlayers  int         `json:"maxPlayers"`
+	SeatsFree   int         `json:"seatsFree"`
+	Visibility  Visibility  `json:"visibility"`
+	HasPassword bool        `json:"hasPassword"`
+	Options     GameOptions `json:"options"`
+	CreatedAt   time.Time   `json:"createdAt"`
+}
+
+// SetLobbySettings sets the game's display name, visibility and join password.
+func (g *Game) SetLobbySettings(settings LobbySettings) error {
+	if len(settings.Name) > MaxGameNameLength {
+		return newError(ErrInvalidName, fmt.Sprintf("game name cannot be longer than %d characters", MaxGameNameLength), "field", "name")
+	}
+	switch settings.Visibility {
+	case "":
+		settings.Visibility = VisibilityPublic
+	case VisibilityPublic, VisibilityPrivate:
+	default:
+		return newError(ErrInvalidOptions, fmt.Sprintf("invalid visibility: %s", settings.Visibility), "field", "visibility")
+	}
+
+	// Hashing is deliberately slow, so it is done before taking the lock.
+	var salt, hash []byte
+	if settings.Password != "" {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("could not generate password salt: %w", err)
+		}
+		var err error
+		if hash, err = hashPassword(salt, settings.Password, passwordIterations); err != nil {
+			return err
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if settings.Name != "" {
+		g.Name = settings.Name
+	}
+	g.Visibility = settings.Visibility
+	g.passwordSalt, g.passwordHash = salt, hash
+	g.passwordIterations = passwordIterations
+	return nil
+}
+
+// hashPassword returns the PBKDF2 hash of a join password. Games saved before
+// passwords were stretched have no iteration count, and keep the single
+// salted SHA-256 they were hashed with until their password is next set.
+func hashPassword(salt []byte, password string, iterations int) ([]byte, error) {
+	if iterations == 0 {
+		h := sha256.New()
+		h.Write(salt)
+		h.Write([]byte(password))
+		return h.Sum(nil), nil
+	}
+	hash, err := pbkdf2.Key(sha256.New, password, salt, iterations, sha256.Size)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash password: %w", err)
+	}
+	return hash, nil
+}
+
+// CheckPassword checks a join password. Games without a password accept any.
+func (g *Game) CheckPassword(password string) bool {
+	g.mu.RLock()
+	salt, want, iterations := g.passwordSalt, g.passwordHash, g.passwordIterations
+	g.mu.RUnlock()
+	if want == nil {
+		return true
+	}
+	got, err := hashPassword(salt, password, iterations)
+	return err == nil && subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// Summary returns the game's lobby listing.
+func (g *Game) Summary() GameSummary {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var host string
+	if p, ok := g.Players[g.HostID]; ok {
+		host = p.Name
+	}
+
+	seatsFree := 0
+	if g.State == StateWaitingForPlayers {
+		seatsFree = MaxPlayers - len(g.Players)
+	}
+
+	return GameSummary{
+		ID:          g.ID,
+		JoinCode:    g.JoinCode,
+		Name:        g.Name,
+		Host:        host,
+		State:       g.State,
+		Players:     len(g.Players),
+		MaxPlayers:  MaxPlayers,
+		SeatsFree:   seatsFree,
+		Visibility:  g.Visibility,
+		HasPassword: g.passwordHash != nil,
+		Options:     g.Options,
+		CreatedAt:   g.CreatedAt,
+	}
+}
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSetLobbySettings(t *testing.T) {
+	t.Run("sets name, visibility and password", func(t *testing.T) {
+		g := NewGame()
+		err := g.SetLobbySettings(LobbySettings{Name: "Friday Night", Visibility: VisibilityPrivate, Password: "hunter2"})
+		if err != nil {
+			t.Fatalf("SetLobbySettings failed unexpectedly: %v", err)
+		}
+
+		summary := g.Summary()
+		if summary.Name != "Friday Night" {
+			t.Errorf("expected name to be 'Friday Night', but got '%s'", summary.Name)
+		}
+		if summary.Visibility != VisibilityPrivate {
+			t.Errorf("expected visibility to be '%s', but got '%s'", VisibilityPrivate, summary.Visibility)
+		}
+		if !summary.HasPassword {
+			t.Error("expected the summary to report a password")
+		}
+		if g.CheckPassword("wrong") {
+			t.Error("expected the wrong password to be rejected")
+		}
+		if !g.CheckPassword("hunter2") {
+			t.Error("expected the right password to be accepted")
+		}
+	})
+
+	t.Run("the password is stretched", func(t *testing.T) {
+		g := NewGame()
+		if err := g.SetLobbySettings(LobbySettings{Password: "hunter2"}); err != nil {
+			t.Fatalf("SetLobbySettings failed unexpectedly: %v", err)
+		}
+		if g.passwordIterations != passwordIterations {
+			t.Errorf("expected %d iterations, but got %d", passwordIterations, g.passwordIterations)
+		}
+		legacy, _ := hashPassword(g.passwordSalt, "hunter2", 0)
+		if bytes.Equal(g.passwordHash, legacy) {
+			t.Error("expected more than a single salted hash")
+		}
+	})
+
+	t.Run("legacy hashes are still checked", func(t *testing.T) {
+		g := NewGame()
+		g.passwordSalt = []byte("salt")
+		g.passwordHash, _ = hashPassword(g.passwordSalt, "hunter2", 0)
+		if !g.CheckPassword("hunter2") || g.CheckPassword("wrong") {
+			t.Error("expected a legacy hash to be checked as before")
+		}
+	})
+
+	t.Run("games without a password accept anyone", func(t *testing.T) {
+		g := NewGame()
+		if !g.CheckPassword("") {
+			t.Error("expected an empty password to be accepted")
+		}
+		if g.Summary().Visibility != VisibilityPublic {
+			t.Errorf("expected new games to be public")
+		}
+	})
+
+	t.Run("rejects invalid settings", func(t *testing.T) {
+		g := NewGame()
+		if err := g.SetLobbySettings(LobbySettings{Visibility: "secret"}); err == nil {
+			t.Error("expected an error for an invalid visibility, but got nil")
+		}
+	})
+}
+
+func TestSummary_SeatsFree(t *testing.T) {
+	g := NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	_, _ = g.AddPlayer("Player 2")
+
+	summary := g.Summary()
+	if summary.SeatsFree != MaxPlayers-2 {
+		t.Errorf("expected %d free seats, but got %d", MaxPlayers-2, summary.SeatsFree)
+	}
+	if summary.Host != p1.Name {
+		t.Errorf("expected host to be '%s', but got '%s'", p1.Name, summary.Host)
+	}
+
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("StartGame failed unexpectedly: %v", err)
+	}
+	if g.Summary().SeatsFree != 0 {
+		t.Errorf("expected no free seats once the game has started, but got %d", g.Summary().SeatsFree)
+	}
+}
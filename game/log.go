@@ -0,0 +1,74 @@
+package game
+
+import (
+	"context"
+	"log/slog"
+)
+
+// debugKey marks a context whose debug records must be logged whatever the
+// configured level.
+type debugKey struct{}
+
+// WithDebug returns a copy of ctx whose debug records DebugHandler lets
+// through, so a single game can be debugged without flooding the log.
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// DebugHandler wraps h so that records logged with a context from WithDebug
+// are handled even below h's level.
+func DebugHandler(h slog.Handler) slog.Handler {
+	return debugHandler{h}
+}
+
+type debugHandler struct {
+	slog.Handler
+}
+
+func (h debugHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if on, _ := ctx.Value(debugKey{}).(bool); on {
+		return true
+	}
+	return h.Handler.Enabled(ctx, level)
+}
+
+func (h debugHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return debugHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h debugHandler) WithGroup(name string) slog.Handler {
+	return debugHandler{h.Handler.WithGroup(name)}
+}
+
+// SetDebug turns debug logging for the game on or off.
+func (g *Game) SetDebug(on bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.debug = on
+}
+
+// Debug reports whether debug logging is on for the game.
+func (g *Game) Debug() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.debug
+}
+
+// LogContext returns the context to log the game's records with: one that
+// lets its debug records through while debug logging is on for it.
+func (g *Game) LogContext(ctx context.Context) context.Context {
+	if g.Debug() {
+		return WithDebug(ctx)
+	}
+	return ctx
+}
+
+// log writes a record about the game, tagged with its ID.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) log(level slog.Level, msg string, args ...any) {
+	ctx := context.Background()
+	if g.debug {
+		ctx = WithDebug(ctx)
+	}
+	slog.Log(ctx, level, msg, append([]any{"game_id", g.ID}, args...)...)
+}
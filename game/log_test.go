@@ -0,0 +1,35 @@
+package game
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestDebugLogging(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(DebugHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))))
+	defer slog.SetDefault(previous)
+
+	g, p1, p2 := turnGame()
+	g.Apply(PassTurnAction{PlayerID: p1.ID})
+	if buf.Len() != 0 {
+		t.Errorf("expected no debug records at info level, but got:\n%s", buf.String())
+	}
+
+	g.SetDebug(true)
+	g.Apply(PassTurnAction{PlayerID: p2.ID})
+	out := buf.String()
+	if !strings.Contains(out, "level=DEBUG") || !strings.Contains(out, "game_id="+g.ID) || !strings.Contains(out, "event=turn_passed") {
+		t.Errorf("expected the game's events to be logged once debugging is on, but got:\n%s", out)
+	}
+
+	buf.Reset()
+	g.SetDebug(false)
+	g.Apply(PassTurnAction{PlayerID: p1.ID})
+	if buf.Len() != 0 {
+		t.Errorf("expected debug records to stop once debugging is off, but got:\n%s", buf.String())
+	}
+}
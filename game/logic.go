@@ -3,8 +3,11 @@ package game
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"math/rand"
+	"strconv"
 	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -22,12 +25,72 @@ func (g *Game) HasStarted() bool {
 	return g.State != StateWaitingForPlayers
 }
 
+// HasPlayer checks if a player with the given ID has joined the game.
+func (g *Game) HasPlayer(playerID string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.Players[playerID]
+	return ok
+}
+
+// PlayerCount returns the number of players who have joined the game.
+func (g *Game) PlayerCount() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.Players)
+}
+
+// CurrentState returns the state the game is in.
+func (g *Game) CurrentState() GameState {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.State
+}
+
+// CurrentVersion returns the game's version.
+func (g *Game) CurrentVersion() uint64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.Version
+}
+
+// PlayerSnapshot returns a copy of a player that is safe to read without the
+// game lock, and false if the player is not in the game.
+func (g *Game) PlayerSnapshot(playerID string) (Player, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	p, ok := g.Players[playerID]
+	if !ok {
+		return Player{}, false
+	}
+	snapshot := *p
+	snapshot.Hand = copyCards(p.Hand)
+	snapshot.Placemat = p.Placemat.clone()
+	return snapshot, true
+}
+
+// copyCards returns a copy of a slice of cards. Cards themselves are never
+// modified once created, so they can be shared between copies.
+func copyCards(cards []*Card) []*Card {
+	if cards == nil {
+		return nil
+	}
+	return append([]*Card(nil), cards...)
+}
+
+// clone returns a copy of the placemat that does not share slices with it.
+func (p Placemat) clone() Placemat {
+	p.ActiveCards = copyCards(p.ActiveCards)
+	return p
+}
+
 // NewGame creates and initializes a new game.
 func NewGame() *Game {
 	rand.Seed(time.Now().UnixNano())
 
-	popDeck := createPopulationDeck()
-	nuclearDeck := createNuclearWarDeck()
+	deck := CurrentCatalog()
+	popDeck := cards(deck.Population)
+	nuclearDeck := cards(deck.Deck)
 
 	ShuffleCards(popDeck)
 	ShuffleCards(nuclearDeck)
@@ -42,6 +105,9 @@ func NewGame() *Game {
 
 	return &Game{
 		ID:                 gameID,
+		Name:               fmt.Sprintf("Game %s", gameID[:8]),
+		Visibility:         VisibilityPublic,
+		CreatedAt:          time.Now(),
 		Players:            make(map[string]*Player),
 		PlayerOrder:        make([]string, 0, 6),
 		CurrentPlayerIndex: 0,
@@ -50,50 +116,120 @@ func NewGame() *Game {
 		DiscardPile:        make([]*Card, 0),
 		PopulationBank:     totalPopulation,
 		State:              StateWaitingForPlayers,
+		playerTokens:       make(map[string]string),
+		spectatorTokens:    make(map[string]string),
+		spectatorSeen:      make(map[string]time.Time),
+		watchers:           make(map[string]int),
+		lastSeen:           make(map[string]time.Time),
+		subscribers:        make(map[chan struct{}]struct{}),
 	}
 }
 
-// AddPlayer adds a new player to the game.
-func (g *Game) AddPlayer(name string) (*Player, error) {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	if name == "" {
-		return nil, fmt.Errorf("player name cannot be empty")
+// NewGameWithOptions creates a new game with the given settings.
+func NewGameWithOptions(opts GameOptions) (*Game, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	g := NewGame()
+	g.Options = opts
+	return g, nil
+}
+
+// JoinAction seats a new player in a game that has not started yet.
+type JoinAction struct {
+	PlayerID string // ID for the new player, from NewJoinAction
+	Name     string
+}
+
+// NewJoinAction returns the action seating a player called name, with a fresh
+// player ID.
+func NewJoinAction(name string) JoinAction {
+	return JoinAction{PlayerID: uuid.New().String(), Name: name}
+}
+
+// Type implements Action.
+func (a JoinAction) Type() ActionType { return ActionJoin }
+
+// Actor implements Action. The player has no seat until the action succeeds,
+// so the server takes it on their behalf.
+func (a JoinAction) Actor() string { return "" }
+
+// Validate implements Action.
+func (a JoinAction) Validate(g *Game) error {
+	if a.Name == "" {
+		return newError(ErrInvalidName, "player name cannot be empty", "field", "playerName")
+	}
+	if len(a.Name) > MaxPlayerNameLength {
+		return newError(ErrInvalidName, fmt.Sprintf("player name cannot be longer than %d characters", MaxPlayerNameLength), "field", "playerName")
 	}
-	if g.State != StateWaitingForPlayers {
-		return nil, fmt.Errorf("cannot add players, game has already started")
+	if len(g.Players) >= MaxPlayers {
+		return newError(ErrGameFull, fmt.Sprintf("cannot add more than %d players", MaxPlayers))
 	}
-	if len(g.Players) >= 6 {
-		return nil, fmt.Errorf("cannot add more than 6 players")
+	if _, ok := g.Players[a.PlayerID]; ok || a.PlayerID == "" {
+		return newError(ErrInvalidState, "a joining player needs a new player ID; use NewJoinAction", "playerID", a.PlayerID)
 	}
+	return nil
+}
 
-	playerID := uuid.New().String()
+// Apply implements Action.
+func (a JoinAction) Apply(g *Game) error {
 	player := &Player{
-		ID:         playerID,
-		Name:       name,
+		ID:         a.PlayerID,
+		Name:       a.Name,
 		Population: 0,
 		Hand:       make([]*Card, 0),
 		Placemat:   Placemat{},
 		IsActive:   true,
 	}
 
-	g.Players[playerID] = player
-	g.PlayerOrder = append(g.PlayerOrder, playerID)
-
-	return player, nil
+	g.Players[player.ID] = player
+	g.PlayerOrder = append(g.PlayerOrder, player.ID)
+	g.emit(EventPlayerJoined, player.ID, "", "Player %s joined the game.", player.Name)
+	// The game's creator joins first and becomes its host.
+	if g.HostID == "" {
+		g.HostID = player.ID
+	}
+	return nil
 }
 
-// StartGame begins the game, dealing cards to players.
-func (g *Game) StartGame() error {
+// AddPlayer seats a new player called name and returns them.
+func (g *Game) AddPlayer(name string) (*Player, error) {
+	a := NewJoinAction(name)
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	if g.State != StateWaitingForPlayers {
-		return fmt.Errorf("game has already started")
+	if err := g.applyLocked(a, time.Now()); err != nil {
+		return nil, err
 	}
+	return g.Players[a.PlayerID], nil
+}
+
+// StartGameAction deals the opening hands and moves the game into the opening round.
+type StartGameAction struct {
+	PlayerID string // The player starting the game, or "" for the server
+}
+
+// Type implements Action.
+func (a StartGameAction) Type() ActionType { return ActionStartGame }
+
+// Actor implements Action.
+func (a StartGameAction) Actor() string { return a.PlayerID }
+
+// Validate implements Action.
+func (a StartGameAction) Validate(g *Game) error {
 	if len(g.Players) < 2 {
-		return fmt.Errorf("not enough players to start the game (minimum 2)")
+		return newError(ErrNotEnoughPlayers, "not enough players to start the game (minimum 2)", "players", strconv.Itoa(len(g.Players)))
 	}
+	// The server may start a game without waiting for the ready check.
+	if a.PlayerID != "" {
+		if names := g.unreadyPlayers(); len(names) > 0 {
+			return notReadyError(names)
+		}
+	}
+	return nil
+}
 
+// Apply implements Action.
+func (a StartGameAction) Apply(g *Game) error {
 	// Determine number of population cards to deal
 	numPopCards := 0
 	switch len(g.Players) {
@@ -108,19 +244,19 @@ func (g *Game) StartGame() error {
 	case 6:
 		numPopCards = 3
 	}
+	if len(g.PopulationDeck) < numPopCards*len(g.Players) {
+		return newError(ErrInvalidState, "not enough population cards in the deck to deal",
+			"needed", strconv.Itoa(numPopCards*len(g.Players)), "available", strconv.Itoa(len(g.PopulationDeck)))
+	}
 
 	// Deal population cards
 	for _, playerID := range g.PlayerOrder {
 		player := g.Players[playerID]
 		for i := 0; i < numPopCards; i++ {
-			if len(g.PopulationDeck) > 0 {
-				card := g.PopulationDeck[0]
-				g.PopulationDeck = g.PopulationDeck[1:]
-				player.Population += card.Value
-				g.PopulationBank -= card.Value
-			} else {
-				return fmt.Errorf("not enough population cards in the deck to deal")
-			}
+			card := g.PopulationDeck[0]
+			g.PopulationDeck = g.PopulationDeck[1:]
+			player.Population += card.Value
+			g.PopulationBank -= card.Value
 		}
 	}
 
@@ -128,7 +264,7 @@ func (g *Game) StartGame() error {
 	secretCards := make([]*Card, 0)
 	nonSecretCards := make([]*Card, 0)
 	for _, card := range g.Deck {
-		if card.Type == "Secret" {
+		if card.Type == TypeSecret {
 			secretCards = append(secretCards, card)
 		} else {
 			nonSecretCards = append(nonSecretCards, card)
@@ -142,9 +278,6 @@ func (g *Game) StartGame() error {
 	// Reassemble the main deck for drawing
 	g.Deck = nonSecretCards
 
-	// For a 2-player game, each player gets 9 cards total.
-	numCardsToDeal := 9
-
 	// Deal one secret card and the rest from the main deck to each player.
 	for i, playerID := range g.PlayerOrder {
 		player := g.Players[playerID]
@@ -155,46 +288,76 @@ func (g *Game) StartGame() error {
 			player.Hand = append(player.Hand, secretCards[i])
 		} else {
 			// Handle case where there aren't enough secret cards (shouldn't happen with standard deck)
-			fmt.Println("Warning: Not enough secret cards for all players.")
+			g.log(slog.LevelWarn, "not enough secret cards for every player", "player_id", playerID)
 			player.Hand = append(player.Hand, g.drawCard()) // Draw a regular card instead
 		}
 
 		// Deal the remaining cards
-		for j := 1; j < numCardsToDeal; j++ {
+		for j := 1; j < handSize; j++ {
 			player.Hand = append(player.Hand, g.drawCard())
 		}
 	}
 
-	g.State = StateOpeningRound
+	if err := g.setState(StateOpeningRound); err != nil {
+		return err
+	}
+	// The game is no longer open, so its join code is released.
+	g.JoinCode = ""
+	g.emit(EventGameStarted, a.PlayerID, "", "The game has started with %d players. Play your secret cards face down.", len(g.Players))
 	return nil
 }
 
+// StartGame begins the game, dealing cards to players.
+func (g *Game) StartGame() error {
+	return g.Apply(StartGameAction{})
+}
+
 // getAvailableCommands determines the commands available to a player based on the game state.
+// Candidate commands come from the state transition table; each is offered only
+// if the player passes the same turn rules Apply enforces.
 // NOTE: This function assumes a read lock is already held on the game state.
 func (g *Game) getAvailableCommands(playerID string) []Command {
 	commands := []Command{}
 	player, ok := g.Players[playerID]
-	if !ok || player.IsEliminated {
-		return commands // No commands for eliminated or non-existent players
+	if !ok {
+		return commands // No commands for non-existent players
 	}
 
-	switch g.State {
-	case StateWaitingForPlayers:
-		// Any player can start the game if there are enough players.
-		if len(g.Players) >= 2 {
-			commands = append(commands, Command{Name: "start", Description: "Start the game (2+ players required)"})
+	for _, t := range transitions {
+		if t.From != g.State || g.checkRules(t.Action, playerID) != nil {
+			continue
 		}
-	case StateOpeningRound, StateInProgress:
-		// Check if it's the current player's turn
-		if len(g.PlayerOrder) > g.CurrentPlayerIndex && g.PlayerOrder[g.CurrentPlayerIndex] == playerID {
-			commands = append(commands, Command{Name: "play", Description: "Play a card (e.g., play <cardID> <location>)"})
-			commands = append(commands, Command{Name: "pass", Description: "Pass your turn"})
-			// A simple check if an attack is possible.
-			if g.State == StateInProgress {
-				// A more complex check for valid targets/cards would go here
-				commands = append(commands, Command{Name: "attack", Description: "Attack a player (e.g., attack <target_player_id>)"})
+		switch t.Action {
+		case ActionJoin:
+			// Only players who are already seated are offered commands.
+			continue
+		case ActionStartGame:
+			if len(g.Players) < 2 || len(g.unreadyPlayers()) > 0 {
+				continue
+			}
+		case ActionReady:
+			if playerID == g.HostID {
+				continue
+			}
+		case ActionForfeit:
+			if player.IsEliminated {
+				continue
+			}
+		case ActionKick, ActionTransferHost:
+			if len(g.Players) < 2 {
+				continue
 			}
+		case ActionPlayCard:
+			// During the opening round each player plays exactly one secret card.
+			if g.State == StateOpeningRound && player.Placemat.FaceDownCard1 != nil {
+				continue
+			}
+		}
+		cmd := Command{Name: string(t.Action), Description: t.Description}
+		if !g.commandMoves(&cmd, player) {
+			continue
 		}
+		commands = append(commands, cmd)
 	}
 	return commands
 }
@@ -214,47 +377,109 @@ func (g *Game) NewPlayerView(playerID string) *PlayerView {
 		if id == playerID {
 			continue
 		}
-		opponentPlayer := g.Players[id]
-		opponents = append(opponents, Opponent{
-			ID:         opponentPlayer.ID,
-			Name:       opponentPlayer.Name,
-			Population: opponentPlayer.Population,
-			HandSize:   len(opponentPlayer.Hand),
-			Placemat:   &opponentPlayer.Placemat,
-			IsEliminated: opponentPlayer.IsEliminated,
-		})
-	}
-
-	var currentTurnPlayerName string
-	var currentTurnPlayerId string
-	if len(g.PlayerOrder) > 0 && g.CurrentPlayerIndex < len(g.PlayerOrder) {
-		currentTurnPlayerId = g.PlayerOrder[g.CurrentPlayerIndex]
-		if p, ok := g.Players[currentTurnPlayerId]; ok {
-			currentTurnPlayerName = p.Name
-		}
+		opponents = append(opponents, g.publicPlayer(g.Players[id]))
 	}
 
-	var winnerName *string
-	if g.Winner != nil {
-		winnerName = &g.Winner.Name
-	}
+	currentTurnPlayerName, currentTurnPlayerId := g.currentTurn()
+
+	// The view is encoded after the lock is released, so it must not share
+	// any slices with the live game.
+	placemat := self.Placemat.clone()
 
 	return &PlayerView{
 		GameID:              g.ID,
+		Version:             g.Version,
+		JoinCode:            g.JoinCode,
+		PlayerID:            self.ID,
 		PlayerName:          self.Name,
+		HostID:              g.HostID,
+		IsReady:             self.IsReady,
+		TurnDeadline:        g.turnDeadlinePtr(),
 		PlayerPopulation:    self.Population,
-		PlayerHand:          self.Hand,
-		PlayerPlacemat:      &self.Placemat,
+		PlayerHand:          copyCards(self.Hand),
+		PlayerPlacemat:      &placemat,
 		Opponents:           opponents,
 		CurrentTurnPlayer:   currentTurnPlayerName,
 		CurrentTurnPlayerId: currentTurnPlayerId,
 		State:               g.State,
-		Winner:              winnerName,
-		TurnLog:             g.TurnLog,
+		Winner:              g.winnerName(),
+		TurnLog:             append([]string{}, g.TurnLog...),
 		AvailableCommands:   g.getAvailableCommands(playerID),
 	}
 }
 
+// NewSpectatorView creates a view of the game that only contains public
+// information, for clients that are not seated in the game.
+func (g *Game) NewSpectatorView() *SpectatorView {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.spectatorView()
+}
+
+// spectatorView builds the live spectator view.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) spectatorView() *SpectatorView {
+	players := []Opponent{}
+	for _, id := range g.PlayerOrder {
+		players = append(players, g.publicPlayer(g.Players[id]))
+	}
+
+	currentTurnPlayerName, currentTurnPlayerId := g.currentTurn()
+
+	return &SpectatorView{
+		GameID:              g.ID,
+		Version:             g.Version,
+		HostID:              g.HostID,
+		TurnDeadline:        g.turnDeadlinePtr(),
+		Players:             players,
+		CurrentTurnPlayer:   currentTurnPlayerName,
+		CurrentTurnPlayerId: currentTurnPlayerId,
+		State:               g.State,
+		Winner:              g.winnerName(),
+		TurnLog:             append([]string{}, g.TurnLog...),
+		Spectators:          g.watching(),
+	}
+}
+
+// publicPlayer returns the information about a player that everyone may see.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) publicPlayer(p *Player) Opponent {
+	placemat := p.Placemat.clone()
+	return Opponent{
+		ID:           p.ID,
+		Name:         p.Name,
+		Population:   p.Population,
+		HandSize:     len(p.Hand),
+		Placemat:     &placemat,
+		IsEliminated: p.IsEliminated,
+		IsReady:      p.IsReady,
+		Connected:    g.isConnected(p.ID, time.Now()),
+		IsBot:        p.IsBot,
+	}
+}
+
+// currentTurn returns the name and ID of the player whose turn it is.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) currentTurn() (name, id string) {
+	if len(g.PlayerOrder) > 0 && g.CurrentPlayerIndex < len(g.PlayerOrder) {
+		id = g.PlayerOrder[g.CurrentPlayerIndex]
+		if p, ok := g.Players[id]; ok {
+			name = p.Name
+		}
+	}
+	return name, id
+}
+
+// winnerName returns a copy of the winner's name, or nil if there is no winner yet.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) winnerName() *string {
+	if g.Winner == nil {
+		return nil
+	}
+	name := g.Winner.Name
+	return &name
+}
+
 // drawCard removes and returns the top card from the deck.
 // This is an internal function and assumes a lock is already held.
 func (g *Game) drawCard() *Card {
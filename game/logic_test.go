@@ -1,6 +1,8 @@
 package game
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -69,6 +71,42 @@ func TestAddPlayer(t *testing.T) {
 			t.Error("expected an error when adding a player with an empty name, but got nil")
 		}
 	})
+
+	t.Run("returns an error for an over-long player name", func(t *testing.T) {
+		g := NewGame()
+		_, err := g.AddPlayer(strings.Repeat("x", MaxPlayerNameLength+1))
+		if !errors.Is(err, ErrInvalidName) {
+			t.Errorf("expected %v, but got %v", ErrInvalidName, err)
+		}
+		if len(g.Players) != 0 {
+			t.Error("expected no player to be seated")
+		}
+	})
+
+	t.Run("joins are actions", func(t *testing.T) {
+		g := NewGame()
+		o := &recordingObserver{}
+		g.SetObserver(o)
+
+		p, _ := g.AddPlayer("Player 1")
+		events := g.EventsSince(0)
+		if len(events) != 1 || events[0].Type != EventPlayerJoined || events[0].PlayerID != p.ID {
+			t.Errorf("expected a player_joined event for the player, but got %+v", events)
+		}
+		if events[0].Version != g.Version || g.HostID != p.ID {
+			t.Errorf("expected the event at version %d and the player to host, but got version %d and host %s",
+				g.Version, events[0].Version, g.HostID)
+		}
+		if len(o.actions) != 1 || o.actions[0] != nil {
+			t.Errorf("expected the join to be observed, but got %v", o.actions)
+		}
+
+		g.AddPlayer("Player 2")
+		g.StartGame()
+		if _, err := g.AddPlayer("Late"); !errors.Is(err, ErrInvalidPhase) {
+			t.Errorf("expected ErrInvalidPhase once the game has started, but got %v", err)
+		}
+	})
 }
 
 func TestStartGame(t *testing.T) {
@@ -118,4 +156,37 @@ func TestStartGame(t *testing.T) {
 			t.Error("expected an error when starting a game with less than 2 players, but got nil")
 		}
 	})
+	t.Run("fails to start without enough population cards", func(t *testing.T) {
+		g := NewGame()
+		_, _ = g.AddPlayer("Player 1")
+		_, _ = g.AddPlayer("Player 2")
+		g.PopulationDeck = g.PopulationDeck[:5]
+
+		if err := g.StartGame(); !errors.Is(err, ErrInvalidState) {
+			t.Errorf("expected ErrInvalidState, but got %v", err)
+		}
+	})
+}
+
+func TestNewSpectatorView(t *testing.T) {
+	g := NewGame()
+	_, _ = g.AddPlayer("Player 1")
+	_, _ = g.AddPlayer("Player 2")
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("StartGame failed unexpectedly: %v", err)
+	}
+
+	view := g.NewSpectatorView()
+
+	if len(view.Players) != 2 {
+		t.Fatalf("expected 2 players in the spectator view, but got %d", len(view.Players))
+	}
+	for _, p := range view.Players {
+		if p.HandSize != 9 {
+			t.Errorf("expected hand size of 9 for player %s, but got %d", p.Name, p.HandSize)
+		}
+	}
+	if view.State != StateOpeningRound {
+		t.Errorf("expected state to be '%s', but got '%s'", StateOpeningRound, view.State)
+	}
 }
@@ -0,0 +1,110 @@
+package game
+
+// CardLocations lists every placemat location a card can be played to.
+var CardLocations = []string{"face_up", "face_down_1", "face_down_2", "deterrent_1", "deterrent_2"}
+
+// CommandParam is one parameter of a Command, named after the field it is
+// sent in.
+type CommandParam struct {
+	Name string `json:"name"`
+	// Values lists every value that is legal for the parameter right now, or
+	// is empty if the values cannot be listed, as with game options.
+	Values []string `json:"values,omitempty"`
+}
+
+// Move is one legal way to take a command.
+type Move struct {
+	// Args holds a value for each of the command's parameters.
+	Args map[string]string `json:"args"`
+	// InterceptedBy is the ID of the face-up Anti-Missile that would stop an
+	// attack, if the target has one.
+	InterceptedBy string `json:"interceptedBy,omitempty"`
+}
+
+// commandMoves fills in the parameters and legal moves of a command for a
+// player. It returns false if the command takes parameters but no value for
+// them is legal, so the command should not be offered at all.
+// Every candidate is checked with the action's own Validate, so the moves
+// offered are exactly the ones the engine accepts.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) commandMoves(cmd *Command, player *Player) bool {
+	switch ActionType(cmd.Name) {
+	case ActionPlayCard:
+		cards, locations, targets := []string{}, []string{}, []string{}
+		for _, card := range player.Hand {
+			for _, location := range CardLocations {
+				a := PlayCardAction{PlayerID: player.ID, CardID: card.ID, Location: location}
+				if a.Validate(g) != nil {
+					continue
+				}
+				cards, locations = appendNew(cards, card.ID), appendNew(locations, location)
+				cmd.Moves = append(cmd.Moves, Move{Args: map[string]string{"cardID": card.ID, "location": location}})
+			}
+			if card.Type != TypePropaganda {
+				continue
+			}
+			for _, id := range g.PlayerOrder {
+				a := PlayCardAction{PlayerID: player.ID, CardID: card.ID, Location: "face_up", TargetID: id}
+				if a.Validate(g) != nil {
+					continue
+				}
+				targets = appendNew(targets, id)
+				cmd.Moves = append(cmd.Moves, Move{Args: map[string]string{"cardID": card.ID, "location": "face_up", "targetID": id}})
+			}
+		}
+		cmd.Params = []CommandParam{{Name: "cardID", Values: cards}, {Name: "location", Values: locations}}
+		if len(targets) > 0 {
+			cmd.Params = append(cmd.Params, CommandParam{Name: "targetID", Values: targets})
+		}
+	case ActionAttack:
+		targets := []string{}
+		for _, id := range g.PlayerOrder {
+			a := AttackAction{AttackerID: player.ID, TargetID: id}
+			if a.Validate(g) != nil {
+				continue
+			}
+			targets = append(targets, id)
+			move := Move{Args: map[string]string{"targetID": id}}
+			if anti := interceptor(g.Players[id]); anti != nil {
+				move.InterceptedBy = anti.ID
+			}
+			cmd.Moves = append(cmd.Moves, move)
+		}
+		cmd.Params = []CommandParam{{Name: "targetID", Values: targets}}
+	case ActionKick, ActionTransferHost:
+		targets := []string{}
+		for _, id := range g.PlayerOrder {
+			var err error
+			if ActionType(cmd.Name) == ActionKick {
+				err = KickPlayerAction{PlayerID: player.ID, TargetID: id}.Validate(g)
+			} else {
+				err = TransferHostAction{PlayerID: player.ID, TargetID: id}.Validate(g)
+			}
+			if err != nil {
+				continue
+			}
+			targets = append(targets, id)
+			cmd.Moves = append(cmd.Moves, Move{Args: map[string]string{"playerID": id}})
+		}
+		cmd.Params = []CommandParam{{Name: "playerID", Values: targets}}
+	case ActionReady:
+		cmd.Params = []CommandParam{{Name: "ready", Values: []string{"true", "false"}}}
+		return true
+	case ActionUpdateOptions:
+		cmd.Params = []CommandParam{{Name: "options"}}
+		return true
+	default:
+		return true
+	}
+	return len(cmd.Moves) > 0
+}
+
+// appendNew appends s to list unless list already holds it.
+func appendNew(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,101 @@
+package game
+
+import "testing"
+
+// findCommand returns the named command, or nil if it is not offered.
+func findCommand(commands []Command, name string) *Command {
+	for i := range commands {
+		if commands[i].Name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func TestCommandMoves(t *testing.T) {
+	g := NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	p3, _ := g.AddPlayer("Player 3")
+	g.PlayerOrder = []string{p1.ID, p2.ID, p3.ID}
+	g.CurrentPlayerIndex = 0
+
+	t.Run("opening round offers only the secret card to face_down_1", func(t *testing.T) {
+		g.State = StateOpeningRound
+		p1.Hand = []*Card{{ID: "secret", Type: TypeSecret}, {ID: "warhead", Type: TypeWarhead}}
+
+		play := findCommand(g.getAvailableCommands(p1.ID), "play")
+		if play == nil {
+			t.Fatal("expected 'play' to be offered")
+		}
+		if len(play.Moves) != 1 || play.Moves[0].Args["cardID"] != "secret" || play.Moves[0].Args["location"] != "face_down_1" {
+			t.Errorf("expected the secret card to face_down_1 as the only move, but got %+v", play.Moves)
+		}
+	})
+
+	t.Run("occupied slots are not offered", func(t *testing.T) {
+		g.State = StateInProgress
+		p1.Hand = []*Card{{ID: "c1", Type: TypeWarhead}, {ID: "c2", Type: TypePropaganda}}
+		p1.Placemat.FaceDownCard1 = &Card{ID: "down"}
+		defer func() { p1.Placemat.FaceDownCard1 = nil }()
+
+		play := findCommand(g.getAvailableCommands(p1.ID), "play")
+		if play == nil {
+			t.Fatal("expected 'play' to be offered")
+		}
+		// Each card to each free slot, plus the Propaganda at each opponent.
+		if want := 2*(len(CardLocations)-1) + 2; len(play.Moves) != want {
+			t.Errorf("expected %d moves, but got %d", want, len(play.Moves))
+		}
+		for _, m := range play.Moves {
+			if m.Args["location"] == "face_down_1" {
+				t.Errorf("expected face_down_1 not to be offered while it is occupied")
+			}
+			if m.Args["targetID"] != "" && m.Args["cardID"] != "c2" {
+				t.Errorf("expected only the Propaganda card to take a target, but got %+v", m.Args)
+			}
+		}
+		if len(play.Params) != 3 || len(play.Params[0].Values) != 2 {
+			t.Errorf("expected both cards to be listed as cardID values, but got %+v", play.Params)
+		}
+		if targets := play.Params[2]; targets.Name != "targetID" || len(targets.Values) != 2 {
+			t.Errorf("expected both opponents as Propaganda targets, but got %+v", targets)
+		}
+	})
+
+	t.Run("attack needs a delivery system and a warhead", func(t *testing.T) {
+		g.State = StateInProgress
+		p1.Placemat.ActiveCards = []*Card{{ID: "d1", Type: TypeDeliverySystem}}
+		if findCommand(g.getAvailableCommands(p1.ID), "attack") != nil {
+			t.Error("expected 'attack' not to be offered without a warhead in play")
+		}
+
+		p1.Placemat.ActiveCards = append(p1.Placemat.ActiveCards, &Card{ID: "w1", Type: TypeWarhead})
+		p2.Placemat.ActiveCards = []*Card{{ID: "anti", Type: TypeAntiMissile}}
+		p3.IsEliminated = true
+		defer func() { p3.IsEliminated = false }()
+
+		attack := findCommand(g.getAvailableCommands(p1.ID), "attack")
+		if attack == nil {
+			t.Fatal("expected 'attack' to be offered once armed")
+		}
+		// Neither the attacker nor an eliminated player is a legal target.
+		if len(attack.Moves) != 1 || attack.Moves[0].Args["targetID"] != p2.ID {
+			t.Fatalf("expected player 2 as the only target, but got %+v", attack.Moves)
+		}
+		if attack.Moves[0].InterceptedBy != "anti" {
+			t.Errorf("expected the attack to show it would be intercepted by 'anti', but got '%s'", attack.Moves[0].InterceptedBy)
+		}
+	})
+
+	t.Run("kick lists every other player", func(t *testing.T) {
+		g.State = StateWaitingForPlayers
+		kick := findCommand(g.getAvailableCommands(p1.ID), "kick")
+		if kick == nil {
+			t.Fatal("expected the host to be offered 'kick'")
+		}
+		if len(kick.Moves) != 2 || kick.Params[0].Name != "playerID" {
+			t.Errorf("expected two players to kick, but got %+v", kick)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package game
+
+import "time"
+
+// Observer is told what happens in a game, for metrics and the like.
+// Its methods are called with the game's lock held, so they must be quick
+// and must not call back into the game.
+type Observer interface {
+	// ActionTaken is called for every action the game is asked to apply,
+	// with nil if it was applied or the error it was rejected with.
+	ActionTaken(gameID string, action ActionType, err error)
+	// EventCommitted is called for every event the game commits, in order.
+	EventCommitted(gameID string, ev Event)
+}
+
+// SetObserver makes o the game's observer, replacing any earlier one. A nil
+// observer turns observing off.
+func (g *Game) SetObserver(o Observer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.observer = o
+}
+
+// observeAction tells the observer, if any, about an action.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) observeAction(action ActionType, err error) {
+	if g.observer != nil {
+		g.observer.ActionTaken(g.ID, action, err)
+	}
+}
+
+// ConnectedPlayers returns the number of seated players who have made a
+// request recently.
+func (g *Game) ConnectedPlayers(now time.Time) int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	n := 0
+	for id := range g.Players {
+		if g.isConnected(id, now) {
+			n++
+		}
+	}
+	return n
+}
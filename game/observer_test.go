@@ -0,0 +1,48 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+// recordingObserver remembers what it is told.
+type recordingObserver struct {
+	actions []error
+	events  []EventType
+}
+
+func (o *recordingObserver) ActionTaken(gameID string, action ActionType, err error) {
+	o.actions = append(o.actions, err)
+}
+
+func (o *recordingObserver) EventCommitted(gameID string, ev Event) {
+	o.events = append(o.events, ev.Type)
+}
+
+func TestObserver(t *testing.T) {
+	g, p1, p2 := turnGame()
+	o := &recordingObserver{}
+	g.SetObserver(o)
+
+	if err := g.Apply(PassTurnAction{PlayerID: p1.ID}); err != nil {
+		t.Fatalf("pass failed unexpectedly: %v", err)
+	}
+	g.Apply(PassTurnAction{PlayerID: p1.ID})
+	g.ApplyAtVersion(PassTurnAction{PlayerID: p2.ID}, 0)
+
+	if len(o.actions) != 3 {
+		t.Fatalf("expected 3 actions to be observed, but got %d", len(o.actions))
+	}
+	if o.actions[0] != nil || !errors.Is(o.actions[1], ErrNotYourTurn) || !errors.Is(o.actions[2], ErrVersionMismatch) {
+		t.Errorf("expected ok, not your turn and version mismatch, but got %v", o.actions)
+	}
+	if len(o.events) == 0 || o.events[0] != EventTurnPassed {
+		t.Errorf("expected the pass's events to be observed, but got %v", o.events)
+	}
+
+	g.SetObserver(nil)
+	g.Apply(PassTurnAction{PlayerID: p2.ID})
+	if len(o.actions) != 3 {
+		t.Error("expected nothing to be observed once the observer is removed")
+	}
+}
@@ -0,0 +1,189 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// savedGameFormat is the version of the saved game layout. RestoreGame
+// refuses layouts it does not know.
+const savedGameFormat = 1
+
+// savedGame is the stored form of a game. Unlike the views sent to clients,
+// it holds everything needed to carry the game on, hidden cards and secrets
+// included, so it must never be shown to anyone.
+type savedGame struct {
+	Format             int                     `json:"format"`
+	ID                 string                  `json:"id"`
+	Name               string                  `json:"name"`
+	Visibility         Visibility              `json:"visibility"`
+	JoinCode           string                  `json:"joinCode,omitempty"`
+	HostID             string                  `json:"hostID,omitempty"`
+	CreatedAt          time.Time               `json:"createdAt"`
+	Players            map[string]*savedPlayer `json:"players"`
+	PlayerOrder        []string                `json:"playerOrder"`
+	CurrentPlayerIndex int                     `json:"currentPlayerIndex"`
+	Deck               []*Card                 `json:"deck"`
+	PopulationDeck     []*Card                 `json:"populationDeck"`
+	DiscardPile        []*Card                 `json:"discardPile"`
+	PopulationBank     int64                   `json:"populationBank"`
+	State              GameState               `json:"state"`
+	Winner             *savedPlayer            `json:"winner,omitempty"`
+	TurnLog            []string                `json:"turnLog"`
+	Version            uint64                  `json:"version"`
+	Options            GameOptions             `json:"options"`
+	Events             []Event                 `json:"events"`
+	LastSeq            uint64                  `json:"lastSeq"`
+	PlayerTokens       map[string]string       `json:"playerTokens"`
+	SpectatorTokens    map[string]string       `json:"spectatorTokens"`
+	SpectatorFrames    []savedFrame            `json:"spectatorFrames,omitempty"`
+	PasswordSalt       []byte                  `json:"passwordSalt,omitempty"`
+	PasswordHash       []byte                  `json:"passwordHash,omitempty"`
+	PasswordIterations int                     `json:"passwordIterations,omitempty"`
+}
+
+// savedPlayer is a player with the face-down cards the Placemat hides.
+type savedPlayer struct {
+	Player
+	FaceDown [2]*Card `json:"faceDown"`
+}
+
+// savedFrame is a spectator frame, kept so that a delay survives a restart.
+type savedFrame struct {
+	At   time.Time      `json:"at"`
+	View *SpectatorView `json:"view"`
+}
+
+func savePlayer(p *Player) *savedPlayer {
+	if p == nil {
+		return nil
+	}
+	return &savedPlayer{Player: *p, FaceDown: [2]*Card{p.Placemat.FaceDownCard1, p.Placemat.FaceDownCard2}}
+}
+
+func (sp *savedPlayer) player() *Player {
+	p := sp.Player
+	p.Placemat.FaceDownCard1, p.Placemat.FaceDownCard2 = sp.FaceDown[0], sp.FaceDown[1]
+	return &p
+}
+
+// MarshalState encodes the whole game, secrets included, for RestoreGame.
+// The encoding is meant for a store, never for clients.
+func (g *Game) MarshalState() ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	s := savedGame{
+		Format:             savedGameFormat,
+		ID:                 g.ID,
+		Name:               g.Name,
+		Visibility:         g.Visibility,
+		JoinCode:           g.JoinCode,
+		HostID:             g.HostID,
+		CreatedAt:          g.CreatedAt,
+		Players:            make(map[string]*savedPlayer, len(g.Players)),
+		PlayerOrder:        g.PlayerOrder,
+		CurrentPlayerIndex: g.CurrentPlayerIndex,
+		Deck:               g.Deck,
+		PopulationDeck:     g.PopulationDeck,
+		DiscardPile:        g.DiscardPile,
+		PopulationBank:     g.PopulationBank,
+		State:              g.State,
+		Winner:             savePlayer(g.Winner),
+		TurnLog:            g.TurnLog,
+		Version:            g.Version,
+		Options:            g.Options,
+		Events:             g.events,
+		LastSeq:            g.lastSeq,
+		PlayerTokens:       g.playerTokens,
+		SpectatorTokens:    g.spectatorTokens,
+		PasswordSalt:       g.passwordSalt,
+		PasswordHash:       g.passwordHash,
+		PasswordIterations: g.passwordIterations,
+	}
+	for id, p := range g.Players {
+		s.Players[id] = savePlayer(p)
+	}
+	for _, frame := range g.spectatorFrames {
+		s.SpectatorFrames = append(s.SpectatorFrames, savedFrame{At: frame.at, View: frame.view})
+	}
+	return json.Marshal(s)
+}
+
+// RestoreGame rebuilds a game from the output of MarshalState. Players keep
+// their tokens and clients can resume the event stream where they left off.
+// Nobody counts as connected until they are seen again, and a running turn
+// clock starts over, so the downtime is not charged to anyone.
+func RestoreGame(data []byte) (*Game, error) {
+	var s savedGame
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("decoding saved game: %w", err)
+	}
+	if s.Format != savedGameFormat {
+		return nil, fmt.Errorf("saved game %s has format %d, but only %d is understood", s.ID, s.Format, savedGameFormat)
+	}
+
+	g := &Game{
+		ID:                 s.ID,
+		Name:               s.Name,
+		Visibility:         s.Visibility,
+		JoinCode:           s.JoinCode,
+		HostID:             s.HostID,
+		CreatedAt:          s.CreatedAt,
+		Players:            make(map[string]*Player, len(s.Players)),
+		PlayerOrder:        s.PlayerOrder,
+		CurrentPlayerIndex: s.CurrentPlayerIndex,
+		Deck:               s.Deck,
+		PopulationDeck:     s.PopulationDeck,
+		DiscardPile:        s.DiscardPile,
+		PopulationBank:     s.PopulationBank,
+		State:              s.State,
+		TurnLog:            s.TurnLog,
+		Version:            s.Version,
+		Options:            s.Options,
+		events:             s.Events,
+		lastSeq:            s.LastSeq,
+		playerTokens:       s.PlayerTokens,
+		spectatorTokens:    s.SpectatorTokens,
+		passwordSalt:       s.PasswordSalt,
+		passwordHash:       s.PasswordHash,
+		passwordIterations: s.PasswordIterations,
+		lastSeen:           make(map[string]time.Time),
+		subscribers:        make(map[chan struct{}]struct{}),
+	}
+	for id, sp := range s.Players {
+		g.Players[id] = sp.player()
+	}
+	// The winner is one of the players, unless they have since left.
+	if s.Winner != nil {
+		if p, ok := g.Players[s.Winner.ID]; ok {
+			g.Winner = p
+		} else {
+			g.Winner = s.Winner.player()
+		}
+	}
+	for _, frame := range s.SpectatorFrames {
+		g.spectatorFrames = append(g.spectatorFrames, spectatorFrame{at: frame.At, view: frame.View})
+	}
+	if g.PlayerOrder == nil {
+		g.PlayerOrder = make([]string, 0, MaxPlayers)
+	}
+	if g.DiscardPile == nil {
+		g.DiscardPile = make([]*Card, 0)
+	}
+	if g.playerTokens == nil {
+		g.playerTokens = make(map[string]string)
+	}
+	if g.spectatorTokens == nil {
+		g.spectatorTokens = make(map[string]string)
+	}
+	// Restored spectator tokens get a full idle period from the restart.
+	g.spectatorSeen = make(map[string]time.Time, len(g.spectatorTokens))
+	for token := range g.spectatorTokens {
+		g.spectatorSeen[token] = time.Now()
+	}
+	g.watchers = make(map[string]int)
+	g.updateTurnClock(time.Now())
+	return g, nil
+}
@@ -0,0 +1,112 @@
+package game
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestoreGame(t *testing.T) {
+	g, err := NewGameWithOptions(GameOptions{TurnTimeoutSeconds: 60, SpectatorDelaySeconds: 30})
+	if err != nil {
+		t.Fatalf("NewGameWithOptions failed unexpectedly: %v", err)
+	}
+	if err := g.SetLobbySettings(LobbySettings{Name: "Saved", Password: "hunter2"}); err != nil {
+		t.Fatalf("SetLobbySettings failed unexpectedly: %v", err)
+	}
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	token, _ := g.IssuePlayerToken(p1.ID)
+	spectatorToken, _ := g.IssueSpectatorToken("Watcher")
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("StartGame failed unexpectedly: %v", err)
+	}
+	p1.Placemat.FaceDownCard1 = &Card{ID: "hidden", Type: TypeAntiMissile}
+
+	data, err := g.MarshalState()
+	if err != nil {
+		t.Fatalf("MarshalState failed unexpectedly: %v", err)
+	}
+	restoredAt := time.Now()
+	restored, err := RestoreGame(data)
+	if err != nil {
+		t.Fatalf("RestoreGame failed unexpectedly: %v", err)
+	}
+
+	t.Run("hidden state is kept", func(t *testing.T) {
+		if !reflect.DeepEqual(restored.Deck, g.Deck) || !reflect.DeepEqual(restored.PopulationDeck, g.PopulationDeck) {
+			t.Error("expected the decks to be restored in order")
+		}
+		if restored.PopulationBank != g.PopulationBank || restored.Version != g.Version || restored.State != g.State {
+			t.Error("expected the bank, version and state to be restored")
+		}
+		if got := restored.Players[p1.ID].Placemat.FaceDownCard1; got == nil || got.ID != "hidden" {
+			t.Errorf("expected the face-down card to be restored, but got %+v", got)
+		}
+		if !reflect.DeepEqual(restored.Players[p2.ID].Hand, p2.Hand) {
+			t.Error("expected the hands to be restored")
+		}
+	})
+
+	t.Run("secrets still work", func(t *testing.T) {
+		if id, ok := restored.PlayerForToken(token); !ok || id != p1.ID {
+			t.Error("expected the player token to identify the player")
+		}
+		if !restored.IsSpectatorToken(spectatorToken) {
+			t.Error("expected the spectator token to be accepted")
+		}
+		if !restored.CheckPassword("hunter2") || restored.CheckPassword("wrong") {
+			t.Error("expected the join password to be restored")
+		}
+	})
+
+	t.Run("events resume where they left off", func(t *testing.T) {
+		if restored.LastSeq() != g.LastSeq() || len(restored.EventsSince(0)) != len(g.EventsSince(0)) {
+			t.Errorf("expected %d events up to %d, but got %d up to %d",
+				len(g.EventsSince(0)), g.LastSeq(), len(restored.EventsSince(0)), restored.LastSeq())
+		}
+	})
+
+	t.Run("the turn clock starts over", func(t *testing.T) {
+		// Checking the password takes a while, so this is timed from the restore.
+		if restored.turnDeadline.Before(restoredAt.Add(60 * time.Second)) {
+			t.Errorf("expected a fresh turn clock, but it runs out at %v", restored.turnDeadline)
+		}
+	})
+
+	t.Run("delayed spectators keep their delay", func(t *testing.T) {
+		if got := restored.DelayedSpectatorView(time.Now()); got.State != StateWaitingForPlayers {
+			t.Errorf("expected the delayed view to still show the lobby, but got %s", got.State)
+		}
+	})
+
+	t.Run("the game carries on", func(t *testing.T) {
+		if err := restored.Apply(ForfeitAction{PlayerID: p2.ID}); err != nil {
+			t.Errorf("expected the restored game to accept actions, but got %v", err)
+		}
+	})
+}
+
+func TestRestoreGame_Winner(t *testing.T) {
+	g, p1, _ := turnGame()
+	g.State = StateGameOver
+	g.Winner = p1
+
+	data, _ := g.MarshalState()
+	restored, err := RestoreGame(data)
+	if err != nil {
+		t.Fatalf("RestoreGame failed unexpectedly: %v", err)
+	}
+	if restored.Winner != restored.Players[p1.ID] {
+		t.Error("expected the winner to be the seated player, not a copy")
+	}
+}
+
+func TestRestoreGame_RejectsUnknownFormat(t *testing.T) {
+	data, _ := json.Marshal(map[string]interface{}{"format": savedGameFormat + 1, "id": "future"})
+	if _, err := RestoreGame(data); err == nil || !strings.Contains(err.Error(), "format") {
+		t.Errorf("expected an unknown format to be rejected, but got %v", err)
+	}
+}
@@ -63,3 +63,49 @@ func TestPlayCard(t *testing.T) {
 		}
 	})
 }
+
+func TestPlayCard_Propaganda(t *testing.T) {
+	t.Run("steals population from the target", func(t *testing.T) {
+		g, p1, p2 := turnGame()
+		p1.Population = 10000000
+		p1.Hand = []*Card{{ID: "prop", Name: "Propaganda", Type: TypePropaganda}}
+
+		if err := g.Apply(PlayCardAction{PlayerID: p1.ID, CardID: "prop", Location: "face_up", TargetID: p2.ID}); err != nil {
+			t.Fatalf("PlayCard failed unexpectedly: %v", err)
+		}
+		if p1.Population != 11000000 || p2.Population != 24000000 {
+			t.Errorf("expected 1 million to move from player 2 to player 1, but they have %d and %d", p1.Population, p2.Population)
+		}
+		if len(p1.Placemat.ActiveCards) != 0 || len(g.DiscardPile) != 1 {
+			t.Error("expected the Propaganda to be discarded once resolved")
+		}
+	})
+
+	t.Run("taking the last population eliminates the target", func(t *testing.T) {
+		g, p1, p2 := turnGame()
+		p2.Population = 500000
+		p1.Hand = []*Card{{ID: "prop", Name: "Propaganda", Type: TypePropaganda}}
+
+		if err := g.Apply(PlayCardAction{PlayerID: p1.ID, CardID: "prop", Location: "face_up", TargetID: p2.ID}); err != nil {
+			t.Fatalf("PlayCard failed unexpectedly: %v", err)
+		}
+		if !p2.IsEliminated || g.State != StateFinalStrike || g.PlayerOrder[g.CurrentPlayerIndex] != p2.ID {
+			t.Error("expected player 2 to be eliminated and given their Final Strike")
+		}
+	})
+
+	t.Run("only Propaganda played face up takes a target", func(t *testing.T) {
+		g, p1, p2 := turnGame()
+		p1.Hand = []*Card{{ID: "prop", Type: TypePropaganda}, {ID: "w1", Type: TypeWarhead}}
+
+		if err := g.Apply(PlayCardAction{PlayerID: p1.ID, CardID: "w1", Location: "face_up", TargetID: p2.ID}); err == nil {
+			t.Error("expected a warhead with a target to be rejected")
+		}
+		if err := g.Apply(PlayCardAction{PlayerID: p1.ID, CardID: "prop", Location: "deterrent_1", TargetID: p2.ID}); err == nil {
+			t.Error("expected Propaganda played as a deterrent with a target to be rejected")
+		}
+		if err := g.Apply(PlayCardAction{PlayerID: p1.ID, CardID: "prop", Location: "face_up", TargetID: p1.ID}); err == nil {
+			t.Error("expected Propaganda at yourself to be rejected")
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxSpectatorDelay is the longest spectator delay a game may be created with.
+const MaxSpectatorDelay = 10 * time.Minute
+
+// MaxSpectatorTokens is the most spectator tokens a game holds at once.
+const MaxSpectatorTokens = 256
+
+// SpectatorTokenIdle is how long a spectator token lasts without being used.
+// A token with a stream or socket open on it does not expire.
+const SpectatorTokenIdle = time.Hour
+
+// spectatorFrame is the spectator view as it was at a point in time.
+type spectatorFrame struct {
+	at   time.Time
+	view *SpectatorView
+}
+
+// Validate checks that the options are within the allowed ranges.
+func (o GameOptions) Validate() error {
+	if o.SpectatorDelaySeconds < 0 {
+		return newError(ErrInvalidOptions, "spectator delay cannot be negative", "field", "spectatorDelaySeconds")
+	}
+	if time.Duration(o.SpectatorDelaySeconds)*time.Second > MaxSpectatorDelay {
+		return newError(ErrInvalidOptions, fmt.Sprintf("spectator delay cannot be longer than %s", MaxSpectatorDelay), "field", "spectatorDelaySeconds")
+	}
+	switch o.ForfeitPopulation {
+	case "", ForfeitToBank, ForfeitSplit, ForfeitDestroy:
+	default:
+		return newError(ErrInvalidOptions, fmt.Sprintf("invalid forfeit population rule: %s", o.ForfeitPopulation), "field", "forfeitPopulation")
+	}
+	if err := o.validateTimers(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// spectatorDelay returns the configured spectator delay.
+func (o GameOptions) spectatorDelay() time.Duration {
+	return time.Duration(o.SpectatorDelaySeconds) * time.Second
+}
+
+// IssueSpectatorToken creates a watcher token for someone who wants to follow
+// the game without joining it. Tokens left unused expire, and once a game
+// holds MaxSpectatorTokens it turns new spectators away.
+func (g *Game) IssueSpectatorToken(name string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	g.expireSpectatorTokens(now)
+	if len(g.spectatorTokens) >= MaxSpectatorTokens {
+		return "", newError(ErrGameFull, fmt.Sprintf("cannot have more than %d spectators", MaxSpectatorTokens))
+	}
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	g.spectatorTokens[token] = name
+	g.spectatorSeen[token] = now
+	return token, nil
+}
+
+// IsSpectatorToken checks if a token was issued to a spectator of this game
+// and has not expired.
+func (g *Game) IsSpectatorToken(token string) bool {
+	if token == "" {
+		return false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.spectatorTokens[token]
+	return ok && !g.spectatorTokenExpired(token, time.Now())
+}
+
+// MarkSpectatorSeen records that a spectator token was used at the given time.
+func (g *Game) MarkSpectatorSeen(token string, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.spectatorTokens[token]; ok {
+		g.spectatorSeen[token] = now
+	}
+}
+
+// Watch counts a stream or socket a spectator opened with token, until the
+// returned function is called. Spectator views report the number of them.
+func (g *Game) Watch(token string) (stop func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.watchers[token]++
+	return func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.watchers[token]--; g.watchers[token] <= 0 {
+			delete(g.watchers, token)
+		}
+		if _, ok := g.spectatorTokens[token]; ok {
+			g.spectatorSeen[token] = time.Now()
+		}
+	}
+}
+
+// watching returns the number of open spectator streams and sockets.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) watching() int {
+	n := 0
+	for _, count := range g.watchers {
+		n += count
+	}
+	return n
+}
+
+// spectatorTokenExpired reports whether a spectator token has gone unused for
+// too long.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) spectatorTokenExpired(token string, now time.Time) bool {
+	if g.watchers[token] > 0 {
+		return false
+	}
+	return now.Sub(g.spectatorSeen[token]) >= SpectatorTokenIdle
+}
+
+// expireSpectatorTokens drops the spectator tokens that have expired.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) expireSpectatorTokens(now time.Time) {
+	for token := range g.spectatorTokens {
+		if g.spectatorTokenExpired(token, now) {
+			delete(g.spectatorTokens, token)
+			delete(g.spectatorSeen, token)
+		}
+	}
+}
+
+// DelayedSpectatorView returns the spectator view as it was the configured
+// spectator delay ago. Without a delay, or before the game has started, it
+// is the live view.
+func (g *Game) DelayedSpectatorView(now time.Time) *SpectatorView {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	delay := g.Options.spectatorDelay()
+	if delay == 0 || g.State == StateWaitingForPlayers || len(g.spectatorFrames) == 0 {
+		return g.spectatorView()
+	}
+
+	cutoff := now.Add(-delay)
+	var view *SpectatorView
+	for _, frame := range g.spectatorFrames {
+		if frame.at.After(cutoff) {
+			break
+		}
+		view = frame.view
+	}
+	if view == nil {
+		// Nothing is old enough yet: show the game as it was before it started.
+		view = g.spectatorFrames[0].view
+	}
+
+	delayed := *view
+	delayed.Spectators = g.watching()
+	delayed.DelaySeconds = g.Options.SpectatorDelaySeconds
+	return &delayed
+}
+
+// recordSpectatorFrame remembers the current spectator view for delayed
+// spectating and drops frames that are no longer needed.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) recordSpectatorFrame() {
+	delay := g.Options.spectatorDelay()
+	if delay == 0 {
+		return
+	}
+	now := time.Now()
+	g.spectatorFrames = append(g.spectatorFrames, spectatorFrame{at: now, view: g.spectatorView()})
+
+	// Keep the newest frame that is older than the delay and everything after it.
+	cutoff := now.Add(-delay)
+	keepFrom := 0
+	for i, frame := range g.spectatorFrames {
+		if frame.at.After(cutoff) {
+			break
+		}
+		keepFrom = i
+	}
+	if keepFrom > 0 {
+		g.spectatorFrames = append([]spectatorFrame(nil), g.spectatorFrames[keepFrom:]...)
+	}
+}
+
+// recordSpectatorBaseline records the state before the first action, so that
+// delayed spectators have something to see until the delay has passed.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) recordSpectatorBaseline() {
+	if g.Options.spectatorDelay() > 0 && len(g.spectatorFrames) == 0 {
+		g.spectatorFrames = append(g.spectatorFrames, spectatorFrame{at: time.Now(), view: g.spectatorView()})
+	}
+}
@@ -0,0 +1,107 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGameOptions_Validate(t *testing.T) {
+	if err := (GameOptions{SpectatorDelaySeconds: -1}).Validate(); err == nil {
+		t.Error("expected an error for a negative spectator delay, but got nil")
+	}
+	if err := (GameOptions{SpectatorDelaySeconds: 3600}).Validate(); err == nil {
+		t.Error("expected an error for a spectator delay over the maximum, but got nil")
+	}
+	if err := (GameOptions{SpectatorDelaySeconds: 30}).Validate(); err != nil {
+		t.Errorf("expected a 30 second delay to be valid, but got: %v", err)
+	}
+}
+
+func TestDelayedSpectatorView(t *testing.T) {
+	t.Run("without a delay spectators see the live game", func(t *testing.T) {
+		g := NewGame()
+		_, _ = g.AddPlayer("Player 1")
+		_, _ = g.AddPlayer("Player 2")
+		if err := g.StartGame(); err != nil {
+			t.Fatalf("StartGame failed unexpectedly: %v", err)
+		}
+
+		view := g.DelayedSpectatorView(time.Now())
+		if view.State != StateOpeningRound {
+			t.Errorf("expected state to be '%s', but got '%s'", StateOpeningRound, view.State)
+		}
+	})
+
+	t.Run("with a delay spectators see the game as it was", func(t *testing.T) {
+		g, err := NewGameWithOptions(GameOptions{SpectatorDelaySeconds: 30})
+		if err != nil {
+			t.Fatalf("NewGameWithOptions failed unexpectedly: %v", err)
+		}
+		_, _ = g.AddPlayer("Player 1")
+		_, _ = g.AddPlayer("Player 2")
+		if err := g.StartGame(); err != nil {
+			t.Fatalf("StartGame failed unexpectedly: %v", err)
+		}
+
+		view := g.DelayedSpectatorView(time.Now())
+		if view.State != StateWaitingForPlayers {
+			t.Errorf("expected the delayed state to be '%s', but got '%s'", StateWaitingForPlayers, view.State)
+		}
+		if view.DelaySeconds != 30 {
+			t.Errorf("expected the view to report a 30 second delay, but got %d", view.DelaySeconds)
+		}
+
+		view = g.DelayedSpectatorView(time.Now().Add(31 * time.Second))
+		if view.State != StateOpeningRound {
+			t.Errorf("expected the delayed state to be '%s' once the delay passed, but got '%s'", StateOpeningRound, view.State)
+		}
+	})
+}
+
+func TestSpectatorTokens(t *testing.T) {
+	g := NewGame()
+	token, err := g.IssueSpectatorToken("Watcher")
+	if err != nil {
+		t.Fatalf("IssueSpectatorToken failed unexpectedly: %v", err)
+	}
+	if !g.IsSpectatorToken(token) {
+		t.Error("expected the spectator token to be recognised")
+	}
+	if _, ok := g.PlayerForToken(token); ok {
+		t.Error("expected a spectator token not to identify a player")
+	}
+	if n := g.NewSpectatorView().Spectators; n != 0 {
+		t.Errorf("expected no spectators before a stream is open, but got %d", n)
+	}
+	stop := g.Watch(token)
+	if n := g.NewSpectatorView().Spectators; n != 1 {
+		t.Errorf("expected 1 spectator while watching, but got %d", n)
+	}
+
+	// A token in use does not expire; one left idle does.
+	g.spectatorSeen[token] = time.Now().Add(-2 * SpectatorTokenIdle)
+	if !g.IsSpectatorToken(token) {
+		t.Error("expected a token with an open stream to stay valid")
+	}
+	stop()
+	if n := g.NewSpectatorView().Spectators; n != 0 {
+		t.Errorf("expected no spectators once the stream closed, but got %d", n)
+	}
+	g.spectatorSeen[token] = time.Now().Add(-2 * SpectatorTokenIdle)
+	if g.IsSpectatorToken(token) {
+		t.Error("expected an idle token to expire")
+	}
+
+	for i := 0; i < MaxSpectatorTokens; i++ {
+		if _, err := g.IssueSpectatorToken("Watcher"); err != nil {
+			t.Fatalf("IssueSpectatorToken %d failed unexpectedly: %v", i+1, err)
+		}
+	}
+	if len(g.spectatorTokens) != MaxSpectatorTokens {
+		t.Errorf("expected the expired token to be dropped, but %d tokens are held", len(g.spectatorTokens))
+	}
+	if _, err := g.IssueSpectatorToken("Watcher"); !errors.Is(err, ErrGameFull) {
+		t.Errorf("expected ErrGameFull past %d tokens, but got %v", MaxSpectatorTokens, err)
+	}
+}
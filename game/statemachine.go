@@ -0,0 +1,129 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Transition declares that taking Action while the game is in From is legal
+// and leaves the game in one of the states listed in To.
+type Transition struct {
+	From        GameState   `json:"from"`
+	Action      ActionType  `json:"action"`
+	To          []GameState `json:"to"`
+	Description string      `json:"description"`
+}
+
+// transitions is the game's state machine. An action that has no entry for the
+// current state is rejected before it is validated, and an action that tries
+// to move the game to a state not listed for it fails.
+// The order of the entries is the order in which commands are offered to players.
+var transitions = []Transition{
+	{StateWaitingForPlayers, ActionJoin, []GameState{StateWaitingForPlayers},
+		"Take a seat in the game"},
+	{StateWaitingForPlayers, ActionReady, []GameState{StateWaitingForPlayers},
+		"Mark yourself ready to start, or not ready"},
+	{StateWaitingForPlayers, ActionStartGame, []GameState{StateOpeningRound},
+		"Start the game (host only; 2+ players, everyone ready)"},
+	{StateWaitingForPlayers, ActionKick, []GameState{StateWaitingForPlayers},
+		"Remove a player from the game (e.g., kick <player_id>)"},
+	{StateWaitingForPlayers, ActionTransferHost, []GameState{StateWaitingForPlayers},
+		"Make another player the host (e.g., transfer_host <player_id>)"},
+	{StateWaitingForPlayers, ActionUpdateOptions, []GameState{StateWaitingForPlayers},
+		"Change the game options"},
+	{StateWaitingForPlayers, ActionLeave, []GameState{StateWaitingForPlayers},
+		"Leave the game"},
+	{StateOpeningRound, ActionPlayCard, []GameState{StateOpeningRound, StateInProgress},
+		"Play your secret card (e.g., play <cardID> face_down_1)"},
+	{StateOpeningRound, ActionPass, []GameState{StateOpeningRound},
+		"Pass your turn"},
+	{StateOpeningRound, ActionForfeit, []GameState{StateOpeningRound, StateInProgress, StateGameOver},
+		"Concede the game"},
+	{StateInProgress, ActionPlayCard, []GameState{StateInProgress, StateFinalStrike},
+		"Play a card (e.g., play <cardID> <location>, or play <cardID> face_up <target_player_id> for Propaganda)"},
+	{StateInProgress, ActionPass, []GameState{StateInProgress},
+		"Pass your turn"},
+	{StateInProgress, ActionAttack, []GameState{StateInProgress, StateFinalStrike, StateGameOver},
+		"Attack a player (e.g., attack <target_player_id>)"},
+	{StateInProgress, ActionTurn, []GameState{StateInProgress, StateFinalStrike, StateGameOver},
+		"Play cards, then attack or pass, all at once (e.g., turn <cardID>:<location> ... [target_player_id])"},
+	{StateInProgress, ActionForfeit, []GameState{StateInProgress, StateGameOver},
+		"Concede the game"},
+	{StateFinalStrike, ActionAttack, []GameState{StateInProgress, StateFinalStrike, StateGameOver},
+		"Launch your Final Strike (e.g., attack <target_player_id>)"},
+	{StateFinalStrike, ActionPass, []GameState{StateInProgress, StateGameOver},
+		"Decline your Final Strike"},
+}
+
+// Transitions returns a copy of the game's state transition table.
+func Transitions() []Transition {
+	out := make([]Transition, len(transitions))
+	for i, t := range transitions {
+		t.To = append([]GameState(nil), t.To...)
+		out[i] = t
+	}
+	return out
+}
+
+// lookupTransition returns the transition for taking action in state, if any.
+func lookupTransition(state GameState, action ActionType) (Transition, bool) {
+	for _, t := range transitions {
+		if t.From == state && t.Action == action {
+			return t, true
+		}
+	}
+	return Transition{}, false
+}
+
+// AllowedActions returns the action types that are legal in the given state,
+// in table order.
+func AllowedActions(state GameState) []ActionType {
+	actions := []ActionType{}
+	for _, t := range transitions {
+		if t.From == state {
+			actions = append(actions, t.Action)
+		}
+	}
+	return actions
+}
+
+// setState moves the game to a new state, rejecting moves that the transition
+// table does not declare for the action being applied.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) setState(next GameState) error {
+	if next == g.State {
+		return nil
+	}
+	if g.applying == "" {
+		return newError(ErrInvalidState, fmt.Sprintf("illegal transition from %s to %s outside of an action", g.State, next),
+			"from", string(g.State), "to", string(next))
+	}
+	t, ok := lookupTransition(g.transitionFrom, g.applying)
+	if ok {
+		for _, to := range t.To {
+			if to == next {
+				g.State = next
+				return nil
+			}
+		}
+	}
+	return newError(ErrInvalidState, fmt.Sprintf("illegal transition from %s to %s on %s", g.transitionFrom, next, g.applying),
+		"from", string(g.transitionFrom), "to", string(next), "action", string(g.applying))
+}
+
+// TransitionTable renders the state machine as a Markdown table.
+// The "Game States" section of the README is generated from it.
+func TransitionTable() string {
+	escape := strings.NewReplacer("<", "&lt;", ">", "&gt;", "|", "\\|")
+	var b strings.Builder
+	b.WriteString("| State | Action | Next states | Description |\n")
+	b.WriteString("|---|---|---|---|\n")
+	for _, t := range transitions {
+		next := make([]string, len(t.To))
+		for i, to := range t.To {
+			next[i] = "`" + string(to) + "`"
+		}
+		fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s |\n", t.From, t.Action, strings.Join(next, ", "), escape.Replace(t.Description))
+	}
+	return b.String()
+}
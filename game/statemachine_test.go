@@ -0,0 +1,95 @@
+package game
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTransitionTable_CoversEveryAction(t *testing.T) {
+	for actionType := range actionRules {
+		found := false
+		for _, tr := range transitions {
+			if tr.Action == actionType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("action %s has no entry in the transition table", actionType)
+		}
+	}
+
+	if actions := AllowedActions(StateGameOver); len(actions) != 0 {
+		t.Errorf("expected no actions to be allowed after the game is over, but got %v", actions)
+	}
+}
+
+func TestSetState_RejectsUndeclaredTransition(t *testing.T) {
+	g := NewGame()
+	g.State = StateInProgress
+	g.applying, g.transitionFrom = ActionPass, StateInProgress
+
+	if err := g.setState(StateGameOver); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("expected ErrInvalidState for a transition missing from the table, but got %v", err)
+	}
+	if g.State != StateInProgress {
+		t.Errorf("expected game state to stay '%s', but got '%s'", StateInProgress, g.State)
+	}
+}
+
+func TestGetAvailableCommands_FollowsTransitionTable(t *testing.T) {
+	g := NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	g.PlayerOrder = []string{p1.ID, p2.ID}
+
+	t.Run("opening round offers play to every player who has not played", func(t *testing.T) {
+		g.State = StateOpeningRound
+		g.CurrentPlayerIndex = 0
+		p2.Hand = []*Card{{ID: "s2", Type: TypeSecret}}
+
+		if !hasCommand(g.getAvailableCommands(p2.ID), "play") {
+			t.Error("expected player 2 to be offered 'play' during the opening round")
+		}
+		p2.Placemat.FaceDownCard1 = &Card{ID: "s1", Type: TypeSecret}
+		if hasCommand(g.getAvailableCommands(p2.ID), "play") {
+			t.Error("expected 'play' to be withheld once the secret card is played")
+		}
+		p2.Placemat.FaceDownCard1 = nil
+	})
+
+	t.Run("final strike offers attack to the eliminated player only", func(t *testing.T) {
+		g.State = StateFinalStrike
+		g.CurrentPlayerIndex = 1
+		p2.IsEliminated = true
+		p2.Placemat.ActiveCards = []*Card{{ID: "d1", Type: TypeDeliverySystem}, {ID: "w1", Type: TypeWarhead}}
+
+		if !hasCommand(g.getAvailableCommands(p2.ID), "attack") {
+			t.Error("expected the eliminated player to be offered 'attack' during their Final Strike")
+		}
+		if len(g.getAvailableCommands(p1.ID)) != 0 {
+			t.Error("expected no commands for the other player during a Final Strike")
+		}
+	})
+}
+
+func TestReadmeStateTableIsCurrent(t *testing.T) {
+	readme, err := os.ReadFile("../README.md")
+	if err != nil {
+		t.Fatalf("could not read README: %v", err)
+	}
+	if !strings.Contains(string(readme), TransitionTable()) {
+		t.Errorf("the state table in README.md is out of date; replace it with:\n%s", TransitionTable())
+	}
+}
+
+func hasCommand(commands []Command, name string) bool {
+	for _, c := range commands {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package game
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+)
+
+// TimeoutPolicy values decide what happens when a player's turn clock runs out.
+const (
+	TimeoutPass     = "pass"      // Pass the turn (the default)
+	TimeoutFaceDown = "face_down" // Place a random card face down, then pass
+	TimeoutBot      = "bot"       // Hand the seat to a bot for the rest of the game
+)
+
+// MaxTurnTimeout is the longest turn clock a game may be created with.
+const MaxTurnTimeout = time.Hour
+
+// DefaultFinalStrikeTimeout caps the Final Strike clock when the game does not
+// set one of its own.
+const DefaultFinalStrikeTimeout = 30 * time.Second
+
+// maxTickSteps bounds the number of actions a single Tick may take.
+const maxTickSteps = 64
+
+// validateTimers checks the turn clock options.
+func (o GameOptions) validateTimers() error {
+	timeouts := []struct {
+		field   string
+		seconds int
+	}{
+		{"turnTimeoutSeconds", o.TurnTimeoutSeconds},
+		{"finalStrikeTimeoutSeconds", o.FinalStrikeTimeoutSeconds},
+	}
+	for _, t := range timeouts {
+		if t.seconds < 0 {
+			return newError(ErrInvalidOptions, "timeouts cannot be negative", "field", t.field)
+		}
+		if time.Duration(t.seconds)*time.Second > MaxTurnTimeout {
+			return newError(ErrInvalidOptions, fmt.Sprintf("timeouts cannot be longer than %s", MaxTurnTimeout), "field", t.field)
+		}
+	}
+	switch o.TimeoutPolicy {
+	case "", TimeoutPass, TimeoutFaceDown, TimeoutBot:
+	default:
+		return newError(ErrInvalidOptions, fmt.Sprintf("invalid timeout policy: %s", o.TimeoutPolicy), "field", "timeoutPolicy")
+	}
+	return nil
+}
+
+// turnTimeout returns how long a player has to act in the given state, or 0
+// if there is no clock.
+// Only turns and Final Strikes have clocks. Interceptions have none, and no
+// InterceptionTimeoutSeconds option, because there is no interception
+// decision to wait for: an Anti-Missile in play stops an attack the moment it
+// lands (see interceptor), so the game never waits on the defending player.
+func (o GameOptions) turnTimeout(state GameState) time.Duration {
+	turn := time.Duration(o.TurnTimeoutSeconds) * time.Second
+	switch state {
+	case StateOpeningRound, StateInProgress:
+		return turn
+	case StateFinalStrike:
+		if o.FinalStrikeTimeoutSeconds > 0 {
+			return time.Duration(o.FinalStrikeTimeoutSeconds) * time.Second
+		}
+		if turn > DefaultFinalStrikeTimeout {
+			return DefaultFinalStrikeTimeout
+		}
+		return turn
+	}
+	return 0
+}
+
+// updateTurnClock starts a new clock whenever the turn passes to another
+// player or the game changes state. Plays that do not end the turn leave the
+// running clock alone.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) updateTurnClock(now time.Time) {
+	timeout := g.Options.turnTimeout(g.State)
+	if timeout == 0 {
+		g.turnDeadline, g.clockKey = time.Time{}, ""
+		return
+	}
+	// The opening round is played simultaneously, so it has a single clock.
+	key := string(g.State)
+	if g.State != StateOpeningRound {
+		_, id := g.currentTurn()
+		key += "/" + id
+	}
+	if key != g.clockKey {
+		g.clockKey = key
+		g.turnDeadline = now.Add(timeout)
+	}
+}
+
+// turnDeadlinePtr returns a copy of the running clock's deadline, or nil.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) turnDeadlinePtr() *time.Time {
+	if g.turnDeadline.IsZero() {
+		return nil
+	}
+	deadline := g.turnDeadline
+	return &deadline
+}
+
+// Tick lets bots take their turns and acts for players whose turn clock has run
+// out. It reports whether the game changed.
+func (g *Game) Tick(now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	changed := false
+	for i := 0; i < maxTickSteps; i++ {
+		a := g.dueAction(now)
+		if a == nil {
+			break
+		}
+		if err := g.applyLocked(a, now); err != nil {
+			g.log(slog.LevelWarn, "automatic action failed", "action", a.Type(), "player_id", a.Actor(), "err", err)
+			// Whatever went wrong would go wrong again on the next Tick, so
+			// the game must move on some other way.
+			fallback := g.fallbackAction(a.Actor(), err.Error())
+			if err := g.applyLocked(fallback, now); err != nil {
+				g.log(slog.LevelError, "fallback action failed", "action", fallback.Type(), "player_id", fallback.Actor(), "err", err)
+				break
+			}
+		}
+		changed = true
+	}
+	return changed
+}
+
+// dueAction returns the next action the server must take on a player's
+// behalf, or nil if there is none.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) dueAction(now time.Time) Action {
+	expired := !g.turnDeadline.IsZero() && !now.Before(g.turnDeadline)
+	takeover := g.Options.TimeoutPolicy == TimeoutBot
+
+	switch g.State {
+	case StateOpeningRound:
+		for _, id := range g.PlayerOrder {
+			p := g.Players[id]
+			if p.IsEliminated || p.Placemat.FaceDownCard1 != nil {
+				continue
+			}
+			if !p.IsBot && !expired {
+				continue
+			}
+			a := botAction(g, p)
+			if a == nil {
+				// Without a secret card the player can never finish the
+				// opening round.
+				a = g.fallbackAction(p.ID, "no secret card to play")
+			}
+			if p.IsBot {
+				return a
+			}
+			return timedOutAction{Action: a, takeover: takeover}
+		}
+	case StateInProgress, StateFinalStrike:
+		_, id := g.currentTurn()
+		p, ok := g.Players[id]
+		if !ok {
+			return nil
+		}
+		if p.IsBot {
+			return botAction(g, p)
+		}
+		if !expired {
+			return nil
+		}
+		if takeover {
+			return timedOutAction{Action: botAction(g, p), takeover: true}
+		}
+		a := timedOutAction{Action: PassTurnAction{PlayerID: p.ID}}
+		if g.Options.TimeoutPolicy == TimeoutFaceDown && g.State == StateInProgress {
+			a.place = randomFaceDownPlay(p)
+		}
+		return a
+	}
+	return nil
+}
+
+// randomFaceDownPlay picks a random card from the player's hand for a free
+// face-down slot, or returns nil if there is none.
+func randomFaceDownPlay(p *Player) *PlayCardAction {
+	if len(p.Hand) == 0 {
+		return nil
+	}
+	var location string
+	switch {
+	case p.Placemat.FaceDownCard1 == nil:
+		location = "face_down_1"
+	case p.Placemat.FaceDownCard2 == nil:
+		location = "face_down_2"
+	default:
+		return nil
+	}
+	card := p.Hand[rand.Intn(len(p.Hand))]
+	return &PlayCardAction{PlayerID: p.ID, CardID: card.ID, Location: location}
+}
+
+// timedOutAction is an action the server takes for a player whose clock ran
+// out. It is checked and recorded as the action it wraps.
+type timedOutAction struct {
+	Action
+	place    *PlayCardAction // Card to place face down before the action, if any
+	takeover bool            // Whether a bot takes over the seat
+}
+
+// Apply implements Action.
+func (a timedOutAction) Apply(g *Game) error {
+	player := g.Players[a.Actor()]
+	g.emit(EventTurnTimeout, player.ID, "", "Player %s ran out of time.", player.Name)
+	if a.takeover {
+		player.IsBot = true
+		g.emit(EventBotTakeover, player.ID, "", "A bot is now playing for %s.", player.Name)
+	}
+	if a.place != nil && a.place.Validate(g) == nil {
+		if err := a.place.Apply(g); err != nil {
+			return err
+		}
+	}
+	return a.Action.Apply(g)
+}
+
+// fallbackAction is what the server does for a player when it cannot take the
+// action it meant to: pass the turn or, in the opening round, where a pass
+// would leave the round waiting on them for good, forfeit the game.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) fallbackAction(playerID, reason string) Action {
+	if g.State == StateOpeningRound {
+		return failedAutoAction{Action: ForfeitAction{PlayerID: playerID}, reason: reason}
+	}
+	return failedAutoAction{Action: PassTurnAction{PlayerID: playerID}, reason: reason}
+}
+
+// failedAutoAction is a fallback the server takes for a player. It is checked
+// and recorded as the action it wraps, and first tells the table why.
+type failedAutoAction struct {
+	Action
+	reason string // Why the server could not act as it meant to
+}
+
+// Apply implements Action.
+func (a failedAutoAction) Apply(g *Game) error {
+	player := g.Players[a.Actor()]
+	g.emit(EventAutoActionFailed, player.ID, "", "The server could not act for %s (%s), so they %s.", player.Name, a.reason, fallbackVerb(a.Type()))
+	return a.Action.Apply(g)
+}
+
+// fallbackVerb describes a fallback action in the past tense.
+func fallbackVerb(t ActionType) string {
+	if t == ActionForfeit {
+		return "forfeited"
+	}
+	return "passed"
+}
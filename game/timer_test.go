@@ -0,0 +1,188 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// newTimedGame starts a two-player game with the given timer options.
+func newTimedGame(t *testing.T, opts GameOptions) (*Game, *Player, *Player) {
+	t.Helper()
+	g, err := NewGameWithOptions(opts)
+	if err != nil {
+		t.Fatalf("NewGameWithOptions failed unexpectedly: %v", err)
+	}
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+	if err := g.StartGame(); err != nil {
+		t.Fatalf("StartGame failed unexpectedly: %v", err)
+	}
+	return g, p1, p2
+}
+
+// expire runs the scheduler just after the running clock's deadline.
+func expire(g *Game) bool {
+	return g.Tick(g.turnDeadline.Add(time.Second))
+}
+
+func TestTurnClock(t *testing.T) {
+	t.Run("the deadline is exposed in the player view", func(t *testing.T) {
+		g, p1, _ := newTimedGame(t, GameOptions{TurnTimeoutSeconds: 60})
+		view := g.NewPlayerView(p1.ID)
+		if view.TurnDeadline == nil {
+			t.Fatal("expected the player view to carry a turn deadline")
+		}
+		if until := time.Until(*view.TurnDeadline); until <= 0 || until > time.Minute {
+			t.Errorf("expected the deadline to be within a minute, but it is %s away", until)
+		}
+	})
+
+	t.Run("games without a clock are left alone", func(t *testing.T) {
+		g, p1, _ := newTimedGame(t, GameOptions{})
+		if g.NewPlayerView(p1.ID).TurnDeadline != nil {
+			t.Error("expected no turn deadline")
+		}
+		if g.Tick(time.Now().Add(24 * time.Hour)) {
+			t.Error("expected Tick not to change an untimed game")
+		}
+	})
+
+	t.Run("nothing happens before the deadline", func(t *testing.T) {
+		g, _, _ := newTimedGame(t, GameOptions{TurnTimeoutSeconds: 60})
+		if g.Tick(time.Now()) {
+			t.Error("expected Tick not to act before the deadline")
+		}
+	})
+
+	t.Run("the opening round plays missing secret cards", func(t *testing.T) {
+		g, _, _ := newTimedGame(t, GameOptions{TurnTimeoutSeconds: 60})
+		if !expire(g) {
+			t.Fatal("expected Tick to act after the deadline")
+		}
+		if g.State != StateInProgress {
+			t.Errorf("expected game state to be '%s', but got '%s'", StateInProgress, g.State)
+		}
+	})
+
+	t.Run("a player without a secret card forfeits the opening round", func(t *testing.T) {
+		g, p1, _ := newTimedGame(t, GameOptions{TurnTimeoutSeconds: 60})
+		for _, card := range p1.Hand {
+			if card.Type == TypeSecret {
+				card.Type = TypeWarhead
+			}
+		}
+		if !expire(g) {
+			t.Fatal("expected Tick to act after the deadline")
+		}
+		if g.State == StateOpeningRound {
+			t.Fatal("expected the game to get past the opening round")
+		}
+		if !p1.IsEliminated {
+			t.Error("expected the player without a secret card to forfeit")
+		}
+		found := false
+		for _, e := range g.EventsSince(0) {
+			if e.Type == EventAutoActionFailed && e.PlayerID == p1.ID {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected an '%s' event for the player", EventAutoActionFailed)
+		}
+		if expire(g) {
+			t.Error("expected nothing left to do once the game is over")
+		}
+	})
+
+	t.Run("auto-pass", func(t *testing.T) {
+		g, _, _ := newTimedGame(t, GameOptions{TurnTimeoutSeconds: 60})
+		expire(g)
+		expire(g)
+		if g.CurrentPlayerIndex != 1 {
+			t.Errorf("expected the turn to pass to player 2, but CurrentPlayerIndex is %d", g.CurrentPlayerIndex)
+		}
+		events := g.EventsSince(0)
+		found := false
+		for _, e := range events {
+			if e.Type == EventTurnTimeout {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("expected a turn_timeout event")
+		}
+	})
+
+	t.Run("auto-place a face-down card", func(t *testing.T) {
+		g, p1, _ := newTimedGame(t, GameOptions{TurnTimeoutSeconds: 60, TimeoutPolicy: TimeoutFaceDown})
+		expire(g)
+		handSize := len(p1.Hand)
+		expire(g)
+		if p1.Placemat.FaceDownCard1 == nil || len(p1.Hand) != handSize-1 {
+			t.Error("expected a card to be placed face down")
+		}
+		if g.CurrentPlayerIndex != 1 {
+			t.Errorf("expected the turn to pass to player 2, but CurrentPlayerIndex is %d", g.CurrentPlayerIndex)
+		}
+	})
+
+	t.Run("bot takeover", func(t *testing.T) {
+		g, p1, p2 := newTimedGame(t, GameOptions{TurnTimeoutSeconds: 60, TimeoutPolicy: TimeoutBot})
+		if !expire(g) {
+			t.Fatal("expected Tick to act after the deadline")
+		}
+		if !p1.IsBot || !p2.IsBot {
+			t.Fatal("expected bots to take over both seats")
+		}
+		// The bots play on without waiting for the clock.
+		if g.State == StateOpeningRound {
+			t.Error("expected the bots to get past the opening round")
+		}
+		if g.Version < 4 {
+			t.Errorf("expected the bots to keep playing, but the game is only at version %d", g.Version)
+		}
+	})
+
+	t.Run("the player takes their seat back by acting", func(t *testing.T) {
+		g, p1, _ := newTimedGame(t, GameOptions{TurnTimeoutSeconds: 60, TimeoutPolicy: TimeoutBot})
+		p1.IsBot = true
+		g.Apply(PassTurnAction{PlayerID: p1.ID})
+		if p1.IsBot {
+			t.Error("expected the player to be back in control")
+		}
+	})
+
+	t.Run("a rejected action leaves the bot in charge", func(t *testing.T) {
+		g, p1, _ := newTimedGame(t, GameOptions{TurnTimeoutSeconds: 60, TimeoutPolicy: TimeoutBot})
+		p1.IsBot = true
+		if err := g.Apply(PlayCardAction{PlayerID: p1.ID, CardID: "no-such-card", Location: "face_down_1"}); err == nil {
+			t.Fatal("expected a play of a card not in hand to be rejected")
+		}
+		if !p1.IsBot {
+			t.Error("expected the bot to keep the seat")
+		}
+	})
+
+	t.Run("the Final Strike has a shorter clock", func(t *testing.T) {
+		opts := GameOptions{TurnTimeoutSeconds: 120}
+		if got := opts.turnTimeout(StateFinalStrike); got != DefaultFinalStrikeTimeout {
+			t.Errorf("expected a Final Strike timeout of %s, but got %s", DefaultFinalStrikeTimeout, got)
+		}
+		opts.FinalStrikeTimeoutSeconds = 10
+		if got := opts.turnTimeout(StateFinalStrike); got != 10*time.Second {
+			t.Errorf("expected a Final Strike timeout of 10s, but got %s", got)
+		}
+	})
+}
+
+func TestGameOptions_ValidateTimers(t *testing.T) {
+	if err := (GameOptions{TimeoutPolicy: "nap"}).Validate(); err == nil {
+		t.Error("expected an error for an unknown timeout policy, but got nil")
+	}
+	if err := (GameOptions{TurnTimeoutSeconds: -1}).Validate(); err == nil {
+		t.Error("expected an error for a negative timeout, but got nil")
+	}
+	if err := (GameOptions{TurnTimeoutSeconds: 7200}).Validate(); err == nil {
+		t.Error("expected an error for a timeout over an hour, but got nil")
+	}
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// newToken returns a random, unguessable bearer token.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// IssuePlayerToken creates a secret token that identifies a player in this game.
+// Player IDs are visible to opponents, so only the token proves who is acting.
+func (g *Game) IssuePlayerToken(playerID string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.Players[playerID]; !ok {
+		return "", newError(ErrPlayerNotFound, fmt.Sprintf("player with ID %s not found", playerID), "playerID", playerID)
+	}
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	g.playerTokens[token] = playerID
+	return token, nil
+}
+
+// PlayerForToken returns the ID of the player a token was issued to.
+func (g *Game) PlayerForToken(token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	playerID, ok := g.playerTokens[token]
+	if !ok {
+		return "", false
+	}
+	// Tokens only identify players who are still seated in the game.
+	if _, seated := g.Players[playerID]; !seated {
+		return "", false
+	}
+	return playerID, true
+}
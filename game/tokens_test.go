@@ -0,0 +1,33 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestPlayerTokens(t *testing.T) {
+	g := NewGame()
+	p1, _ := g.AddPlayer("Player 1")
+	p2, _ := g.AddPlayer("Player 2")
+
+	token1, err := g.IssuePlayerToken(p1.ID)
+	if err != nil {
+		t.Fatalf("IssuePlayerToken failed unexpectedly: %v", err)
+	}
+	token2, _ := g.IssuePlayerToken(p2.ID)
+	if token1 == token2 {
+		t.Fatal("expected each player to get a different token")
+	}
+
+	if id, ok := g.PlayerForToken(token1); !ok || id != p1.ID {
+		t.Errorf("expected token to identify player 1, but got %q", id)
+	}
+	if _, ok := g.PlayerForToken(p1.ID); ok {
+		t.Error("expected a player ID not to be accepted as a token")
+	}
+	if _, ok := g.PlayerForToken(""); ok {
+		t.Error("expected an empty token to be rejected")
+	}
+	if _, err := g.IssuePlayerToken("nobody"); err == nil {
+		t.Error("expected an error when issuing a token for an unknown player, but got nil")
+	}
+}
@@ -1,121 +1,221 @@
 package game
 
-import "fmt"
+import (
+	"fmt"
+	"log/slog"
+)
+
+// PlayCardAction places a card from a player's hand onto their placemat.
+type PlayCardAction struct {
+	PlayerID string
+	CardID   string
+	Location string // e.g., "face_up", "face_down_1", "deterrent_1"
+	// TargetID is the player a face-up Propaganda card steals population
+	// from. A Propaganda card played without a target stays on the placemat.
+	TargetID string
+}
 
-// PlayCard handles a player's action to play a card.
-func (g *Game) PlayCard(playerID, cardID, location string) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+// propagandaSteal is the population a Propaganda card takes from its target.
+const propagandaSteal = 1000000
 
-	// 1. Find the player
-	player, ok := g.Players[playerID]
-	if !ok {
-		return fmt.Errorf("player with ID %s not found", playerID)
-	}
+// Type implements Action.
+func (a PlayCardAction) Type() ActionType { return ActionPlayCard }
 
-	// 2. Find the card in the player's hand
-	var cardToPlay *Card
-	cardIndex := -1
-	for i, card := range player.Hand {
-		if card.ID == cardID {
-			cardToPlay = card
-			cardIndex = i
-			break
-		}
-	}
+// Actor implements Action.
+func (a PlayCardAction) Actor() string { return a.PlayerID }
+
+// Validate implements Action.
+func (a PlayCardAction) Validate(g *Game) error {
+	player := g.Players[a.PlayerID]
 
+	// 1. Find the card in the player's hand
+	cardToPlay, _ := findCard(player.Hand, a.CardID)
 	if cardToPlay == nil {
-		return fmt.Errorf("card with ID %s not found in player %s's hand", cardID, player.Name)
+		return newError(ErrCardNotInHand, fmt.Sprintf("card with ID %s not found in player %s's hand", a.CardID, player.Name), "cardID", a.CardID)
 	}
 
-	// 3. Enforce game state rules
-	if g.State == "opening_round" {
-		if cardToPlay.Type != "Secret" {
-			return fmt.Errorf("only secret cards can be played during the opening round")
+	// 2. Enforce opening round rules
+	if g.State == StateOpeningRound {
+		if cardToPlay.Type != TypeSecret {
+			return newError(ErrCardNotPlayable, "only secret cards can be played during the opening round", "cardID", a.CardID)
 		}
-		if location != "face_down_1" {
-			return fmt.Errorf("secret cards must be played to the 'face_down_1' location during the opening round")
+		if a.Location != "face_down_1" {
+			return newError(ErrInvalidLocation, "secret cards must be played to the 'face_down_1' location during the opening round", "location", a.Location)
 		}
-	} else {
-		// Check if it's the player's turn
-		if g.PlayerOrder[g.CurrentPlayerIndex] != playerID {
-			return fmt.Errorf("it is not player %s's turn", g.Players[g.PlayerOrder[g.CurrentPlayerIndex]].Name)
+	}
+
+	// 3. Check the Propaganda target
+	if a.TargetID != "" {
+		if cardToPlay.Type != TypePropaganda {
+			return newError(ErrCardNotPlayable, fmt.Sprintf("only Propaganda cards take a target, not %s", cardToPlay.Name), "cardID", a.CardID)
+		}
+		if a.Location != "face_up" {
+			return newError(ErrInvalidLocation, "Propaganda must be played face up to take a target", "location", a.Location)
+		}
+		target, ok := g.Players[a.TargetID]
+		if !ok {
+			return newError(ErrPlayerNotFound, fmt.Sprintf("target with ID %s not found", a.TargetID), "targetID", a.TargetID)
+		}
+		if target.ID == player.ID {
+			return newError(ErrInvalidTarget, "you cannot target yourself with Propaganda", "targetID", a.TargetID)
+		}
+		if target.IsEliminated {
+			return newError(ErrInvalidTarget, fmt.Sprintf("player %s is already eliminated", target.Name), "targetID", a.TargetID)
 		}
 	}
 
-	// 4. Place the card on the placemat
-	switch location {
+	// 4. Check the target location is free
+	switch a.Location {
 	case "face_up":
-		if g.State == "opening_round" {
-			return fmt.Errorf("cannot play cards face up during the opening round")
-		}
-		player.Placemat.ActiveCards = append(player.Placemat.ActiveCards, cardToPlay)
 	case "face_down_1":
 		if player.Placemat.FaceDownCard1 != nil {
-			return fmt.Errorf("face-down card slot 1 is already occupied")
+			return newError(ErrSlotOccupied, "face-down card slot 1 is already occupied", "location", a.Location)
 		}
-		player.Placemat.FaceDownCard1 = cardToPlay
 	case "face_down_2":
 		if player.Placemat.FaceDownCard2 != nil {
-			return fmt.Errorf("face-down card slot 2 is already occupied")
+			return newError(ErrSlotOccupied, "face-down card slot 2 is already occupied", "location", a.Location)
 		}
-		player.Placemat.FaceDownCard2 = cardToPlay
 	case "deterrent_1":
 		if player.Placemat.Deterrent1 != nil {
-			return fmt.Errorf("deterrent slot 1 is already occupied")
+			return newError(ErrSlotOccupied, "deterrent slot 1 is already occupied", "location", a.Location)
 		}
 		// TODO: Check if card is a valid deterrent
-		player.Placemat.Deterrent1 = cardToPlay
 	case "deterrent_2":
 		if player.Placemat.Deterrent2 != nil {
-			return fmt.Errorf("deterrent slot 2 is already occupied")
+			return newError(ErrSlotOccupied, "deterrent slot 2 is already occupied", "location", a.Location)
 		}
 		// TODO: Check if card is a valid deterrent
-		player.Placemat.Deterrent2 = cardToPlay
 	default:
-		return fmt.Errorf("invalid card location: %s", location)
+		return newError(ErrInvalidLocation, fmt.Sprintf("invalid card location: %s", a.Location), "location", a.Location)
 	}
+	return nil
+}
+
+// Apply implements Action.
+func (a PlayCardAction) Apply(g *Game) error {
+	player := g.Players[a.PlayerID]
+	cardToPlay, cardIndex := findCard(player.Hand, a.CardID)
 
-	// 5. Remove the card from the player's hand
+	// 1. Place the card on the placemat
+	switch a.Location {
+	case "face_up":
+		if a.TargetID != "" {
+			// Propaganda with a target takes effect at once, below.
+			g.DiscardPile = append(g.DiscardPile, cardToPlay)
+			break
+		}
+		player.Placemat.ActiveCards = append(player.Placemat.ActiveCards, cardToPlay)
+	case "face_down_1":
+		player.Placemat.FaceDownCard1 = cardToPlay
+	case "face_down_2":
+		player.Placemat.FaceDownCard2 = cardToPlay
+	case "deterrent_1":
+		player.Placemat.Deterrent1 = cardToPlay
+	case "deterrent_2":
+		player.Placemat.Deterrent2 = cardToPlay
+	}
+
+	// 2. Remove the card from the player's hand
 	player.Hand = append(player.Hand[:cardIndex], player.Hand[cardIndex+1:]...)
 
-	fmt.Printf("Player %s played card '%s' to location %s\n", player.Name, cardToPlay.Name, location)
+	// Face-down cards stay hidden from the other players.
+	if a.Location == "face_down_1" || a.Location == "face_down_2" {
+		g.emit(EventCardPlayed, player.ID, "", "Player %s played a card face down to location %s", player.Name, a.Location)
+	} else {
+		g.emit(EventCardPlayed, player.ID, "", "Player %s played card '%s' to location %s", player.Name, cardToPlay.Name, a.Location)
+	}
 
-	// 6. Check if the game state should advance
-	if g.State == "opening_round" {
-		allPlayed := true
-		for _, p := range g.Players {
-			if p.Placemat.FaceDownCard1 == nil {
-				allPlayed = false
-				break
-			}
+	// 3. Resolve Propaganda
+	if a.TargetID != "" {
+		target := g.Players[a.TargetID]
+		stolen := int64(propagandaSteal)
+		if target.Population < stolen {
+			stolen = target.Population
 		}
-		if allPlayed {
-			fmt.Println("All players have played their secret cards. Resolving opening secrets.")
-			g.ResolveOpeningSecrets()
-			g.State = StateInProgress
+		target.Population -= stolen
+		player.Population += stolen
+		g.emit(EventPropaganda, player.ID, target.ID, "Player %s's Propaganda lures %d population away from Player %s.", player.Name, stolen, target.Name)
+		if target.Population == 0 {
+			return g.eliminate(target)
 		}
-	} else if g.State == StateInProgress {
-		return nil
 	}
 
+	// 4. Check if the game state should advance
+	if g.State == StateOpeningRound && g.openingRoundComplete() {
+		g.ResolveOpeningSecrets()
+		return g.setState(StateInProgress)
+	}
 	return nil
 }
 
-// PassTurn allows the current player to pass their turn.
-func (g *Game) PassTurn(playerID string) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+// openingRoundComplete reports whether every player still in the game has
+// played their secret card.
+// This is an internal function and assumes a lock is already held.
+func (g *Game) openingRoundComplete() bool {
+	for _, p := range g.Players {
+		if !p.IsEliminated && p.Placemat.FaceDownCard1 == nil {
+			return false
+		}
+	}
+	return true
+}
 
-	// Check if it's the player's turn.
-	if g.PlayerOrder[g.CurrentPlayerIndex] != playerID {
-		return fmt.Errorf("it is not player %s's turn", playerID)
+// findCard returns the card with the given ID and its index, or nil and -1.
+func findCard(cards []*Card, cardID string) (*Card, int) {
+	for i, card := range cards {
+		if card.ID == cardID {
+			return card, i
+		}
 	}
+	return nil, -1
+}
+
+// PlayCard handles a player's action to play a card.
+func (g *Game) PlayCard(playerID, cardID, location string) error {
+	return g.Apply(PlayCardAction{PlayerID: playerID, CardID: cardID, Location: location})
+}
+
+// PassTurnAction ends the current player's turn without further plays.
+// During a Final Strike it declines the strike.
+type PassTurnAction struct {
+	PlayerID string
+}
 
+// Type implements Action.
+func (a PassTurnAction) Type() ActionType { return ActionPass }
+
+// Actor implements Action.
+func (a PassTurnAction) Actor() string { return a.PlayerID }
+
+// Validate implements Action.
+func (a PassTurnAction) Validate(g *Game) error { return nil }
+
+// Apply implements Action.
+func (a PassTurnAction) Apply(g *Game) error {
+	player := g.Players[a.PlayerID]
+	if g.State == StateFinalStrike {
+		g.emit(EventTurnPassed, player.ID, "", "Player %s declined their Final Strike.", player.Name)
+		if err := g.setState(StateInProgress); err != nil {
+			return err
+		}
+		if err := g.checkForWinner(); err != nil {
+			return err
+		}
+		if g.State == StateGameOver {
+			return nil
+		}
+	} else {
+		g.emit(EventTurnPassed, player.ID, "", "Player %s passed.", player.Name)
+	}
 	g.AdvanceTurn()
 	return nil
 }
 
+// PassTurn allows the current player to pass their turn.
+func (g *Game) PassTurn(playerID string) error {
+	return g.Apply(PassTurnAction{PlayerID: playerID})
+}
+
 // AdvanceTurn moves to the next active player, skipping those who are eliminated.
 // This is an internal function and assumes a lock is already held.
 func (g *Game) AdvanceTurn() {
@@ -124,18 +224,17 @@ func (g *Game) AdvanceTurn() {
 		g.CurrentPlayerIndex = (g.CurrentPlayerIndex + 1) % len(g.PlayerOrder)
 		nextPlayerID := g.PlayerOrder[g.CurrentPlayerIndex]
 		if !g.Players[nextPlayerID].IsEliminated {
-			fmt.Printf("--- Turn advanced. It is now %s's turn. ---\n", g.Players[nextPlayerID].Name)
+			g.emit(EventTurnAdvanced, nextPlayerID, "", "It is now %s's turn.", g.Players[nextPlayerID].Name)
 			return
 		}
 	}
 	// This case should ideally not be reached if a winner is declared correctly.
-	fmt.Println("No active players left to advance turn to.")
+	g.log(slog.LevelWarn, "no active players left to advance the turn to")
 }
 
 // ResolveOpeningSecrets handles the simultaneous reveal of secret cards.
 // This is an internal function and assumes a lock is already held.
 func (g *Game) ResolveOpeningSecrets() {
-	fmt.Println("--- Resolving Opening Secrets ---")
 	for _, playerID := range g.PlayerOrder {
 		player := g.Players[playerID]
 		if player.Placemat.FaceDownCard1 != nil {
@@ -143,12 +242,11 @@ func (g *Game) ResolveOpeningSecrets() {
 			revealedCard := player.Placemat.FaceDownCard1
 			player.Placemat.FaceDownCard1 = nil
 
-			fmt.Printf("Player %s revealed secret: %s (%s)\n", player.Name, revealedCard.Name, revealedCard.Description)
+			g.emit(EventSecretRevealed, player.ID, "", "Player %s revealed secret: %s (%s)", player.Name, revealedCard.Name, revealedCard.Description)
 			// TODO: Implement secret card effects based on revealedCard.ID or Name
 
 			// By not placing the revealedCard on the FaceUpCard slot, we consider it resolved and discarded,
 			// freeing the placemat for the main gameplay phase.
 		}
 	}
-	fmt.Println("--- Finished Resolving Secrets ---")
 }
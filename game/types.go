@@ -2,6 +2,7 @@ package game
 
 import (
 	"sync"
+	"time"
 )
 
 // MaxPlayers is the maximum number of players allowed in a game.
@@ -9,6 +10,7 @@ const MaxPlayers = 6
 
 // CardType defines the type of a card.
 const (
+	TypePopulation     = "Population"
 	TypePropaganda     = "Propaganda"
 	TypeDeliverySystem = "Delivery System"
 	TypeWarhead        = "Warhead"
@@ -19,60 +21,92 @@ const (
 
 // Player represents a player in the game.
 type Player struct {
-	ID         string    `json:"id"`
-	Name       string    `json:"name"`
-	Population int64     `json:"population"`
-	Hand       []*Card   `json:"hand"`
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Population   int64    `json:"population"`
+	Hand         []*Card  `json:"hand"`
 	Placemat     Placemat `json:"placemat"`
 	IsActive     bool     `json:"is_active"`
 	IsEliminated bool     `json:"is_eliminated"`
+	IsReady      bool     `json:"is_ready"` // Ready to start; unused for the host
+	IsBot        bool     `json:"is_bot"`   // Played by the server after a timeout
 }
 
 // Placemat holds the cards a player has in play.
 type Placemat struct {
 	ActiveCards   []*Card `json:"active_cards,omitempty"`
-	FaceDownCard1 *Card `json:"-"` // Hidden from other players
-	FaceDownCard2 *Card `json:"-"` // Hidden from other players
-	Deterrent1    *Card `json:"deterrent_1,omitempty"`
-	Deterrent2    *Card `json:"deterrent_2,omitempty"`
+	FaceDownCard1 *Card   `json:"-"` // Hidden from other players
+	FaceDownCard2 *Card   `json:"-"` // Hidden from other players
+	Deterrent1    *Card   `json:"deterrent_1,omitempty"`
+	Deterrent2    *Card   `json:"deterrent_2,omitempty"`
 }
 
 // PlayerView represents the game state from a single player's perspective,
 // hiding information that should not be visible to them (e.g., other players' hands).
 // This is used to provide a secure view of the game to each client.
 type PlayerView struct {
-	GameID             string        `json:"gameID"`
-	PlayerName         string        `json:"playerName"`
-	PlayerPopulation   int64         `json:"playerPopulation"`
-	PlayerHand         []*Card       `json:"playerHand"`
-	PlayerPlacemat     *Placemat     `json:"playerPlacemat"`
-	Opponents          []Opponent    `json:"opponents"`
-	CurrentTurnPlayer  string        `json:"currentTurnPlayer"`
-	State              GameState     `json:"state"`
-	Winner             *string       `json:"winner,omitempty"`
-	TurnLog             []string      `json:"turnLog"`
-	CurrentTurnPlayerId string        `json:"currentTurnPlayerId,omitempty"`
-	AvailableCommands   []Command     `json:"availableCommands,omitempty"`
+	GameID              string     `json:"gameID"`
+	Version             uint64     `json:"version"`
+	JoinCode            string     `json:"joinCode,omitempty"`
+	PlayerID            string     `json:"playerID"`
+	PlayerName          string     `json:"playerName"`
+	HostID              string     `json:"hostID,omitempty"`
+	IsReady             bool       `json:"isReady"`
+	TurnDeadline        *time.Time `json:"turnDeadline,omitempty"`
+	PlayerPopulation    int64      `json:"playerPopulation"`
+	PlayerHand          []*Card    `json:"playerHand"`
+	PlayerPlacemat      *Placemat  `json:"playerPlacemat"`
+	Opponents           []Opponent `json:"opponents"`
+	CurrentTurnPlayer   string     `json:"currentTurnPlayer"`
+	State               GameState  `json:"state"`
+	Winner              *string    `json:"winner,omitempty"`
+	TurnLog             []string   `json:"turnLog"`
+	CurrentTurnPlayerId string     `json:"currentTurnPlayerId,omitempty"`
+	AvailableCommands   []Command  `json:"availableCommands,omitempty"`
+}
+
+// SpectatorView represents the public state of the game, as seen by someone
+// who is not seated in it. No player's hand or face-down cards are included.
+type SpectatorView struct {
+	GameID              string     `json:"gameID"`
+	Version             uint64     `json:"version"`
+	HostID              string     `json:"hostID,omitempty"`
+	TurnDeadline        *time.Time `json:"turnDeadline,omitempty"`
+	Players             []Opponent `json:"players"`
+	CurrentTurnPlayer   string     `json:"currentTurnPlayer"`
+	CurrentTurnPlayerId string     `json:"currentTurnPlayerId,omitempty"`
+	State               GameState  `json:"state"`
+	Winner              *string    `json:"winner,omitempty"`
+	TurnLog             []string   `json:"turnLog"`
+	// Spectators is the number of spectator streams and sockets open now.
+	Spectators int `json:"spectators"`
+	// DelaySeconds is how far behind the live game this view is.
+	DelaySeconds int `json:"delaySeconds,omitempty"`
 }
 
 // Opponent represents a player as seen by another player.
 // It redacts sensitive information like the player's hand.
 type Opponent struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Population  int64     `json:"population"`
-	HandSize    int       `json:"handSize"`
-	Placemat    *Placemat `json:"placemat"`
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Population   int64     `json:"population"`
+	HandSize     int       `json:"handSize"`
+	Placemat     *Placemat `json:"placemat"`
 	IsEliminated bool      `json:"isEliminated"`
+	IsReady      bool      `json:"isReady"`
+	Connected    bool      `json:"connected"`
+	IsBot        bool      `json:"isBot,omitempty"`
 }
 
 // Command represents a single action a player can take.
 // This is used to dynamically inform the client about available options.
 type Command struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	// We can add fields for required parameters here later if needed
-	// e.g., Params []string `json:"params"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Params      []CommandParam `json:"params,omitempty"`
+	// Moves lists every legal combination of parameter values, for commands
+	// whose values can be listed.
+	Moves []Move `json:"moves,omitempty"`
 }
 
 // Card represents a single card in the game.
@@ -81,10 +115,10 @@ type Card struct {
 	Name             string   `json:"name"`
 	Type             string   `json:"type"`
 	Description      string   `json:"description,omitempty"`
-	Value            int64    `json:"value,omitempty"`            // For Population cards
+	Value            int64    `json:"value,omitempty"`             // For Population cards
 	WarheadSize      int      `json:"warhead_size,omitempty"`      // For Warheads
 	CarryingCapacity int      `json:"carrying_capacity,omitempty"` // For Delivery Systems
-	Intercepts       []string `json:"intercepts,omitempty"`      // For Anti-Missiles
+	Intercepts       []string `json:"intercepts,omitempty"`        // For Anti-Missiles
 }
 
 // Game represents the state of a single game.
@@ -100,16 +134,62 @@ const (
 )
 
 type Game struct {
-	ID                 string             `json:"id"`
-	Players            map[string]*Player `json:"players"`
-	PlayerOrder        []string           `json:"player_order"`
-	CurrentPlayerIndex int                `json:"current_player_index"`
-	Deck               []*Card            `json:"-"` // Deck is not sent to clients
-	PopulationDeck     []*Card            `json:"-"` // Population deck is not sent to clients
-	DiscardPile        []*Card            `json:"-"` // Discard pile is not sent
-	PopulationBank     int64              `json:"-"` // Population bank is not sent
-	State              GameState          `json:"state"`
-	Winner             *Player            `json:"winner,omitempty"`
-	TurnLog            []string           `json:"turnLog"`
-	mu                 sync.RWMutex       `json:"-"` // Mutex to protect game state
+	ID                 string                     `json:"id"`
+	Name               string                     `json:"name"`
+	Visibility         Visibility                 `json:"visibility"`
+	JoinCode           string                     `json:"joinCode,omitempty"` // Cleared when the game starts
+	HostID             string                     `json:"hostID,omitempty"`   // Player who runs the lobby
+	CreatedAt          time.Time                  `json:"createdAt"`
+	Players            map[string]*Player         `json:"players"`
+	PlayerOrder        []string                   `json:"player_order"`
+	CurrentPlayerIndex int                        `json:"current_player_index"`
+	Deck               []*Card                    `json:"-"` // Deck is not sent to clients
+	PopulationDeck     []*Card                    `json:"-"` // Population deck is not sent to clients
+	DiscardPile        []*Card                    `json:"-"` // Discard pile is not sent
+	PopulationBank     int64                      `json:"-"` // Population bank is not sent
+	State              GameState                  `json:"state"`
+	Winner             *Player                    `json:"winner,omitempty"`
+	TurnLog            []string                   `json:"turnLog"`
+	Version            uint64                     `json:"version"` // Incremented on every applied action and join
+	Options            GameOptions                `json:"options"`
+	mu                 sync.RWMutex               `json:"-"` // Mutex to protect game state
+	events             []Event                    // Committed events, oldest first
+	pending            []Event                    // Events emitted by the action being applied
+	lastSeq            uint64                     // Sequence number of the last committed event
+	applying           ActionType                 // Type of the action being applied, if any
+	transitionFrom     GameState                  // State the game was in when the action started
+	playerTokens       map[string]string          // Bearer token -> player ID
+	spectatorTokens    map[string]string          // Bearer token -> spectator name
+	spectatorSeen      map[string]time.Time       // Spectator token -> when it was last used
+	watchers           map[string]int             // Spectator token -> streams and sockets open on it
+	spectatorFrames    []spectatorFrame           // Past spectator views, for delayed spectating
+	passwordSalt       []byte                     // Salt for the join password hash
+	passwordHash       []byte                     // Hash of the join password; nil if there is none
+	passwordIterations int                        // PBKDF2 iterations of passwordHash; 0 for a legacy hash
+	lastSeen           map[string]time.Time       // Player ID -> time of their last request
+	turnDeadline       time.Time                  // When the running turn clock runs out; zero if none
+	clockKey           string                     // State and player the running clock belongs to
+	subscribers        map[chan struct{}]struct{} // Notified whenever the game changes
+	observer           Observer                   // Told about actions and events; nil if none
+	debug              bool                       // Log the game's debug records whatever the level
+}
+
+// GameOptions holds the per-game settings chosen when the game is created.
+type GameOptions struct {
+	// SpectatorDelaySeconds delays what spectators see once the game has
+	// started, so a streamed game cannot be used to spy on the players.
+	SpectatorDelaySeconds int `json:"spectatorDelaySeconds,omitempty"`
+	// ForfeitPopulation is what happens to a forfeiting player's population:
+	// "bank" (the default), "split" or "destroy".
+	ForfeitPopulation string `json:"forfeitPopulation,omitempty"`
+	// TurnTimeoutSeconds bounds how long a player may take over their turn.
+	// Zero means turns are not timed.
+	TurnTimeoutSeconds int `json:"turnTimeoutSeconds,omitempty"`
+	// FinalStrikeTimeoutSeconds bounds a Final Strike. It defaults to the turn
+	// timeout, capped at 30 seconds. Interceptions need no timeout of their
+	// own, as they are resolved the moment an attack lands.
+	FinalStrikeTimeoutSeconds int `json:"finalStrikeTimeoutSeconds,omitempty"`
+	// TimeoutPolicy is what happens when a clock runs out: "pass" (the
+	// default), "face_down" or "bot".
+	TimeoutPolicy string `json:"timeoutPolicy,omitempty"`
 }
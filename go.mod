@@ -5,3 +5,5 @@ go 1.24.4
 require github.com/gorilla/mux v1.8.1
 
 require github.com/google/uuid v1.6.0
+
+require github.com/gorilla/websocket v1.5.3
@@ -1,12 +1,86 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"nuclear-war-game-server/api"
+	"nuclear-war-game-server/config"
+	"nuclear-war-game-server/game"
+	"nuclear-war-game-server/store"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	fmt.Println("Nuclear War Game Server Starting...")
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		config.Usage(os.Stdout)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n\nRun with -help to list the settings.\n", err)
+		os.Exit(2)
+	}
+	if err := run(cfg); err != nil {
+		slog.Error("server stopped", "err", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the server with the resolved settings, and shuts it down
+// gracefully on SIGINT or SIGTERM, saving every game to the store.
+func run(cfg config.Config) error {
+	// Games with debug logging switched on get their debug records through
+	// whatever the level. The standard logger goes through slog too.
+	opts := &slog.HandlerOptions{Level: cfg.LogLevel}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if cfg.LogFormat == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(game.DebugHandler(handler)))
+
+	if cfg.CatalogPath != "" {
+		catalog, err := game.LoadCatalog(cfg.CatalogPath)
+		if err != nil {
+			return err
+		}
+		game.SetCatalog(catalog)
+	}
+	st, err := store.Open(cfg.Store, cfg.DataDir)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Nuclear War server starting", "config", cfg)
 	server := api.NewServer()
-	server.Start()
+	server.SetAdminToken(cfg.AdminToken)
+	server.SetStore(st)
+	server.SetLimits(api.Limits{
+		PerIP:        api.RateLimit{Rate: cfg.RateLimit, Burst: cfg.RateBurst},
+		PerToken:     api.RateLimit{Rate: cfg.TokenRateLimit, Burst: cfg.TokenRateBurst},
+		Creates:      api.RateLimit{Rate: cfg.CreateRateLimit, Burst: cfg.CreateRateBurst},
+		MaxBodyBytes: cfg.MaxBodyBytes,
+	})
+	if _, err := server.RestoreGames(); err != nil {
+		// The games that could be read are restored; the rest stay in the store.
+		slog.Error("some saved games could not be restored", "err", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return server.Serve(ctx, api.ListenConfig{
+		Addr:              cfg.Addr,
+		Socket:            cfg.Socket,
+		TLSCert:           cfg.TLSCert,
+		TLSKey:            cfg.TLSKey,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ShutdownTimeout:   cfg.ShutdownTimeout,
+	})
 }
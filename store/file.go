@@ -0,0 +1,129 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// gameFileSuffix ends the name of every game file in a File store.
+const gameFileSuffix = ".json"
+
+// validID matches the game IDs a File store accepts, so that an ID can never
+// name a file outside its directory.
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// File is a Store that keeps each game in its own file in a directory.
+// Files are replaced atomically, so a crash mid-save leaves the previous state.
+type File struct {
+	dir string
+}
+
+// NewFile returns a store keeping its games in dir, creating the directory if
+// needed. It fails if the directory cannot be written to.
+func NewFile(dir string) (*File, error) {
+	if dir == "" {
+		return nil, errors.New("the file store needs a directory")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating store directory: %w", err)
+	}
+	f := &File{dir: dir}
+	if err := f.Ping(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Ping implements Store by creating and removing a file in the directory.
+func (f *File) Ping() error {
+	probe, err := os.CreateTemp(f.dir, ".probe-*")
+	if err != nil {
+		return fmt.Errorf("store directory %s is not writable: %w", f.dir, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// path returns the file a game is kept in.
+func (f *File) path(id string) (string, error) {
+	if !validID.MatchString(id) {
+		return "", fmt.Errorf("invalid game ID %q", id)
+	}
+	return filepath.Join(f.dir, id+gameFileSuffix), nil
+}
+
+// Save implements Store.
+func (f *File) Save(id string, data []byte) error {
+	path, err := f.path(id)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.dir, ".save-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load implements Store.
+func (f *File) Load(id string) ([]byte, error) {
+	path, err := f.path(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Delete implements Store.
+func (f *File) Delete(id string) error {
+	path, err := f.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List implements Store.
+func (f *File) List() ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, gameFileSuffix) {
+			continue
+		}
+		if id := strings.TrimSuffix(name, gameFileSuffix); validID.MatchString(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
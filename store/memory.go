@@ -0,0 +1,62 @@
+package store
+
+import (
+	"sort"
+	"sync"
+)
+
+// Memory is a Store that keeps games in memory, so they only survive as long
+// as the process. It is the default, and is useful for tests.
+type Memory struct {
+	mu    sync.RWMutex
+	games map[string][]byte
+}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{games: make(map[string][]byte)}
+}
+
+// Save implements Store.
+func (m *Memory) Save(id string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.games[id] = append([]byte(nil), data...)
+	return nil
+}
+
+// Load implements Store.
+func (m *Memory) Load(id string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.games[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Delete implements Store.
+func (m *Memory) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.games, id)
+	return nil
+}
+
+// List implements Store.
+func (m *Memory) List() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]string, 0, len(m.games))
+	for id := range m.games {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Ping implements Store. Memory is always reachable.
+func (m *Memory) Ping() error {
+	return nil
+}
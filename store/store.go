@@ -0,0 +1,43 @@
+// Package store keeps the saved state of games between server restarts.
+// A store holds opaque, already encoded game states keyed by game ID, so it
+// knows nothing about the game itself.
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned when a store holds no state for a game.
+var ErrNotFound = errors.New("game not found in store")
+
+// Store saves and loads encoded game states by game ID.
+// Implementations are safe for concurrent use.
+type Store interface {
+	// Save stores the state of a game, replacing any earlier state.
+	Save(id string, data []byte) error
+	// Load returns the saved state of a game, or ErrNotFound.
+	Load(id string) ([]byte, error)
+	// Delete removes the saved state of a game. Deleting a game that is not
+	// stored is not an error.
+	Delete(id string) error
+	// List returns the IDs of every stored game.
+	List() ([]string, error)
+	// Ping checks that games could be saved right now.
+	Ping() error
+}
+
+// Backends lists the names Open accepts.
+var Backends = []string{"memory", "file"}
+
+// Open returns the store for a backend name. dir is the directory the file
+// backend keeps its games in; the memory backend ignores it.
+func Open(backend, dir string) (Store, error) {
+	switch backend {
+	case "memory":
+		return NewMemory(), nil
+	case "file":
+		return NewFile(dir)
+	}
+	return nil, fmt.Errorf("unknown store backend %q (want one of %v)", backend, Backends)
+}
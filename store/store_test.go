@@ -0,0 +1,98 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+	file, err := NewFile(filepath.Join(t.TempDir(), "games"))
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	for name, s := range map[string]Store{"memory": NewMemory(), "file": file} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Load("g1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound for a missing game, but got %v", err)
+			}
+			if err := s.Save("g1", []byte(`{"v":1}`)); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+			if err := s.Save("g1", []byte(`{"v":2}`)); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+			s.Save("g2", []byte(`{}`))
+
+			data, err := s.Load("g1")
+			if err != nil || string(data) != `{"v":2}` {
+				t.Errorf("expected the latest state back, but got %q, %v", data, err)
+			}
+			ids, _ := s.List()
+			if len(ids) != 2 || ids[0] != "g1" || ids[1] != "g2" {
+				t.Errorf("expected [g1 g2], but got %v", ids)
+			}
+
+			if err := s.Delete("g1"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if err := s.Delete("g1"); err != nil {
+				t.Errorf("expected deleting a missing game to succeed, but got %v", err)
+			}
+			if _, err := s.Load("g1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound once deleted, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestFile_RejectsPathsOutsideItsDirectory(t *testing.T) {
+	s, err := NewFile(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	for _, id := range []string{"../escape", "a/b", ""} {
+		if err := s.Save(id, []byte(`{}`)); err == nil {
+			t.Errorf("expected Save(%q) to be rejected", id)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := Open("memory", ""); err != nil {
+		t.Errorf("expected the memory backend to open, but got %v", err)
+	}
+	if _, err := Open("file", ""); err == nil {
+		t.Error("expected the file backend to need a directory")
+	}
+	if _, err := Open("redis", ""); err == nil {
+		t.Error("expected an unknown backend to be rejected")
+	}
+
+	// A path that is a file, not a directory, cannot hold games.
+	notDir := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(notDir, nil, 0o644)
+	if _, err := Open("file", notDir); err == nil {
+		t.Error("expected a file in place of the directory to be rejected")
+	}
+}
+
+func TestFile_Ping(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "games")
+	s, err := NewFile(dir)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	if err := s.Ping(); err != nil {
+		t.Errorf("expected Ping to succeed, but got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected Ping to leave nothing behind, but found %d files", len(entries))
+	}
+
+	os.RemoveAll(dir)
+	if err := s.Ping(); err == nil {
+		t.Error("expected Ping to fail once the directory is gone")
+	}
+}